@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/milandamen/quisnix/compiler"
+)
+
+const usage = `usage: quisnix <command> [arguments]
+
+commands:
+  build [--emit=<stage>] -o <output> <file.qx>...
+        compile the given files into LLVM IR, or write the result of
+        an earlier stage: tokens, ast, typed-ast or ir (default);
+        flags may come before or after the files
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run executes the command given in args and returns the exit code of the program.
+func run(args []string, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "build":
+		return runBuild(args[1:], stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stderr, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command '%s'\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+func runBuild(args []string, stderr io.Writer) int {
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "path of the output file, or - for standard output")
+	emit := fs.String("emit", "ir", "stage to write the result of: tokens, ast, typed-ast or ir")
+
+	// Parsing stops at the first file, so it continues after every file to also allow flags after the files.
+	var paths []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+
+		// Everything after "--" is a file, even when it looks like a flag.
+		if len(args) > fs.NArg() && args[len(args)-fs.NArg()-1] == "--" {
+			paths = append(paths, fs.Args()...)
+			break
+		}
+
+		paths = append(paths, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	stage, err := compiler.ParseStage(*emit)
+	if err != nil {
+		fmt.Fprintf(stderr, "build: %s\n", err)
+		return 2
+	}
+
+	if *output == "" {
+		fmt.Fprintln(stderr, "build: missing output path (-o)")
+		return 2
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(stderr, "build: no source files given")
+		return 2
+	}
+
+	files := make([]compiler.SourceFile, 0, len(paths))
+	for _, path := range paths {
+		if filepath.Ext(path) != ".qx" {
+			fmt.Fprintf(stderr, "build: '%s' is not a .qx file\n", path)
+			return 2
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "build: %s\n", err)
+			return 1
+		}
+
+		files = append(files, compiler.SourceFile{Name: path, Reader: bytes.NewReader(src)})
+	}
+
+	b := bytes.Buffer{}
+	c := compiler.Compiler{}
+	if err := c.Emit(&b, stage, files...); err != nil {
+		fmt.Fprintf(stderr, "build: %s\n", err)
+		return 1
+	}
+
+	if *output == "-" {
+		if _, err := b.WriteTo(os.Stdout); err != nil {
+			fmt.Fprintf(stderr, "build: %s\n", err)
+			return 1
+		}
+
+		return 0
+	}
+
+	if err := os.WriteFile(*output, b.Bytes(), 0644); err != nil {
+		fmt.Fprintf(stderr, "build: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Command", func() {
+	var dir string
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	It("should build the given files into LLVM IR", func() {
+		mainPath := writeFile("main.qx", "func main() Int {\n\treturn test(2);\n}\n")
+		testPath := writeFile("test.qx", "func test(asd Int) Int {\n\treturn 2 + asd;\n}\n")
+		output := filepath.Join(dir, "program.ll")
+
+		stderr := bytes.Buffer{}
+		Expect(run([]string{"build", "-o", output, mainPath, testPath}, &stderr)).To(Equal(0))
+		Expect(stderr.String()).To(BeEmpty())
+
+		ir, err := os.ReadFile(output)
+		Expect(err).To(Succeed())
+		Expect(string(ir)).To(ContainSubstring("define i32 @main()"))
+		Expect(string(ir)).To(ContainSubstring("call i32 @qx_uf_test(i32 2)"))
+	})
+	It("should write the result of the stage given by --emit", func() {
+		mainPath := writeFile("main.qx", "func main() {\n}\n")
+		output := filepath.Join(dir, "main.tokens")
+
+		stderr := bytes.Buffer{}
+		Expect(run([]string{"build", "--emit=tokens", "-o", output, mainPath}, &stderr)).To(Equal(0))
+
+		tokens, err := os.ReadFile(output)
+		Expect(err).To(Succeed())
+		Expect(string(tokens)).To(HavePrefix("# " + mainPath + "\n"))
+	})
+	It("should parse flags that come after the files", func() {
+		mainPath := writeFile("main.qx", "func main() Int {\n\treturn test(2);\n}\n")
+		testPath := writeFile("test.qx", "func test(asd Int) Int {\n\treturn 2 + asd;\n}\n")
+		output := filepath.Join(dir, "main.tokens")
+
+		stderr := bytes.Buffer{}
+		Expect(run([]string{"build", mainPath, "-o", output, testPath, "--emit=tokens"}, &stderr)).To(Equal(0))
+		Expect(stderr.String()).To(BeEmpty())
+
+		tokens, err := os.ReadFile(output)
+		Expect(err).To(Succeed())
+		Expect(string(tokens)).To(HavePrefix("# " + mainPath + "\n"))
+		Expect(string(tokens)).To(ContainSubstring("# " + testPath + "\n"))
+	})
+	It("should fail without writing the output when a file does not compile", func() {
+		mainPath := writeFile("main.qx", "func main() Int {\n\treturn x;\n}\n")
+		output := filepath.Join(dir, "program.ll")
+
+		stderr := bytes.Buffer{}
+		Expect(run([]string{"build", "-o", output, mainPath}, &stderr)).To(Equal(1))
+		Expect(stderr.String()).To(ContainSubstring("no variable or function found for identifier 'x'"))
+		Expect(output).ToNot(BeAnExistingFile())
+	})
+	It("should fail arguments that are not valid", func() {
+		mainPath := writeFile("main.qx", "func main() {\n}\n")
+		args := map[string][]string{
+			"unknown command 'run'":           {"run", mainPath},
+			"build: missing output path (-o)": {"build", mainPath},
+			"build: no source files given":    {"build", "-o", "-"},
+			"'-o' is not a .qx file":          {"build", "-o", "-", mainPath, "--", "-o"},
+			"is not a .qx file":               {"build", "-o", "-", filepath.Join(dir, "main.go")},
+			"build: unknown stage 'bytecode'": {"build", "--emit=bytecode", "-o", "-", mainPath},
+		}
+		for message, a := range args {
+			stderr := bytes.Buffer{}
+			Expect(run(a, &stderr)).To(Equal(2), message)
+			Expect(stderr.String()).To(ContainSubstring(message))
+		}
+	})
+})
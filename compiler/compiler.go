@@ -0,0 +1,140 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/milandamen/quisnix/lexer"
+	"github.com/milandamen/quisnix/parser"
+	"github.com/milandamen/quisnix/printer"
+	"github.com/milandamen/quisnix/semanalyzer"
+)
+
+// Stage is a stage of the compiler whose result can be emitted.
+type Stage int
+
+const (
+	TokensStage   Stage = iota // Token stream of every file.
+	ASTStage                   // Declaration tree of every file with resolved declarations.
+	TypedASTStage              // Declaration tree of every file annotated with the types of expressions.
+	IRStage                    // Resulting LLVM IR module.
+)
+
+var stageNames = map[string]Stage{
+	"tokens":    TokensStage,
+	"ast":       ASTStage,
+	"typed-ast": TypedASTStage,
+	"ir":        IRStage,
+}
+
+// ParseStage returns the stage belonging to the given name, as used by the --emit flag of the driver.
+func ParseStage(name string) (Stage, error) {
+	stage, ok := stageNames[name]
+	if !ok {
+		return 0, errors.Errorf("unknown stage '%s': expected one of tokens, ast, typed-ast, ir", name)
+	}
+
+	return stage, nil
+}
+
+// SourceFile is a single Quisnix source file that is part of the program being compiled.
+type SourceFile struct {
+	Name   string // Name used to identify the file in diagnostics.
+	Reader io.Reader
+}
+
+// Compiler runs all stages of the compiler on a set of source files.
+type Compiler struct{}
+
+// Build compiles the given source files into a single LLVM IR module and writes it to w.
+// Nothing is written to w when any of the stages fails.
+func (c *Compiler) Build(w io.Writer, files ...SourceFile) error {
+	return c.Emit(w, IRStage, files...)
+}
+
+// Emit runs the compiler up to and including the given stage, and writes the result of that stage to w.
+// The token and tree stages are written per file, each preceded by a line holding the name of the file.
+// Nothing is written to w when any of the stages fails.
+func (c *Compiler) Emit(w io.Writer, stage Stage, files ...SourceFile) error {
+	if len(files) == 0 {
+		return errors.New("no source files given")
+	}
+
+	b := bytes.Buffer{}
+	fileTokens := make([][]lexer.Token, len(files))
+	for i, f := range files {
+		l := lexer.Lexer{}
+		tokens, err := l.Parse(f.Reader)
+		if err != nil {
+			return errors.Wrap(err, f.Name)
+		}
+
+		fileTokens[i] = tokens
+	}
+
+	if stage == TokensStage {
+		tp := printer.TokenPrinter{}
+		for i, f := range files {
+			writeFileHeader(&b, f)
+			if err := tp.Print(&b, fileTokens[i]); err != nil {
+				return err
+			}
+		}
+
+		_, err := b.WriteTo(w)
+		return err
+	}
+
+	p := parser.Parser{}
+	fileScope := parser.NewFileScope(parser.NewBuiltInScope())
+	fileDeclarations := make([][]parser.Declaration, len(files))
+	declarations := make([]parser.Declaration, 0)
+	for i, f := range files {
+		decls, err := p.ParseFile(fileTokens[i], fileScope)
+		if err != nil {
+			return errors.Wrap(err, f.Name)
+		}
+
+		fileDeclarations[i] = decls
+		declarations = append(declarations, decls...)
+	}
+
+	if err := p.ResolveUnknownTypes(); err != nil {
+		return err
+	}
+
+	if stage != ASTStage {
+		a := semanalyzer.SemAnalyzer{}
+		if _, err := a.Analyze(declarations, fileScope); err != nil {
+			return err
+		}
+	}
+
+	switch stage {
+	case ASTStage, TypedASTStage:
+		ap := printer.ASTPrinter{Types: stage == TypedASTStage}
+		for i, f := range files {
+			writeFileHeader(&b, f)
+			if err := ap.Print(&b, fileDeclarations[i]); err != nil {
+				return err
+			}
+		}
+	case IRStage:
+		pr := printer.LLVMPrinter{}
+		if err := pr.Print(&b, declarations, fileScope); err != nil {
+			return errors.Wrap(err, "could not generate LLVM IR")
+		}
+	default:
+		return errors.Errorf("unknown stage '%d'", stage)
+	}
+
+	_, err := b.WriteTo(w)
+	return err
+}
+
+func writeFileHeader(b *bytes.Buffer, f SourceFile) {
+	_, _ = fmt.Fprintf(b, "# %s\n", f.Name)
+}
@@ -0,0 +1,171 @@
+package quisnix
+
+import (
+	"bytes"
+
+	"github.com/milandamen/quisnix/compiler"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compiler", func() {
+	It("should build a program spread over multiple files", func() {
+		c := compiler.Compiler{}
+
+		mainFile := `
+func main() Int {
+	return test(2);
+}
+`
+		testFile := `
+func test(asd Int) Int {
+	return 2 + asd;
+}
+`
+		b := bytes.Buffer{}
+		err := c.Build(&b,
+			compiler.SourceFile{Name: "main.qx", Reader: bytes.NewBufferString(mainFile)},
+			compiler.SourceFile{Name: "test.qx", Reader: bytes.NewBufferString(testFile)})
+		Expect(err).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("define i32 @main()"))
+		Expect(b.String()).To(ContainSubstring("call i32 @qx_uf_test(i32 2)"))
+	})
+	It("should report the file that failed and write nothing", func() {
+		c := compiler.Compiler{}
+
+		b := bytes.Buffer{}
+		err := c.Build(&b,
+			compiler.SourceFile{Name: "main.qx", Reader: bytes.NewBufferString("func main() {\n}\n")},
+			compiler.SourceFile{Name: "broken.qx", Reader: bytes.NewBufferString("func test() {\n\t$\n}\n")})
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("broken.qx: unknown token at line 2 column 2"))
+		Expect(b.Len()).To(Equal(0))
+	})
+	It("should fail without source files", func() {
+		c := compiler.Compiler{}
+		Expect(c.Build(&bytes.Buffer{})).ToNot(Succeed())
+	})
+	It("should emit the token stream of every file", func() {
+		c := compiler.Compiler{}
+
+		b := bytes.Buffer{}
+		err := c.Emit(&b, compiler.TokensStage,
+			compiler.SourceFile{Name: "main.qx", Reader: bytes.NewBufferString("func main() {\n\ta = 'b' + \"c\";\n}\n")})
+		Expect(err).To(Succeed())
+		Expect(b.String()).To(Equal(`# main.qx
+1:1	func
+1:6	identifier main
+1:10	(
+1:11	)
+1:13	{
+2:2	identifier a
+2:4	=
+2:6	character 'b'
+2:10	+
+2:12	string "c"
+2:15	;
+3:1	}
+`))
+	})
+	It("should emit the declaration tree with and without types", func() {
+		c := compiler.Compiler{}
+
+		program := `
+func main() Int {
+	var a Int;
+	a = test(a) * 2;
+	return a;
+}
+
+func test(asd Int) Int {
+	return asd;
+}
+`
+		b := bytes.Buffer{}
+		err := c.Emit(&b, compiler.ASTStage, compiler.SourceFile{Name: "main.qx", Reader: bytes.NewBufferString(program)})
+		Expect(err).To(Succeed())
+		Expect(b.String()).To(Equal(`# main.qx
+func main() Int @2:1
+  var a Int @3:2
+  assign a -> var@3:2 @4:2
+    multiply @4:14
+      call @4:10
+        identifier test -> func@8:1 @4:6
+        identifier a -> var@3:2 @4:11
+      integer 2 @4:16
+  return @5:2
+    identifier a -> var@3:2 @5:9
+func test(asd Int) Int @8:1
+  return @9:2
+    identifier asd -> var@8:11 @9:9
+`))
+
+		b = bytes.Buffer{}
+		err = c.Emit(&b, compiler.TypedASTStage, compiler.SourceFile{Name: "main.qx", Reader: bytes.NewBufferString(program)})
+		Expect(err).To(Succeed())
+		Expect(b.String()).To(ContainSubstring(`
+    multiply : Int @4:14
+      call : Int @4:10
+`))
+	})
+	It("should emit calls of functions without return values without types", func() {
+		c := compiler.Compiler{}
+
+		program := `
+func main() {
+	nothing(1);
+}
+
+func nothing(n Int) {
+}
+`
+		b := bytes.Buffer{}
+		err := c.Emit(&b, compiler.TypedASTStage, compiler.SourceFile{Name: "main.qx", Reader: bytes.NewBufferString(program)})
+		Expect(err).To(Succeed())
+		Expect(b.String()).To(Equal(`# main.qx
+func main() @2:1
+  call @3:2
+    identifier nothing -> func@6:1 : func(Int) @3:2
+    integer 1 : Int @3:10
+func nothing(n Int) @6:1
+`))
+	})
+	It("should build programs at the same time", func() {
+		program := `
+type Point {
+	x Int;
+	ys []Int;
+}
+
+func main() Int {
+	var p = Point{x: 1};
+	p.ys = append(p.ys, p.x);
+	var count func([]Int) Int = func(ys []Int) Int {
+		return len(ys);
+	};
+	return count(p.ys[0:1]);
+}
+`
+		results := make([]chan error, 8)
+		for i := range results {
+			results[i] = make(chan error, 1)
+			go func(result chan error) {
+				defer GinkgoRecover()
+				c := compiler.Compiler{}
+				result <- c.Build(&bytes.Buffer{}, compiler.SourceFile{Name: "main.qx", Reader: bytes.NewBufferString(program)})
+			}(results[i])
+		}
+		for _, result := range results {
+			Expect(<-result).To(Succeed())
+		}
+	})
+	It("should parse stage names", func() {
+		stage, err := compiler.ParseStage("typed-ast")
+		Expect(err).To(Succeed())
+		Expect(stage).To(Equal(compiler.TypedASTStage))
+
+		_, err = compiler.ParseStage("bytecode")
+		Expect(err).ToNot(Succeed())
+	})
+})
@@ -3,89 +3,282 @@ package lexer
 import (
 	"bufio"
 	"io"
+	"math/big"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 )
 
+// Lexer turns source code into tokens. The source is read as a stream, so lines can be of any length
+// and tokens like block comments and raw string literals can span multiple lines.
 type Lexer struct {
+	reader *bufio.Reader
+	line   int
+	column int
+	err    error // Error other than io.EOF that occurred while reading.
+
+	// Comments that are found since the last token, which will be attached to the next token.
+	comments []Comment
+
+	// Token that was read ahead, so that comments at the end of the file can be attached to the last token.
+	nextToken Token
+	nextErr   error
+	started   bool
 }
 
-func (l Lexer) Parse(r io.Reader) ([]Token, error) {
+// NewLexer returns a lexer that reads the tokens from r one at a time using Next.
+func NewLexer(r io.Reader) *Lexer {
+	l := &Lexer{}
+	l.reset(r)
+	return l
+}
+
+// Parse reads all tokens from r.
+func (l *Lexer) Parse(r io.Reader) ([]Token, error) {
+	l.reset(r)
+
 	tokens := make([]Token, 0)
-	scanner := bufio.NewScanner(r)
-
-	lineIdx := 0
-	for scanner.Scan() {
-		line := scanner.Bytes()
-
-		column := 0
-		lineLen := len(line)
-		for column < lineLen {
-			if l.isWhitespaceCharacter(line[column]) {
-				column++
-				continue
-			}
-			if t := l.getDoubleCharacterToken(line, lineIdx, column); t != nil {
-				tokens = append(tokens, t)
-				column += 2
-				continue
-			}
-			if t := l.getSingleCharacterToken(line, lineIdx, column); t != nil {
-				tokens = append(tokens, t)
-				column++
-				continue
+	for true {
+		t, err := l.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
+// Next returns the next token. When there are no more tokens, io.EOF is returned.
+// After an error, every following call returns the same error.
+func (l *Lexer) Next() (Token, error) {
+	if l.reader == nil {
+		return nil, io.EOF
+	}
+	if !l.started {
+		l.started = true
+		l.nextToken, l.nextErr = l.lexToken()
+	}
+
+	t, err := l.nextToken, l.nextErr
+	if err != nil {
+		return nil, err
+	}
+
+	l.nextToken, l.nextErr = l.lexToken()
+	if l.nextErr == io.EOF && len(l.comments) > 0 {
+		// Comments at the end of the file belong to the last token.
+		t = withTrivia(t, t.LeadingTrivia(), l.comments)
+		l.comments = make([]Comment, 0)
+	}
+
+	return t, nil
+}
+
+func (l *Lexer) reset(r io.Reader) {
+	*l = Lexer{
+		reader:   bufio.NewReader(r),
+		comments: make([]Comment, 0),
+	}
+}
+
+func (l *Lexer) lexToken() (Token, error) {
+	for true {
+		c0, ok := l.peekByte(0)
+		if !ok {
+			if l.err != nil {
+				return nil, errors.Wrap(l.err, "error reading source")
 			}
+			return nil, io.EOF
+		}
 
-			t, newColumn, err := l.getLiteralToken(line, lineIdx, column)
+		if l.isWhitespaceCharacter(c0) {
+			l.readByte()
+			continue
+		}
+
+		line, column := l.line, l.column
+		if c1, _ := l.peekByte(1); c0 == '/' && c1 == '/' {
+			l.comments = append(l.comments, l.readLineComment())
+			continue
+		} else if c0 == '/' && c1 == '*' {
+			c, err := l.readBlockComment()
 			if err != nil {
-				return nil, errors.Wrapf(err, "error at line %d column %d", lineIdx+1, column+1)
-			}
-			if t != nil {
-				tokens = append(tokens, t)
-				column = newColumn
-				continue
+				return nil, err
 			}
 
-			t, newColumn = l.getKeywordToken(line, lineIdx, column)
-			if t != nil {
-				tokens = append(tokens, t)
-				column = newColumn
-				continue
-			}
+			l.comments = append(l.comments, c)
+			continue
+		}
+
+		t, err := l.lexNonTriviaToken(c0, line, column)
+		if err != nil {
+			return nil, err
+		}
 
-			t, newColumn = l.getIdentifierToken(line, lineIdx, column)
-			if t != nil {
-				tokens = append(tokens, t)
-				column = newColumn
-				continue
+		if len(l.comments) > 0 {
+			t = withTrivia(t, l.comments, nil)
+			l.comments = make([]Comment, 0)
+		}
+
+		return t, nil
+	}
+
+	return nil, errors.New("unreachable code: Lexer.lexToken after loop")
+}
+
+func (l *Lexer) lexNonTriviaToken(c0 byte, line, column int) (Token, error) {
+	if c1, ok := l.peekByte(1); ok {
+		if c2, ok := l.peekByte(2); ok {
+			if t := l.getTripleCharacterToken(c0, c1, c2, line, column); t != nil {
+				l.readBytes(3)
+				return t, nil
 			}
+		}
+		if t := l.getDoubleCharacterToken(c0, c1, line, column); t != nil {
+			l.readBytes(2)
+			return t, nil
+		}
+	}
+	if t := l.getSingleCharacterToken(c0, line, column); t != nil {
+		l.readByte()
+		return t, nil
+	}
+
+	t, err := l.getLiteralToken(c0, line, column)
+	if err != nil {
+		return nil, err
+	}
+	if t != nil {
+		return t, nil
+	}
+
+	if t := l.getKeywordOrIdentifierToken(line, column); t != nil {
+		return t, nil
+	}
+
+	return nil, errors.Errorf("unknown token at line %d column %d", line+1, column+1)
+}
 
-			return nil, errors.Errorf("unknown token at line %d column %d", lineIdx+1, column+1)
+// peekByte returns the byte at the given offset from the current position without consuming it.
+// When the end of the input is reached, false is returned.
+func (l *Lexer) peekByte(offset int) (byte, bool) {
+	b, err := l.reader.Peek(offset + 1)
+	if len(b) <= offset {
+		if err != nil && err != io.EOF && l.err == nil {
+			l.err = err
 		}
+		return 0, false
+	}
 
-		lineIdx++
+	return b[offset], true
+}
+
+// readByte consumes the current byte and keeps track of the line and column of the next byte.
+func (l *Lexer) readByte() (byte, bool) {
+	c, err := l.reader.ReadByte()
+	if err != nil {
+		if err != io.EOF && l.err == nil {
+			l.err = err
+		}
+		return 0, false
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, errors.Wrap(err, "error scanning lines")
+	if c == '\n' {
+		l.line++
+		l.column = 0
+	} else {
+		l.column++
 	}
 
-	return tokens, nil
+	return c, true
+}
+
+func (l *Lexer) readBytes(n int) {
+	for i := 0; i < n; i++ {
+		l.readByte()
+	}
 }
 
 func (Lexer) isWhitespaceCharacter(char byte) bool {
-	return char == ' ' || char == '\t' || char == '\r'
+	return char == ' ' || char == '\t' || char == '\r' || char == '\n'
 }
 
-func (Lexer) getDoubleCharacterToken(line []byte, lineIdx, column int) Token {
-	if column+1 >= len(line) {
-		return nil
+func (l *Lexer) readLineComment() Comment {
+	c := Comment{line: l.line, column: l.column}
+	text := strings.Builder{}
+	for true {
+		b, ok := l.peekByte(0)
+		if !ok || b == '\n' {
+			break
+		}
+
+		l.readByte()
+		text.WriteByte(b)
+	}
+
+	c.text = strings.TrimRight(text.String(), "\r")
+	return c
+}
+
+// readBlockComment reads a block comment, which may span multiple lines and may contain nested block comments.
+func (l *Lexer) readBlockComment() (Comment, error) {
+	c := Comment{block: true, line: l.line, column: l.column}
+	text := strings.Builder{}
+	depth := 0
+	for true {
+		c0, ok := l.peekByte(0)
+		if !ok {
+			return Comment{}, errors.Errorf("unterminated block comment starting at line %d column %d",
+				c.UFLine(), c.UFColumn())
+		}
+
+		c1, _ := l.peekByte(1)
+		if c0 == '/' && c1 == '*' {
+			depth++
+			l.readBytes(2)
+			text.WriteString("/*")
+			continue
+		}
+		if c0 == '*' && c1 == '/' {
+			depth--
+			l.readBytes(2)
+			text.WriteString("*/")
+			if depth == 0 {
+				break
+			}
+			continue
+		}
+
+		l.readByte()
+		if c0 != '\r' {
+			text.WriteByte(c0)
+		}
+	}
+
+	c.text = text.String()
+	return c, nil
+}
+
+func (Lexer) getTripleCharacterToken(c0, c1, c2 byte, lineIdx, column int) Token {
+	if c0 == '<' && c1 == '<' && c2 == '=' {
+		return basicToken{tokenType: ShiftLeftAssign, line: lineIdx, column: column}
+	}
+	if c0 == '>' && c1 == '>' && c2 == '=' {
+		return basicToken{tokenType: ShiftRightAssign, line: lineIdx, column: column}
 	}
-	c0 := line[column]
-	c1 := line[column+1]
 
+	return nil
+}
+
+func (Lexer) getDoubleCharacterToken(c0, c1 byte, lineIdx, column int) Token {
 	if c1 == '=' {
 		if c0 == '+' {
 			return basicToken{tokenType: AddAssign, line: lineIdx, column: column}
@@ -93,6 +286,24 @@ func (Lexer) getDoubleCharacterToken(line []byte, lineIdx, column int) Token {
 		if c0 == '-' {
 			return basicToken{tokenType: SubtractAssign, line: lineIdx, column: column}
 		}
+		if c0 == '*' {
+			return basicToken{tokenType: MultiplyAssign, line: lineIdx, column: column}
+		}
+		if c0 == '/' {
+			return basicToken{tokenType: DivideAssign, line: lineIdx, column: column}
+		}
+		if c0 == '%' {
+			return basicToken{tokenType: ModuloAssign, line: lineIdx, column: column}
+		}
+		if c0 == '&' {
+			return basicToken{tokenType: BitwiseAndAssign, line: lineIdx, column: column}
+		}
+		if c0 == '|' {
+			return basicToken{tokenType: BitwiseOrAssign, line: lineIdx, column: column}
+		}
+		if c0 == '^' {
+			return basicToken{tokenType: BitwiseXorAssign, line: lineIdx, column: column}
+		}
 		if c0 == '=' {
 			return OperatorToken{basicToken{tokenType: Equal, line: lineIdx, column: column}}
 		}
@@ -120,13 +331,17 @@ func (Lexer) getDoubleCharacterToken(line []byte, lineIdx, column int) Token {
 	if c0 == '|' && c1 == '|' {
 		return OperatorToken{basicToken{tokenType: Or, line: lineIdx, column: column}}
 	}
+	if c0 == '<' && c1 == '<' {
+		return OperatorToken{basicToken{tokenType: ShiftLeft, line: lineIdx, column: column}}
+	}
+	if c0 == '>' && c1 == '>' {
+		return OperatorToken{basicToken{tokenType: ShiftRight, line: lineIdx, column: column}}
+	}
 
 	return nil
 }
 
-func (Lexer) getSingleCharacterToken(line []byte, lineIdx, column int) Token {
-	c0 := line[column]
-
+func (Lexer) getSingleCharacterToken(c0 byte, lineIdx, column int) Token {
 	if c0 == '+' {
 		return OperatorToken{basicToken{tokenType: Add, line: lineIdx, column: column}}
 	}
@@ -139,6 +354,21 @@ func (Lexer) getSingleCharacterToken(line []byte, lineIdx, column int) Token {
 	if c0 == '/' {
 		return OperatorToken{basicToken{tokenType: Divide, line: lineIdx, column: column}}
 	}
+	if c0 == '%' {
+		return OperatorToken{basicToken{tokenType: Modulo, line: lineIdx, column: column}}
+	}
+	if c0 == '&' {
+		return OperatorToken{basicToken{tokenType: BitwiseAnd, line: lineIdx, column: column}}
+	}
+	if c0 == '|' {
+		return OperatorToken{basicToken{tokenType: BitwiseOr, line: lineIdx, column: column}}
+	}
+	if c0 == '^' {
+		return OperatorToken{basicToken{tokenType: BitwiseXor, line: lineIdx, column: column}}
+	}
+	if c0 == '~' {
+		return OperatorToken{basicToken{tokenType: BitwiseNot, line: lineIdx, column: column}}
+	}
 	if c0 == '=' {
 		return basicToken{tokenType: Assign, line: lineIdx, column: column}
 	}
@@ -178,50 +408,42 @@ func (Lexer) getSingleCharacterToken(line []byte, lineIdx, column int) Token {
 	if c0 == ';' {
 		return basicToken{tokenType: Semicolon, line: lineIdx, column: column}
 	}
+	if c0 == ':' {
+		return basicToken{tokenType: Colon, line: lineIdx, column: column}
+	}
 
 	return nil
 }
 
-func (Lexer) getLiteralToken(line []byte, lineIdx, column int) (Token, int, error) {
-	lineLen := len(line)
-	c0 := line[column]
+func (l *Lexer) getLiteralToken(c0 byte, lineIdx, column int) (Token, error) {
 	if c0 >= '0' && c0 <= '9' {
-		untilCol := column + 1
-		for untilCol < lineLen {
-			cx := line[untilCol]
-			if cx >= '0' && cx <= '9' {
-				untilCol++
-			} else {
-				break
-			}
+		return l.getNumberToken(lineIdx, column)
+	}
+
+	if c0 == '\'' {
+		raw := strings.Builder{}
+		l.readRawByte(&raw)
+		c1, ok := l.peekByte(0)
+		if ok && c1 == '\'' {
+			return nil, errorAt(lineIdx, column, "character literal can not be empty")
+		}
+		if !ok || c1 == '\n' {
+			return nil, errorAt(lineIdx, column, "unexpected end of line before end of character literal")
 		}
 
-		s := line[column:untilCol] // From column until (exclusive) untilCol
-		integer, err := strconv.Atoi(string(s))
+		character, isByte, err := l.readLiteralCharacter(&raw)
 		if err != nil {
-			return nil, 0, errors.Wrapf(err, "could not parse '%s' into integer", s)
+			return nil, err
 		}
 
-		return IntegerToken{
-			basicToken: basicToken{
-				tokenType: Integer,
-				line:      lineIdx,
-				column:    column,
-			},
-			integer: integer,
-		}, untilCol, nil
-	}
-
-	if c0 == '\'' {
-		if column+1 < lineLen && line[column+1] == '\'' {
-			return nil, 0, errors.New("character literal can not be empty")
-		}
-		if column+2 >= lineLen {
-			return nil, 0, errors.New("unexpected end of line before end of character literal")
+		cx, ok := l.peekByte(0)
+		if !ok || cx == '\n' {
+			return nil, errorAt(lineIdx, column, "unexpected end of line before end of character literal")
 		}
-		if line[column+2] != '\'' {
-			return nil, 0, errors.New("character literal may only be 1 character long")
+		if cx != '\'' {
+			return nil, errorAt(lineIdx, column, "character literal may only be 1 character long")
 		}
+		l.readRawByte(&raw)
 
 		return CharacterToken{
 			basicToken: basicToken{
@@ -229,83 +451,382 @@ func (Lexer) getLiteralToken(line []byte, lineIdx, column int) (Token, int, erro
 				line:      lineIdx,
 				column:    column,
 			},
-			character: line[column+1],
-		}, column + 3, nil
+			character: character,
+			isByte:    isByte,
+			raw:       raw.String(),
+		}, nil
 	}
 
 	if c0 == '"' {
-		untilCol := column + 1
-		closed := false
-		for untilCol < lineLen {
-			if line[untilCol] == '"' {
-				closed = true
-				untilCol++
+		raw := strings.Builder{}
+		l.readRawByte(&raw)
+		s := strings.Builder{}
+		for true {
+			cx, ok := l.peekByte(0)
+			if !ok || cx == '\n' {
+				return nil, errorAt(lineIdx, column, "unexpected end of line before end of string literal")
+			}
+			if cx == '"' {
+				l.readRawByte(&raw)
 				break
 			}
 
-			untilCol++
+			character, isByte, err := l.readLiteralCharacter(&raw)
+			if err != nil {
+				return nil, err
+			}
+
+			if isByte {
+				s.WriteByte(byte(character))
+			} else {
+				s.WriteRune(character)
+			}
 		}
 
-		if !closed {
-			return nil, 0, errors.New("unexpected end of line before end of string literal")
+		return StringToken{
+			basicToken: basicToken{
+				tokenType: String,
+				line:      lineIdx,
+				column:    column,
+			},
+			string: s.String(),
+			raw:    raw.String(),
+		}, nil
+	}
+
+	if c0 == '`' {
+		// Raw string literal, which can span multiple lines and does not support escape sequences.
+		raw := strings.Builder{}
+		l.readRawByte(&raw)
+		s := strings.Builder{}
+		for true {
+			cx, ok := l.readByte()
+			if !ok {
+				return nil, errorAt(lineIdx, column, "unexpected end of file before end of raw string literal")
+			}
+			if cx != '\r' {
+				raw.WriteByte(cx)
+			}
+			if cx == '`' {
+				break
+			}
+			if cx != '\r' {
+				s.WriteByte(cx)
+			}
 		}
 
-		s := line[column+1 : untilCol-1]
 		return StringToken{
 			basicToken: basicToken{
 				tokenType: String,
 				line:      lineIdx,
 				column:    column,
 			},
-			string: string(s),
-		}, untilCol, nil
+			string: s.String(),
+			raw:    raw.String(),
+		}, nil
 	}
 
-	return nil, 0, nil
+	return nil, nil
 }
 
-func (Lexer) getKeywordToken(line []byte, lineIdx, column int) (Token, int) {
-	lineLen := len(line)
-	remainingLineString := string(line[column:])
-	for keyword, tokenType := range keywordMap {
-		untilCol := column + len(keyword)
-		if strings.HasPrefix(remainingLineString, keyword) && (untilCol >= lineLen || !(line[untilCol] >= 'a' && line[untilCol] <= 'z')) {
-			return basicToken{
-				tokenType: tokenType,
+// getNumberToken reads an integer or floating-point literal. Integers can be written in decimal, hexadecimal (0x),
+// octal (0o) or binary (0b) notation. Floating-point literals are decimal and have a fraction (1.5), an exponent (2e10)
+// or both. Digits may be separated by underscores.
+func (l *Lexer) getNumberToken(lineIdx, column int) (Token, error) {
+	raw := strings.Builder{}
+	base := 10
+	baseName := "decimal"
+	c0, _ := l.peekByte(0)
+	c1, _ := l.peekByte(1)
+	if c0 == '0' {
+		switch c1 {
+		case 'x', 'X':
+			base, baseName = 16, "hexadecimal"
+		case 'o', 'O':
+			base, baseName = 8, "octal"
+		case 'b', 'B':
+			base, baseName = 2, "binary"
+		}
+	}
+	if base != 10 {
+		l.readRawByte(&raw)
+		l.readRawByte(&raw)
+	}
+
+	digits := strings.Builder{}
+	numDigits := 0
+	hasFraction := false
+	hasExponent := false
+	lastWasDigit := base != 10 // An underscore may directly follow the prefix.
+	for true {
+		cx, ok := l.peekByte(0)
+		if !ok {
+			break
+		}
+
+		if cx == '.' && base == 10 && !hasFraction && !hasExponent {
+			// Only a fraction when followed by a digit, so that a period after an integer is still a separate token.
+			if next, ok := l.peekByte(1); !ok || next < '0' || next > '9' {
+				break
+			}
+			if !lastWasDigit {
+				return nil, errorAt(l.line, l.column-1, "'_' must separate successive digits")
+			}
+
+			hasFraction = true
+			lastWasDigit = false
+			digits.WriteByte(cx)
+			l.readRawByte(&raw)
+			continue
+		}
+
+		if (cx == 'e' || cx == 'E') && base == 10 && !hasExponent {
+			if !lastWasDigit {
+				return nil, errorAt(l.line, l.column-1, "'_' must separate successive digits")
+			}
+
+			hasExponent = true
+			lastWasDigit = false
+			digits.WriteByte(cx)
+			l.readRawByte(&raw)
+			if sign, ok := l.peekByte(0); ok && (sign == '+' || sign == '-') {
+				digits.WriteByte(sign)
+				l.readRawByte(&raw)
+			}
+			if d, ok := l.peekByte(0); !ok || d < '0' || d > '9' {
+				return nil, errorAt(l.line, l.column, "exponent has no digits")
+			}
+			continue
+		}
+
+		if !((cx >= '0' && cx <= '9') || (cx >= 'a' && cx <= 'z') || (cx >= 'A' && cx <= 'Z') || cx == '_') {
+			break
+		}
+
+		if cx == '_' {
+			if !lastWasDigit {
+				return nil, errorAt(l.line, l.column, "'_' must separate successive digits")
+			}
+
+			lastWasDigit = false
+			l.readRawByte(&raw)
+			continue
+		}
+
+		digit, isHex := getHexDigitValue(cx)
+		if !isHex || digit >= base {
+			return nil, errorAt(l.line, l.column, "invalid digit '%c' in %s literal", cx, baseName)
+		}
+
+		lastWasDigit = true
+		numDigits++
+		digits.WriteByte(cx)
+		l.readRawByte(&raw)
+	}
+
+	if numDigits == 0 {
+		return nil, errorAt(lineIdx, column, "%s literal has no digits", baseName)
+	}
+	if !lastWasDigit {
+		return nil, errorAt(l.line, l.column-1, "'_' must separate successive digits")
+	}
+
+	if hasFraction || hasExponent {
+		float, err := strconv.ParseFloat(digits.String(), 64)
+		if err != nil {
+			return nil, errorAt(lineIdx, column, "floating-point literal '%s' is out of range", raw.String())
+		}
+
+		return FloatToken{
+			basicToken: basicToken{
+				tokenType: Float,
 				line:      lineIdx,
 				column:    column,
-			}, untilCol
+			},
+			float: float,
+			raw:   raw.String(),
+		}, nil
+	}
+
+	integer, ok := new(big.Int).SetString(digits.String(), base)
+	if !ok {
+		return nil, errorAt(lineIdx, column, "could not parse '%s' into integer", raw.String())
+	}
+
+	return IntegerToken{
+		basicToken: basicToken{
+			tokenType: Integer,
+			line:      lineIdx,
+			column:    column,
+		},
+		integer: integer,
+		raw:     raw.String(),
+	}, nil
+}
+
+// readLiteralCharacter reads a single, possibly escaped, character of a character or string literal.
+// Characters written with a \xNN escape sequence are returned as a byte, because they do not have to form valid UTF-8.
+func (l *Lexer) readLiteralCharacter(raw *strings.Builder) (rune, bool, error) {
+	line, column := l.line, l.column
+	c0, _ := l.peekByte(0)
+	if c0 != '\\' {
+		r, err := l.readRune(raw)
+		return r, false, err
+	}
+
+	l.readRawByte(raw)
+	c1, ok := l.peekByte(0)
+	if !ok || c1 == '\n' {
+		return 0, false, errorAt(line, column, "unexpected end of line in escape sequence")
+	}
+
+	l.readRawByte(raw)
+	switch c1 {
+	case 'n':
+		return '\n', false, nil
+	case 't':
+		return '\t', false, nil
+	case 'r':
+		return '\r', false, nil
+	case '0':
+		return 0, false, nil
+	case '\\', '"', '\'':
+		return rune(c1), false, nil
+	case 'x':
+		value := 0
+		for i := 0; i < 2; i++ {
+			cx, ok := l.peekByte(0)
+			digit, isHex := getHexDigitValue(cx)
+			if !ok || !isHex {
+				return 0, false, errorAt(l.line, l.column, "escape sequence '\\x' must be followed by 2 hexadecimal digits")
+			}
+
+			l.readRawByte(raw)
+			value = value*16 + digit
+		}
+
+		return rune(value), true, nil
+	case 'u':
+		if cx, ok := l.peekByte(0); !ok || cx != '{' {
+			return 0, false, errorAt(l.line, l.column, "escape sequence '\\u' must be followed by '{'")
+		}
+		l.readRawByte(raw)
+
+		value := 0
+		numDigits := 0
+		for true {
+			cx, ok := l.peekByte(0)
+			if ok && cx == '}' && numDigits > 0 {
+				l.readRawByte(raw)
+				break
+			}
+
+			digit, isHex := getHexDigitValue(cx)
+			if !ok || !isHex || numDigits == 6 {
+				return 0, false, errorAt(l.line, l.column, "escape sequence '\\u{' must contain 1 to 6 hexadecimal digits followed by '}'")
+			}
+
+			l.readRawByte(raw)
+			value = value*16 + digit
+			numDigits++
+		}
+
+		if value > unicode.MaxRune || (value >= 0xD800 && value <= 0xDFFF) {
+			return 0, false, errorAt(line, column, "escape sequence contains invalid Unicode code point U+%X", value)
+		}
+
+		return rune(value), false, nil
+	default:
+		return 0, false, errorAt(line, column, "unknown escape sequence '\\%c'", c1)
+	}
+}
+
+// readRune reads a single UTF-8 encoded character.
+func (l *Lexer) readRune(raw *strings.Builder) (rune, error) {
+	line, column := l.line, l.column
+	b := make([]byte, 0, utf8.UTFMax)
+	for i := 0; i < utf8.UTFMax; i++ {
+		c, ok := l.peekByte(i)
+		if !ok {
+			break
 		}
+
+		b = append(b, c)
+	}
+
+	r, size := utf8.DecodeRune(b)
+	if r == utf8.RuneError && size <= 1 {
+		return 0, errorAt(line, column, "invalid UTF-8 encoding")
+	}
+
+	for i := 0; i < size; i++ {
+		l.readRawByte(raw)
+	}
+
+	return r, nil
+}
+
+// readRawByte consumes the current byte and adds it to the raw source text of a literal.
+func (l *Lexer) readRawByte(raw *strings.Builder) {
+	if c, ok := l.readByte(); ok {
+		raw.WriteByte(c)
+	}
+}
+
+func getHexDigitValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10, true
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10, true
+	default:
+		return 0, false
 	}
+}
 
-	return nil, 0
+func errorAt(line, column int, format string, args ...interface{}) error {
+	return errors.Errorf("error at line %d column %d: "+format, append([]interface{}{line + 1, column + 1}, args...)...)
 }
 
-func (Lexer) getIdentifierToken(line []byte, lineIdx, column int) (Token, int) {
-	lineLen := len(line)
-	untilCol := column
-	for untilCol < lineLen {
-		c := line[untilCol]
+func (l *Lexer) getKeywordOrIdentifierToken(lineIdx, column int) Token {
+	s := strings.Builder{}
+	for true {
+		c, ok := l.peekByte(0)
+		if !ok {
+			break
+		}
+
 		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || c == '_' ||
-			(untilCol != column && c >= '0' && c <= '9') {
+			(s.Len() != 0 && c >= '0' && c <= '9') {
 
-			untilCol++
+			l.readByte()
+			s.WriteByte(c)
 		} else {
 			break
 		}
 	}
 
-	if untilCol != column {
-		s := line[column:untilCol]
-		return IdentifierToken{
-			basicToken: basicToken{
-				tokenType: Identifier,
-				line:      lineIdx,
-				column:    column,
-			},
-			identifier: string(s),
-		}, untilCol
+	if s.Len() == 0 {
+		return nil
 	}
 
-	return nil, 0
+	word := s.String()
+	if tokenType, ok := keywordMap[word]; ok {
+		return basicToken{
+			tokenType: tokenType,
+			line:      lineIdx,
+			column:    column,
+		}
+	}
+
+	return IdentifierToken{
+		basicToken: basicToken{
+			tokenType: Identifier,
+			line:      lineIdx,
+			column:    column,
+		},
+		identifier: word,
+	}
 }
@@ -1,5 +1,10 @@
 package lexer
 
+import (
+	"math/big"
+	"sort"
+)
+
 type TokenType int
 
 const (
@@ -7,6 +12,7 @@ const (
 
 	// Literal
 	Integer   // 12345
+	Float     // 1.5
 	Character // 'a'
 	String    // "abc"
 
@@ -18,13 +24,30 @@ const (
 	Subtract // -
 	Multiply // *
 	Divide   // /
+	Modulo   // %
+
+	// Bitwise
+	BitwiseAnd // &
+	BitwiseOr  // |
+	BitwiseXor // ^
+	BitwiseNot // ~
+	ShiftLeft  // <<
+	ShiftRight // >>
 
 	// Assignment
-	Assign         // =
-	AddAssign      // +=
-	SubtractAssign // -=
-	Increment      // ++
-	Decrement      // --
+	Assign           // =
+	AddAssign        // +=
+	SubtractAssign   // -=
+	MultiplyAssign   // *=
+	DivideAssign     // /=
+	ModuloAssign     // %=
+	BitwiseAndAssign // &=
+	BitwiseOrAssign  // |=
+	BitwiseXorAssign // ^=
+	ShiftLeftAssign  // <<=
+	ShiftRightAssign // >>=
+	Increment        // ++
+	Decrement        // --
 
 	// Comparison
 	Equal          // ==
@@ -49,9 +72,11 @@ const (
 	Comma            // ,
 	Period           // .
 	Semicolon        // ;
+	Colon            // :
 
 	// Keyword
 	Var
+	Const
 	Type
 	AnyType
 	Func
@@ -60,6 +85,8 @@ const (
 	Return
 	For
 	While
+	Break
+	Continue
 	True
 	False
 	// TODO add "export"
@@ -67,17 +94,20 @@ const (
 
 var (
 	keywordMap = map[string]TokenType{
-		"var":     Var,
-		"type":    Type,
-		"anytype": AnyType,
-		"func":    Func,
-		"if":      If,
-		"else":    Else,
-		"return":  Return,
-		"for":     For,
-		"while":   While,
-		"true":    True,
-		"false":   False,
+		"var":      Var,
+		"const":    Const,
+		"type":     Type,
+		"anytype":  AnyType,
+		"func":     Func,
+		"if":       If,
+		"else":     Else,
+		"return":   Return,
+		"for":      For,
+		"while":    While,
+		"break":    Break,
+		"continue": Continue,
+		"true":     True,
+		"false":    False,
 	}
 )
 
@@ -91,12 +121,54 @@ type Token interface {
 	UFLine() int
 	// User friendly column number (starting at 1)
 	UFColumn() int
+
+	// Comments between the previous token and this token.
+	LeadingTrivia() []Comment
+	// Comments after this token that are not followed by another token. Only the last token of a file can have these.
+	TrailingTrivia() []Comment
+}
+
+// Comment is a line comment (// ...) or block comment (/* ... */) that is kept as trivia of a token.
+type Comment struct {
+	text   string
+	block  bool
+	line   int
+	column int
+}
+
+// Text returns the full source text of the comment, including the comment delimiters.
+// Lines of a block comment are separated by a single newline character.
+func (c Comment) Text() string {
+	return c.text
+}
+
+// Block returns whether the comment is a block comment.
+func (c Comment) Block() bool {
+	return c.block
+}
+
+func (c Comment) Line() int {
+	return c.line
+}
+
+func (c Comment) Column() int {
+	return c.column
+}
+
+func (c Comment) UFLine() int {
+	return c.line + 1
+}
+
+func (c Comment) UFColumn() int {
+	return c.column + 1
 }
 
 type basicToken struct {
-	tokenType TokenType
-	line      int
-	column    int
+	tokenType      TokenType
+	line           int
+	column         int
+	leadingTrivia  []Comment
+	trailingTrivia []Comment
 }
 
 func (t basicToken) Type() TokenType {
@@ -119,33 +191,113 @@ func (t basicToken) UFColumn() int {
 	return t.column + 1
 }
 
+func (t basicToken) LeadingTrivia() []Comment {
+	return t.leadingTrivia
+}
+
+func (t basicToken) TrailingTrivia() []Comment {
+	return t.trailingTrivia
+}
+
+// withTrivia returns a copy of the token with the given leading and trailing comments.
+func withTrivia(token Token, leading, trailing []Comment) Token {
+	switch t := token.(type) {
+	case basicToken:
+		t.leadingTrivia, t.trailingTrivia = leading, trailing
+		return t
+	case IntegerToken:
+		t.leadingTrivia, t.trailingTrivia = leading, trailing
+		return t
+	case FloatToken:
+		t.leadingTrivia, t.trailingTrivia = leading, trailing
+		return t
+	case CharacterToken:
+		t.leadingTrivia, t.trailingTrivia = leading, trailing
+		return t
+	case StringToken:
+		t.leadingTrivia, t.trailingTrivia = leading, trailing
+		return t
+	case IdentifierToken:
+		t.leadingTrivia, t.trailingTrivia = leading, trailing
+		return t
+	case OperatorToken:
+		t.leadingTrivia, t.trailingTrivia = leading, trailing
+		return t
+	default:
+		return token
+	}
+}
+
 type IntegerToken struct {
 	basicToken
-	integer int
+	integer *big.Int
+	raw     string
 }
 
-func (t IntegerToken) Integer() int {
+// Integer returns the value of the literal, which is not limited to the size of any integer type.
+func (t IntegerToken) Integer() *big.Int {
 	return t.integer
 }
 
+// Raw returns the literal as it was written in the source code.
+func (t IntegerToken) Raw() string {
+	return t.raw
+}
+
+type FloatToken struct {
+	basicToken
+	float float64
+	raw   string
+}
+
+func (t FloatToken) Float() float64 {
+	return t.float
+}
+
+// Raw returns the literal as it was written in the source code.
+func (t FloatToken) Raw() string {
+	return t.raw
+}
+
 type CharacterToken struct {
 	basicToken
-	character byte
+	character rune
+	isByte    bool
+	raw       string
 }
 
-func (t CharacterToken) Character() byte {
+// Character returns the character with escape sequences decoded.
+func (t CharacterToken) Character() rune {
 	return t.character
 }
 
+// IsByte returns whether the character was written with a \xNN escape sequence, which is a byte instead of a Unicode
+// code point.
+func (t CharacterToken) IsByte() bool {
+	return t.isByte
+}
+
+// Raw returns the literal as it was written in the source code, including quotes.
+func (t CharacterToken) Raw() string {
+	return t.raw
+}
+
 type StringToken struct {
 	basicToken
 	string string
+	raw    string
 }
 
+// String returns the contents of the string with escape sequences decoded.
 func (t StringToken) String() string {
 	return t.string
 }
 
+// Raw returns the literal as it was written in the source code, including quotes.
+func (t StringToken) Raw() string {
+	return t.raw
+}
+
 type IdentifierToken struct {
 	basicToken
 	identifier string
@@ -159,13 +311,45 @@ type OperatorToken struct {
 	basicToken
 }
 
-// OperatorPrecedence returns the precedence that the operator token has.
+// compoundAssignOperators maps every compound assignment to the operator it applies.
+var compoundAssignOperators = map[TokenType]TokenType{
+	AddAssign:        Add,
+	SubtractAssign:   Subtract,
+	MultiplyAssign:   Multiply,
+	DivideAssign:     Divide,
+	ModuloAssign:     Modulo,
+	BitwiseAndAssign: BitwiseAnd,
+	BitwiseOrAssign:  BitwiseOr,
+	BitwiseXorAssign: BitwiseXor,
+	ShiftLeftAssign:  ShiftLeft,
+	ShiftRightAssign: ShiftRight,
+}
+
+// CompoundAssignOperator returns the operator applied by a compound assignment, like % for %=.
+// When the token type is not a compound assignment, false is returned.
+func CompoundAssignOperator(tt TokenType) (TokenType, bool) {
+	operator, ok := compoundAssignOperators[tt]
+	return operator, ok
+}
+
+// CompoundAssignTokenTypes returns all compound assignments, in the order they are declared.
+func CompoundAssignTokenTypes() []TokenType {
+	tts := make([]TokenType, 0, len(compoundAssignOperators))
+	for tt := range compoundAssignOperators {
+		tts = append(tts, tt)
+	}
+
+	sort.Slice(tts, func(i, j int) bool { return tts[i] < tts[j] })
+	return tts
+}
+
+// OperatorPrecedence returns the precedence that the operator token has when it is written between two operands.
 // A higher value means the operator has higher precedence over a token with a lesser value.
 func (t OperatorToken) OperatorPrecedence() int {
 	switch t.tokenType {
-	case Multiply, Divide, Not:
+	case Multiply, Divide, Modulo, BitwiseAnd, ShiftLeft, ShiftRight:
 		return 5
-	case Add, Subtract:
+	case Add, Subtract, BitwiseOr, BitwiseXor:
 		return 4
 	case Equal, NotEqual, Less, LessOrEqual, Greater, GreaterOrEqual:
 		return 3
@@ -178,10 +362,24 @@ func (t OperatorToken) OperatorPrecedence() int {
 	}
 }
 
+// PrefixOperatorPrecedence returns the precedence that the operator token has when it is written before its operand,
+// like -a. Prefix operators have higher precedence than all operators between two operands.
+// Returns 0 when the token cannot be used as a prefix operator.
+func (t OperatorToken) PrefixOperatorPrecedence() int {
+	switch t.tokenType {
+	case Subtract, Not, BitwiseNot:
+		return 6
+	default:
+		return 0
+	}
+}
+
 func GetTokenTypeString(tt TokenType) string {
 	switch tt {
 	case Integer:
 		return "<integer>"
+	case Float:
+		return "<float>"
 	case Character:
 		return "<character>"
 	case String:
@@ -196,12 +394,42 @@ func GetTokenTypeString(tt TokenType) string {
 		return "*"
 	case Divide:
 		return "/"
+	case Modulo:
+		return "%"
+	case BitwiseAnd:
+		return "&"
+	case BitwiseOr:
+		return "|"
+	case BitwiseXor:
+		return "^"
+	case BitwiseNot:
+		return "~"
+	case ShiftLeft:
+		return "<<"
+	case ShiftRight:
+		return ">>"
 	case Assign:
 		return "="
 	case AddAssign:
 		return "+="
 	case SubtractAssign:
 		return "-="
+	case MultiplyAssign:
+		return "*="
+	case DivideAssign:
+		return "/="
+	case ModuloAssign:
+		return "%="
+	case BitwiseAndAssign:
+		return "&="
+	case BitwiseOrAssign:
+		return "|="
+	case BitwiseXorAssign:
+		return "^="
+	case ShiftLeftAssign:
+		return "<<="
+	case ShiftRightAssign:
+		return ">>="
 	case Increment:
 		return "++"
 	case Decrement:
@@ -242,8 +470,12 @@ func GetTokenTypeString(tt TokenType) string {
 		return "."
 	case Semicolon:
 		return ";"
+	case Colon:
+		return ":"
 	case Var:
 		return "var"
+	case Const:
+		return "const"
 	case Type:
 		return "type"
 	case AnyType:
@@ -260,6 +492,10 @@ func GetTokenTypeString(tt TokenType) string {
 		return "for"
 	case While:
 		return "while"
+	case Break:
+		return "break"
+	case Continue:
+		return "continue"
 	case True:
 		return "true"
 	case False:
@@ -2,6 +2,8 @@ package quisnix
 
 import (
 	"bytes"
+	"io"
+	"strings"
 
 	"github.com/milandamen/quisnix/lexer"
 
@@ -93,6 +95,272 @@ func test(asd Int) Int {
 
 		Expect(tokens[41].Type()).To(Equal(lexer.RightBrace))
 	})
+
+	It("should keep comments as trivia of the next token", func() {
+		l := lexer.Lexer{}
+
+		program := `// Returns a.
+func test(a Int) Int { /* one
+	/* nested */ two */
+	return a / 2; // halved
+}
+// end of file`
+
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+		Expect(len(tokens)).To(Equal(14))
+
+		Expect(tokens[0].Type()).To(Equal(lexer.Func))
+		leading := tokens[0].LeadingTrivia()
+		Expect(len(leading)).To(Equal(1))
+		Expect(leading[0].Text()).To(Equal("// Returns a."))
+		Expect(leading[0].Block()).To(BeFalse())
+		Expect(leading[0].Line()).To(Equal(0))
+		Expect(leading[0].Column()).To(Equal(0))
+
+		Expect(tokens[8].Type()).To(Equal(lexer.Return))
+		leading = tokens[8].LeadingTrivia()
+		Expect(len(leading)).To(Equal(1))
+		Expect(leading[0].Text()).To(Equal("/* one\n\t/* nested */ two */"))
+		Expect(leading[0].Block()).To(BeTrue())
+		Expect(leading[0].UFLine()).To(Equal(2))
+		Expect(leading[0].UFColumn()).To(Equal(24))
+
+		Expect(tokens[10].Type()).To(Equal(lexer.Divide))
+		Expect(len(tokens[10].LeadingTrivia())).To(Equal(0))
+
+		Expect(tokens[13].Type()).To(Equal(lexer.RightBrace))
+		leading = tokens[13].LeadingTrivia()
+		Expect(len(leading)).To(Equal(1))
+		Expect(leading[0].Text()).To(Equal("// halved"))
+		trailing := tokens[13].TrailingTrivia()
+		Expect(len(trailing)).To(Equal(1))
+		Expect(trailing[0].Text()).To(Equal("// end of file"))
+	})
+
+	It("should fail on an unterminated block comment", func() {
+		l := lexer.Lexer{}
+		_, err := l.Parse(bytes.NewBufferString("func /* a /* b */\n*"))
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("unterminated block comment starting at line 1 column 6"))
+	})
+
+	It("should lex lines longer than 64 KiB", func() {
+		l := lexer.Lexer{}
+
+		program := "func test() {\n\t" + strings.Repeat("a++; ", 20000) + "return;\n}\n"
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+		Expect(len(tokens)).To(Equal(60008))
+
+		last := tokens[len(tokens)-3]
+		Expect(last.Type()).To(Equal(lexer.Return))
+		Expect(last.Line()).To(Equal(1))
+		Expect(last.Column()).To(Equal(100001))
+	})
+
+	It("should lex raw multi-line string literals", func() {
+		l := lexer.Lexer{}
+
+		program := "s = `first\r\n\"second\"`; t"
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+		Expect(len(tokens)).To(Equal(5))
+		expectLiteralStringToken(tokens[2], "first\n\"second\"")
+		Expect(tokens[2].Line()).To(Equal(0))
+		Expect(tokens[2].Column()).To(Equal(4))
+		Expect(tokens[3].Type()).To(Equal(lexer.Semicolon))
+		Expect(tokens[3].Line()).To(Equal(1))
+		Expect(tokens[3].Column()).To(Equal(9))
+		expectIdentifierToken(tokens[4], "t")
+
+		_, err = l.Parse(bytes.NewBufferString("s = `abc"))
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("error at line 1 column 5: unexpected end of file before end of raw string literal"))
+	})
+
+	It("should decode escape sequences and UTF-8 in literals", func() {
+		l := lexer.Lexer{}
+
+		program := `'\n' '\'' 'é' '\u{1F600}' '\x41' "a\"b\t\\c\0" "\x41\u{e9}ü"`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+		Expect(len(tokens)).To(Equal(7))
+
+		expectLiteralCharacterToken(tokens[0], '\n')
+		Expect(tokens[0].(lexer.CharacterToken).Raw()).To(Equal(`'\n'`))
+		expectLiteralCharacterToken(tokens[1], '\'')
+		expectLiteralCharacterToken(tokens[2], 'é')
+		expectLiteralCharacterToken(tokens[3], '😀')
+		expectLiteralCharacterToken(tokens[4], 'A')
+		Expect(tokens[2].(lexer.CharacterToken).IsByte()).To(BeFalse())
+		Expect(tokens[4].(lexer.CharacterToken).IsByte()).To(BeTrue())
+		Expect(tokens[4].Column()).To(Equal(27))
+		expectLiteralStringToken(tokens[5], "a\"b\t\\c\x00")
+		Expect(tokens[5].(lexer.StringToken).Raw()).To(Equal(`"a\"b\t\\c\0"`))
+		expectLiteralStringToken(tokens[6], "Aéü")
+		Expect(tokens[6].Column()).To(Equal(48))
+	})
+
+	It("should report invalid literals at the offending column", func() {
+		l := lexer.Lexer{}
+
+		invalid := map[string]string{
+			`a = "abc\qdef";`:   "error at line 1 column 9: unknown escape sequence '\\q'",
+			`a = "\x4g";`:       "error at line 1 column 9: escape sequence '\\x' must be followed by 2 hexadecimal digits",
+			`a = "\u{110000}";`: "error at line 1 column 6: escape sequence contains invalid Unicode code point U+110000",
+			`a = "\u{12";`:      "error at line 1 column 11: escape sequence '\\u{' must contain 1 to 6 hexadecimal digits followed by '}'",
+			`a = 'ab';`:         "error at line 1 column 5: character literal may only be 1 character long",
+			`a = '';`:           "error at line 1 column 5: character literal can not be empty",
+			"a = \"\xff\";":     "error at line 1 column 6: invalid UTF-8 encoding",
+			"a = \"abc\n\";":    "error at line 1 column 5: unexpected end of line before end of string literal",
+		}
+		for program, message := range invalid {
+			_, err := l.Parse(bytes.NewBufferString(program))
+			Expect(err).ToNot(Succeed(), program)
+			Expect(err.Error()).To(Equal(message), program)
+		}
+	})
+
+	It("should lex integer literals in every base", func() {
+		l := lexer.Lexer{}
+
+		program := "1_000_000 0xFF 0x_dead_BEEF 0o17 0b1010 007 123456789012345678901234567890"
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+		Expect(len(tokens)).To(Equal(7))
+
+		expectLiteralIntegerToken(tokens[0], 1000000)
+		Expect(tokens[0].(lexer.IntegerToken).Raw()).To(Equal("1_000_000"))
+		expectLiteralIntegerToken(tokens[1], 255)
+		expectLiteralIntegerToken(tokens[2], 0xdeadbeef)
+		expectLiteralIntegerToken(tokens[3], 15)
+		expectLiteralIntegerToken(tokens[4], 10)
+		expectLiteralIntegerToken(tokens[5], 7)
+		Expect(tokens[6].(lexer.IntegerToken).Integer().String()).To(Equal("123456789012345678901234567890"))
+	})
+
+	It("should report invalid integer literals at the offending column", func() {
+		l := lexer.Lexer{}
+
+		invalid := map[string]string{
+			"a = 0b102;":  "error at line 1 column 9: invalid digit '2' in binary literal",
+			"a = 0o8;":    "error at line 1 column 7: invalid digit '8' in octal literal",
+			"a = 12ab;":   "error at line 1 column 7: invalid digit 'a' in decimal literal",
+			"a = 0x;":     "error at line 1 column 5: hexadecimal literal has no digits",
+			"a = 1__0;":   "error at line 1 column 7: '_' must separate successive digits",
+			"a = 10_;":    "error at line 1 column 7: '_' must separate successive digits",
+			"a = 0x_FFg;": "error at line 1 column 10: invalid digit 'g' in hexadecimal literal",
+		}
+		for program, message := range invalid {
+			_, err := l.Parse(bytes.NewBufferString(program))
+			Expect(err).ToNot(Succeed(), program)
+			Expect(err.Error()).To(Equal(message), program)
+		}
+	})
+
+	It("should lex floating-point literals", func() {
+		l := lexer.Lexer{}
+
+		program := "1.5 2e10 0.25E-2 1_000.5 3.0e+2 x.y 1.y"
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+		Expect(len(tokens)).To(Equal(11))
+
+		expectLiteralFloatToken(tokens[0], 1.5)
+		expectLiteralFloatToken(tokens[1], 2e10)
+		expectLiteralFloatToken(tokens[2], 0.0025)
+		expectLiteralFloatToken(tokens[3], 1000.5)
+		Expect(tokens[3].(lexer.FloatToken).Raw()).To(Equal("1_000.5"))
+		expectLiteralFloatToken(tokens[4], 300)
+		expectIdentifierToken(tokens[5], "x")
+		Expect(tokens[6].Type()).To(Equal(lexer.Period))
+		expectIdentifierToken(tokens[7], "y")
+		expectLiteralIntegerToken(tokens[8], 1)
+		Expect(tokens[9].Type()).To(Equal(lexer.Period))
+		expectIdentifierToken(tokens[10], "y")
+	})
+
+	It("should report invalid floating-point literals at the offending column", func() {
+		l := lexer.Lexer{}
+
+		invalid := map[string]string{
+			"a = 1e;":     "error at line 1 column 7: exponent has no digits",
+			"a = 1.5e+x;": "error at line 1 column 10: exponent has no digits",
+			"a = 1e999;":  "error at line 1 column 5: floating-point literal '1e999' is out of range",
+		}
+		for program, message := range invalid {
+			_, err := l.Parse(bytes.NewBufferString(program))
+			Expect(err).ToNot(Succeed(), program)
+			Expect(err.Error()).To(Equal(message), program)
+		}
+	})
+
+	It("should lex the longest operator", func() {
+		l := lexer.Lexer{}
+
+		program := "* *= / /= % %= & &= && | |= || ^ ^= ~ << <<= >> >>= < <= > >="
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		expected := []lexer.TokenType{
+			lexer.Multiply, lexer.MultiplyAssign, lexer.Divide, lexer.DivideAssign, lexer.Modulo, lexer.ModuloAssign,
+			lexer.BitwiseAnd, lexer.BitwiseAndAssign, lexer.And,
+			lexer.BitwiseOr, lexer.BitwiseOrAssign, lexer.Or,
+			lexer.BitwiseXor, lexer.BitwiseXorAssign, lexer.BitwiseNot,
+			lexer.ShiftLeft, lexer.ShiftLeftAssign, lexer.ShiftRight, lexer.ShiftRightAssign,
+			lexer.Less, lexer.LessOrEqual, lexer.Greater, lexer.GreaterOrEqual,
+		}
+		Expect(len(tokens)).To(Equal(len(expected)))
+		for i, t := range tokens {
+			Expect(t.Type()).To(Equal(expected[i]), lexer.GetTokenTypeString(expected[i]))
+		}
+	})
+
+	It("should lex break and continue with labels", func() {
+		l := lexer.Lexer{}
+
+		tokens, err := l.Parse(bytes.NewBufferString("outer: while x { break outer; continue; }"))
+		Expect(err).To(Succeed())
+
+		expected := []lexer.TokenType{
+			lexer.Identifier, lexer.Colon, lexer.While, lexer.Identifier, lexer.LeftBrace,
+			lexer.Break, lexer.Identifier, lexer.Semicolon, lexer.Continue, lexer.Semicolon, lexer.RightBrace,
+		}
+		Expect(len(tokens)).To(Equal(len(expected)))
+		for i, t := range tokens {
+			Expect(t.Type()).To(Equal(expected[i]), lexer.GetTokenTypeString(expected[i]))
+		}
+		expectIdentifierToken(tokens[0], "outer")
+		expectIdentifierToken(tokens[6], "outer")
+	})
+
+	It("should lex tokens one at a time", func() {
+		l := lexer.NewLexer(bytes.NewBufferString("while x { // loop\n} // done"))
+
+		t, err := l.Next()
+		Expect(err).To(Succeed())
+		Expect(t.Type()).To(Equal(lexer.While))
+
+		t, err = l.Next()
+		Expect(err).To(Succeed())
+		expectIdentifierToken(t, "x")
+
+		t, err = l.Next()
+		Expect(err).To(Succeed())
+		Expect(t.Type()).To(Equal(lexer.LeftBrace))
+
+		t, err = l.Next()
+		Expect(err).To(Succeed())
+		Expect(t.Type()).To(Equal(lexer.RightBrace))
+		Expect(t.LeadingTrivia()[0].Text()).To(Equal("// loop"))
+		Expect(t.TrailingTrivia()[0].Text()).To(Equal("// done"))
+
+		_, err = l.Next()
+		Expect(err).To(Equal(io.EOF))
+		_, err = l.Next()
+		Expect(err).To(Equal(io.EOF))
+	})
 })
 
 func expectIdentifierToken(token lexer.Token, identifier string) {
@@ -106,10 +374,17 @@ func expectLiteralIntegerToken(token lexer.Token, integer int) {
 	Expect(token.Type()).To(Equal(lexer.Integer))
 	t, ok := token.(lexer.IntegerToken)
 	Expect(ok).To(BeTrue())
-	Expect(t.Integer()).To(Equal(integer))
+	Expect(t.Integer().Int64()).To(Equal(int64(integer)))
+}
+
+func expectLiteralFloatToken(token lexer.Token, float float64) {
+	Expect(token.Type()).To(Equal(lexer.Float))
+	t, ok := token.(lexer.FloatToken)
+	Expect(ok).To(BeTrue())
+	Expect(t.Float()).To(Equal(float))
 }
 
-func expectLiteralCharacterToken(token lexer.Token, char byte) {
+func expectLiteralCharacterToken(token lexer.Token, char rune) {
 	Expect(token.Type()).To(Equal(lexer.Character))
 	t, ok := token.(lexer.CharacterToken)
 	Expect(ok).To(BeTrue())
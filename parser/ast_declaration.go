@@ -2,7 +2,12 @@ package parser
 
 type VariableDeclaration struct {
 	nodeSource
-	TypeDeclaration *TypeDeclaration
+	Name            string           // Empty for fields that only describe a type, like function return types.
+	TypeDeclaration *TypeDeclaration // Nil until the semantic analyzer took it from the value, when it was left out.
+	Value           Expression       // Initial value, nil when the variable starts with its zero value.
+	Constant        bool             // Constants cannot be assigned, and their value is known at compile time.
+	Captured        bool             // Used by a function literal inside the function that declares the variable.
+	Addressed       bool             // Holds an array that is sliced, so the slices refer to the memory of the variable.
 }
 
 type TypeDeclaration struct {
@@ -23,7 +28,11 @@ type UnknownDeclaration struct {
 	Scope      Scope // Scope of the place where the identifier was used.
 }
 
-func (*VariableDeclaration) DeclarationType() string {
+func (d *VariableDeclaration) DeclarationType() string {
+	if d.Constant {
+		return "constant"
+	}
+
 	return "variable"
 }
 
@@ -45,6 +54,7 @@ func (*FunctionDeclaration) declNode() {}
 func (*UnknownDeclaration) declNode()  {}
 
 func (*VariableDeclaration) stmtNode() {}
+func (*TypeDeclaration) stmtNode()     {}
 
 func NewFunctionDeclaration(ns nodeSource, funcDef *FunctionDefinition, name string) *FunctionDeclaration {
 	return &FunctionDeclaration{
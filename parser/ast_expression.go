@@ -1,6 +1,14 @@
 package parser
 
-import "github.com/pkg/errors"
+import (
+	"math/big"
+	"strconv"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/milandamen/quisnix/lexer"
+)
 
 type resultingTypeDeclarations interface {
 	ResultingTypeDeclarations() ([]*TypeDeclaration, error)
@@ -17,18 +25,35 @@ type dualInputExpression struct {
 	Right Expression
 }
 
+// DualInputOperands is implemented by all expressions with a left and right side.
+type DualInputOperands interface {
+	Operands() (Expression, Expression)
+}
+
 type dualInputBoolOutputExpression struct {
 	dualInputExpression
 }
 
+// dualInputIntegerExpression is an operation that is only defined for integers, like % and the bitwise operators.
+type dualInputIntegerExpression struct {
+	dualInputExpression
+	operator lexer.TokenType
+}
+
 type IntegerLiteralExpression struct {
 	baseExpression
-	Value int
+	Value *big.Int
+}
+
+type FloatLiteralExpression struct {
+	baseExpression
+	Value float64
 }
 
 type CharacterLiteralExpression struct {
 	baseExpression
-	Value byte
+	Value rune
+	Byte  bool // Written with a \xNN escape sequence, so the value is a byte instead of a Unicode code point.
 }
 
 type StringLiteralExpression struct {
@@ -44,6 +69,8 @@ type BooleanLiteralExpression struct {
 type IdentifierExpression struct {
 	baseExpression
 	IdentifierDeclaration Declaration
+
+	types *typeCache // Types of the file the expression is in, which has the type of a function used as a value.
 }
 
 type AddExpression struct {
@@ -62,6 +89,32 @@ type DivideExpression struct {
 	dualInputExpression
 }
 
+type ModuloExpression struct {
+	dualInputIntegerExpression
+}
+
+type BitwiseAndExpression struct {
+	dualInputIntegerExpression
+}
+
+type BitwiseOrExpression struct {
+	dualInputIntegerExpression
+}
+
+type BitwiseXorExpression struct {
+	dualInputIntegerExpression
+}
+
+// Expression shifting the bits of the left side to the left. Bits shifted past the size of the type are lost.
+type ShiftLeftExpression struct {
+	dualInputIntegerExpression
+}
+
+// Expression shifting the bits of the left side to the right. An Int keeps its sign, a Byte is filled with zeroes.
+type ShiftRightExpression struct {
+	dualInputIntegerExpression
+}
+
 type EqualExpression struct {
 	dualInputBoolOutputExpression
 }
@@ -99,12 +152,93 @@ type NotExpression struct {
 	Expression Expression
 }
 
+// Expression negating a number, like -a.
+type NegateExpression struct {
+	baseExpression
+	Expression Expression
+}
+
+// Expression flipping all bits of an integer, like ~a.
+type BitwiseNotExpression struct {
+	baseExpression
+	Expression Expression
+}
+
+// Expression converting a value to another type, like Float(a).
+type ConversionExpression struct {
+	baseExpression
+	TypeDeclaration *TypeDeclaration // The type being converted to.
+	Expression      Expression
+}
+
+// Expression accessing a field of a struct value, like p.x.
+type FieldAccessExpression struct {
+	baseExpression
+	Expression Expression // Expression resulting in the struct value.
+	FieldName  string
+}
+
+// Expression creating a struct value, like Point{x: 1, y: 2}. Fields that are left out get their zero value.
+type StructLiteralExpression struct {
+	baseExpression
+	TypeDeclaration *TypeDeclaration
+	Fields          []*StructLiteralField
+}
+
+type StructLiteralField struct {
+	nodeSource
+	Name       string
+	Expression Expression
+}
+
+// Expression getting an element of an array or slice, like a[i].
+type IndexExpression struct {
+	baseExpression
+	Expression Expression // Expression resulting in the array or slice.
+	Index      Expression
+}
+
+// Expression creating a slice from part of an array or slice, like a[1:3]. A slice of an array in a variable refers to
+// the memory of the variable, other arrays are copied to the heap first.
+type SliceExpression struct {
+	baseExpression
+	Expression Expression
+	Low        Expression // Index of the first element, 0 when nil.
+	High       Expression // Index after the last element, the length when nil.
+
+	types *typeCache // Types of the file the expression is in, which has the resulting slice type.
+}
+
+// Expression getting the number of elements of an array or slice, like len(a).
+type LenExpression struct {
+	baseExpression
+	Expression Expression
+}
+
+// Expression adding elements to the end of a slice, like append(s, 1, 2). The resulting slice only shares its elements
+// with the given slice when the given slice had enough capacity left.
+type AppendExpression struct {
+	baseExpression
+	Slice    Expression
+	Elements []Expression
+}
+
 type FunctionCallExpression struct {
 	baseExpression
 	CallSource Expression // Expression representing a function that can be called.
 	Parameters []Expression
 }
 
+// Expression defining a function without a name where it is used, like func(x Int) Int { return x + y; }. It captures
+// the variables of the functions around it that it uses, which it then shares with those functions.
+type FunctionLiteralExpression struct {
+	baseExpression
+	FunctionDefinition *FunctionDefinition
+	Captures           []*VariableDeclaration // Variables of the functions around it that it uses, in order of first use.
+
+	types *typeCache // Types of the file the expression is in, which has the type of the function.
+}
+
 func newBaseExpression(source nodeSource, d ...*TypeDeclaration) baseExpression {
 	return baseExpression{
 		nodeSource:       source,
@@ -112,17 +246,25 @@ func newBaseExpression(source nodeSource, d ...*TypeDeclaration) baseExpression
 	}
 }
 
-func newIntegerLiteralExpression(source nodeSource, value int, scope Scope) *IntegerLiteralExpression {
+func newIntegerLiteralExpression(source nodeSource, value *big.Int, scope Scope) *IntegerLiteralExpression {
 	return &IntegerLiteralExpression{
 		baseExpression: newBaseExpression(source, scope.SearchTypeDeclaration("Int")),
 		Value:          value,
 	}
 }
 
-func newCharacterLiteralExpression(source nodeSource, value byte, scope Scope) *CharacterLiteralExpression {
+func newFloatLiteralExpression(source nodeSource, value float64, scope Scope) *FloatLiteralExpression {
+	return &FloatLiteralExpression{
+		baseExpression: newBaseExpression(source, scope.SearchTypeDeclaration("Float")),
+		Value:          value,
+	}
+}
+
+func newCharacterLiteralExpression(source nodeSource, value rune, isByte bool, scope Scope) *CharacterLiteralExpression {
 	return &CharacterLiteralExpression{
 		baseExpression: newBaseExpression(source, scope.SearchTypeDeclaration("Byte")),
 		Value:          value,
+		Byte:           isByte,
 	}
 }
 
@@ -140,10 +282,11 @@ func newBooleanLiteralExpression(source nodeSource, value bool, scope Scope) *Bo
 	}
 }
 
-func newIdentifierExpression(source nodeSource, declaration Declaration) *IdentifierExpression {
+func newIdentifierExpression(source nodeSource, declaration Declaration, types *typeCache) *IdentifierExpression {
 	return &IdentifierExpression{
 		baseExpression:        newBaseExpression(source),
 		IdentifierDeclaration: declaration,
+		types:                 types,
 	}
 }
 
@@ -187,6 +330,41 @@ func newDivideExpression(source nodeSource, left Expression, right Expression) *
 	}
 }
 
+func newDualInputIntegerExpression(source nodeSource, left Expression, right Expression, operator lexer.TokenType) dualInputIntegerExpression {
+	return dualInputIntegerExpression{
+		dualInputExpression: dualInputExpression{
+			baseExpression: newBaseExpression(source),
+			Left:           left,
+			Right:          right,
+		},
+		operator: operator,
+	}
+}
+
+func newModuloExpression(source nodeSource, left Expression, right Expression) *ModuloExpression {
+	return &ModuloExpression{newDualInputIntegerExpression(source, left, right, lexer.Modulo)}
+}
+
+func newBitwiseAndExpression(source nodeSource, left Expression, right Expression) *BitwiseAndExpression {
+	return &BitwiseAndExpression{newDualInputIntegerExpression(source, left, right, lexer.BitwiseAnd)}
+}
+
+func newBitwiseOrExpression(source nodeSource, left Expression, right Expression) *BitwiseOrExpression {
+	return &BitwiseOrExpression{newDualInputIntegerExpression(source, left, right, lexer.BitwiseOr)}
+}
+
+func newBitwiseXorExpression(source nodeSource, left Expression, right Expression) *BitwiseXorExpression {
+	return &BitwiseXorExpression{newDualInputIntegerExpression(source, left, right, lexer.BitwiseXor)}
+}
+
+func newShiftLeftExpression(source nodeSource, left Expression, right Expression) *ShiftLeftExpression {
+	return &ShiftLeftExpression{newDualInputIntegerExpression(source, left, right, lexer.ShiftLeft)}
+}
+
+func newShiftRightExpression(source nodeSource, left Expression, right Expression) *ShiftRightExpression {
+	return &ShiftRightExpression{newDualInputIntegerExpression(source, left, right, lexer.ShiftRight)}
+}
+
 func newEqualExpression(source nodeSource, left Expression, right Expression, scope Scope) *EqualExpression {
 	return &EqualExpression{
 		dualInputBoolOutputExpression: dualInputBoolOutputExpression{
@@ -290,6 +468,122 @@ func newNotExpression(source nodeSource, exp Expression, scope Scope) *NotExpres
 	}
 }
 
+func newNegateExpression(source nodeSource, exp Expression) *NegateExpression {
+	return &NegateExpression{
+		baseExpression: newBaseExpression(source),
+		Expression:     exp,
+	}
+}
+
+func newBitwiseNotExpression(source nodeSource, exp Expression) *BitwiseNotExpression {
+	return &BitwiseNotExpression{
+		baseExpression: newBaseExpression(source),
+		Expression:     exp,
+	}
+}
+
+// newBinaryExpression returns the expression for an operator between two operands. When the token is not such an
+// operator, false is returned.
+func newBinaryExpression(operator lexer.TokenType, source nodeSource, left Expression, right Expression, scope Scope) (Expression, bool) {
+	switch operator {
+	case lexer.Multiply:
+		return newMultiplyExpression(source, left, right), true
+	case lexer.Divide:
+		return newDivideExpression(source, left, right), true
+	case lexer.Modulo:
+		return newModuloExpression(source, left, right), true
+	case lexer.Add:
+		return newAddExpression(source, left, right), true
+	case lexer.Subtract:
+		return newSubtractExpression(source, left, right), true
+	case lexer.BitwiseAnd:
+		return newBitwiseAndExpression(source, left, right), true
+	case lexer.BitwiseOr:
+		return newBitwiseOrExpression(source, left, right), true
+	case lexer.BitwiseXor:
+		return newBitwiseXorExpression(source, left, right), true
+	case lexer.ShiftLeft:
+		return newShiftLeftExpression(source, left, right), true
+	case lexer.ShiftRight:
+		return newShiftRightExpression(source, left, right), true
+	case lexer.Equal:
+		return newEqualExpression(source, left, right, scope), true
+	case lexer.NotEqual:
+		return newNotEqualExpression(source, left, right, scope), true
+	case lexer.Less:
+		return newLessExpression(source, left, right, scope), true
+	case lexer.LessOrEqual:
+		return newLessOrEqualExpression(source, left, right, scope), true
+	case lexer.Greater:
+		return newGreaterExpression(source, left, right, scope), true
+	case lexer.GreaterOrEqual:
+		return newGreaterOrEqualExpression(source, left, right, scope), true
+	case lexer.And:
+		return newAndExpression(source, left, right, scope), true
+	case lexer.Or:
+		return newOrExpression(source, left, right, scope), true
+	default:
+		return nil, false
+	}
+}
+
+func newConversionExpression(source nodeSource, typeDeclaration *TypeDeclaration, exp Expression) *ConversionExpression {
+	return &ConversionExpression{
+		baseExpression:  newBaseExpression(source, typeDeclaration),
+		TypeDeclaration: typeDeclaration,
+		Expression:      exp,
+	}
+}
+
+func newFieldAccessExpression(source nodeSource, exp Expression, fieldName string) *FieldAccessExpression {
+	return &FieldAccessExpression{
+		baseExpression: newBaseExpression(source),
+		Expression:     exp,
+		FieldName:      fieldName,
+	}
+}
+
+func newStructLiteralExpression(source nodeSource, typeDeclaration *TypeDeclaration, fields []*StructLiteralField) *StructLiteralExpression {
+	return &StructLiteralExpression{
+		baseExpression:  newBaseExpression(source),
+		TypeDeclaration: typeDeclaration,
+		Fields:          fields,
+	}
+}
+
+func newIndexExpression(source nodeSource, exp Expression, index Expression) *IndexExpression {
+	return &IndexExpression{
+		baseExpression: newBaseExpression(source),
+		Expression:     exp,
+		Index:          index,
+	}
+}
+
+func newSliceExpression(source nodeSource, exp Expression, low Expression, high Expression, types *typeCache) *SliceExpression {
+	return &SliceExpression{
+		baseExpression: newBaseExpression(source),
+		Expression:     exp,
+		Low:            low,
+		High:           high,
+		types:          types,
+	}
+}
+
+func newLenExpression(source nodeSource, exp Expression, scope Scope) *LenExpression {
+	return &LenExpression{
+		baseExpression: newBaseExpression(source, scope.SearchTypeDeclaration("Int")),
+		Expression:     exp,
+	}
+}
+
+func newAppendExpression(source nodeSource, slice Expression, elements []Expression) *AppendExpression {
+	return &AppendExpression{
+		baseExpression: newBaseExpression(source),
+		Slice:          slice,
+		Elements:       elements,
+	}
+}
+
 func newFunctionCallExpression(source nodeSource, callSource Expression, parameters []Expression) *FunctionCallExpression {
 	return &FunctionCallExpression{
 		baseExpression: newBaseExpression(source),
@@ -305,22 +599,50 @@ func (e *IdentifierExpression) ResultingTypeDeclarations() ([]*TypeDeclaration,
 
 	switch d := e.IdentifierDeclaration.(type) {
 	case *VariableDeclaration:
+		if d.TypeDeclaration == nil {
+			return nil, errors.Errorf("compiler error: type of %s '%s' is not known yet", d.DeclarationType(), d.Name)
+		}
+
 		e.baseExpression.typeDeclarations = []*TypeDeclaration{d.TypeDeclaration}
 		return e.baseExpression.typeDeclarations, nil
 	case *FunctionDeclaration:
-		tds := make([]*TypeDeclaration, 0)
-		fields := d.FunctionDefinition.FunctionType.ReturnTypes
-		for _, f := range fields {
-			tds = append(tds, f.VariableDeclaration.TypeDeclaration)
-		}
+		// A function used as a value has its function type.
+		funcType := d.FunctionDefinition.FunctionType
+		td := e.types.getFunctionTypeDeclaration(funcType.ParameterTypeDeclarations(), funcType.ReturnTypeDeclarations())
 
-		e.baseExpression.typeDeclarations = tds
-		return tds, nil
+		e.baseExpression.typeDeclarations = []*TypeDeclaration{td}
+		return e.baseExpression.typeDeclarations, nil
 	default:
 		return nil, errors.New("compiler error: unknown declaration for identifier expression")
 	}
 }
 
+func (e *IntegerLiteralExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.baseExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := tds[0].Type.(BasicType); ok {
+		if min, max, ok := t.IntegerRange(); ok && (e.Value.Cmp(min) < 0 || e.Value.Cmp(max) > 0) {
+			return nil, errors.Errorf("integer literal %s overflows type '%s' on line %d column %d",
+				e.Value.String(), t.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+		}
+	}
+
+	return tds, nil
+}
+
+func (e *CharacterLiteralExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	// A character above U+007F takes more than one byte in UTF-8, so only bytes written as \xNN go above it.
+	if !e.Byte && e.Value > unicode.MaxASCII {
+		return nil, errors.Errorf("character literal %s is not ASCII and does not fit in type 'Byte' on line %d column %d",
+			strconv.QuoteRune(e.Value), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	return e.baseExpression.ResultingTypeDeclarations()
+}
+
 func (e *NotExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
 	tds, err := MustSingleReturnType(e.Expression)
 	if err != nil {
@@ -339,25 +661,286 @@ func (e *NotExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error)
 	return tds, nil
 }
 
+func (e *NegateExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsNumericType(tds[0]) {
+		return nil, errors.Errorf("cannot negate type '%s' on line %d column %d",
+			tds[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	return tds, nil
+}
+
+func (e *BitwiseNotExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsIntegerType(tds[0]) {
+		return nil, errors.Errorf("operator '~' is only defined for integer types, type '%s' given on line %d column %d",
+			tds[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	return tds, nil
+}
+
+func (e *ConversionExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	target := e.TypeDeclaration
+	if !IsNumericType(tds[0]) || !IsNumericType(target) {
+		return nil, errors.Errorf("cannot convert type '%s' to '%s' on line %d column %d",
+			tds[0].Type.TypeName(), target.Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	return e.baseExpression.typeDeclarations, nil
+}
+
+func (e *FieldAccessExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	_, f, err := e.Field()
+	if err != nil {
+		return nil, err
+	}
+
+	return []*TypeDeclaration{f.VariableDeclaration.TypeDeclaration}, nil
+}
+
+// Field returns the index and the field of the struct that is accessed.
+func (e *FieldAccessExpression) Field() (int, *Field, error) {
+	tds, err := MustSingleReturnType(e.Expression)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	st, ok := tds[0].Type.(StructType)
+	if !ok {
+		return 0, nil, errors.Errorf("type '%s' has no fields, cannot access field '%s' on line %d column %d",
+			tds[0].Type.TypeName(), e.FieldName, e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	i, ok := st.FieldIndex(e.FieldName)
+	if !ok {
+		return 0, nil, errors.Errorf("type '%s' has no field '%s' on line %d column %d",
+			st.TypeName(), e.FieldName, e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	return i, &st.Fields[i], nil
+}
+
+func (e *StructLiteralExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	st, ok := e.TypeDeclaration.Type.(StructType)
+	if !ok {
+		return nil, errors.Errorf("type '%s' is not a struct, cannot create a struct literal on line %d column %d",
+			e.TypeDeclaration.Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	for _, lf := range e.Fields {
+		i, ok := st.FieldIndex(lf.Name)
+		if !ok {
+			return nil, errors.Errorf("type '%s' has no field '%s' on line %d column %d",
+				st.TypeName(), lf.Name, lf.UFSourceLine(), lf.UFSourceColumn())
+		}
+
+		expected := st.Fields[i].VariableDeclaration.TypeDeclaration
+		SetIntegerLiteralTypes(lf.Expression, expected)
+		tds, err := MustSingleReturnType(lf.Expression)
+		if err != nil {
+			return nil, err
+		}
+
+		if tds[0] != expected {
+			return nil, errors.Errorf("type mismatch: expected '%s' but was given '%s' for field '%s' on line %d column %d",
+				expected.Type.TypeName(), tds[0].Type.TypeName(), lf.Name, lf.UFSourceLine(), lf.UFSourceColumn())
+		}
+	}
+
+	return []*TypeDeclaration{e.TypeDeclaration}, nil
+}
+
+func (e *IndexExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	elementType, length, ok := getElementType(tds[0])
+	if !ok {
+		return nil, errors.Errorf("cannot index type '%s' on line %d column %d",
+			tds[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	if err := checkIndex(e.Index, "index", length); err != nil {
+		return nil, err
+	}
+
+	return []*TypeDeclaration{elementType}, nil
+}
+
+func (e *SliceExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	elementType, length, ok := getElementType(tds[0])
+	if !ok {
+		return nil, errors.Errorf("cannot slice type '%s' on line %d column %d",
+			tds[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	// A bound may be equal to the length of an array, as it is the index after the last element.
+	if length >= 0 {
+		length++
+	}
+	for _, bound := range []Expression{e.Low, e.High} {
+		if bound == nil {
+			continue
+		}
+
+		if err := checkIndex(bound, "slice bound", length); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := tds[0].Type.(ArrayType); ok {
+		if err := addressArray(e.Expression); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*TypeDeclaration{e.types.getSliceTypeDeclaration(elementType)}, nil
+}
+
+// addressArray marks the variable that holds the sliced array, so it is kept in memory that the slice can refer to. The
+// array may be a field or an element of an array in the variable. Elements of a slice are already in memory.
+func addressArray(exp Expression) error {
+	for true {
+		switch e := exp.(type) {
+		case *FieldAccessExpression:
+			exp = e.Expression
+		case *IndexExpression:
+			tds, err := MustSingleReturnType(e.Expression)
+			if err != nil {
+				return err
+			}
+			if _, ok := tds[0].Type.(ArrayType); !ok {
+				return nil
+			}
+			exp = e.Expression
+		case *IdentifierExpression:
+			if varDecl, ok := e.IdentifierDeclaration.(*VariableDeclaration); ok && !varDecl.Constant {
+				varDecl.Addressed = true
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (e *LenExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, ok := getElementType(tds[0]); !ok {
+		return nil, errors.Errorf("cannot get the length of type '%s' on line %d column %d",
+			tds[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	return e.baseExpression.ResultingTypeDeclarations()
+}
+
+func (e *AppendExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	tds, err := MustSingleReturnType(e.Slice)
+	if err != nil {
+		return nil, err
+	}
+
+	st, ok := tds[0].Type.(SliceType)
+	if !ok {
+		return nil, errors.Errorf("can only append to a slice, type '%s' given on line %d column %d",
+			tds[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	for _, exp := range e.Elements {
+		SetIntegerLiteralTypes(exp, st.ElementType)
+		elementTds, err := MustSingleReturnType(exp)
+		if err != nil {
+			return nil, err
+		}
+
+		if elementTds[0] != st.ElementType {
+			return nil, errors.Errorf("type mismatch: expected '%s' but was given '%s' on line %d column %d",
+				st.ElementType.Type.TypeName(), elementTds[0].Type.TypeName(), exp.UFSourceLine(), exp.UFSourceColumn())
+		}
+	}
+
+	return tds, nil
+}
+
+// getElementType returns the type of the elements of an array or slice, and the length of an array or -1 for a slice.
+// When the type has no elements, false is returned.
+func getElementType(td *TypeDeclaration) (*TypeDeclaration, int64, bool) {
+	switch t := td.Type.(type) {
+	case ArrayType:
+		return t.ElementType, t.Length, true
+	case SliceType:
+		return t.ElementType, -1, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// checkIndex makes sure the index is an Int. When the index is a literal and the length is known, the index must also
+// be smaller than the length.
+func checkIndex(index Expression, name string, length int64) error {
+	tds, err := MustSingleReturnType(index)
+	if err != nil {
+		return err
+	}
+
+	if t, ok := tds[0].Type.(BasicType); !ok || t.DataType != IntDataType {
+		return errors.Errorf("%s must be of type 'Int' but was given '%s' on line %d column %d",
+			name, tds[0].Type.TypeName(), index.UFSourceLine(), index.UFSourceColumn())
+	}
+
+	if lit, ok := index.(*IntegerLiteralExpression); ok && length >= 0 && lit.Value.Cmp(big.NewInt(length)) >= 0 {
+		return errors.Errorf("%s %s out of range on line %d column %d",
+			name, lit.Value.String(), index.UFSourceLine(), index.UFSourceColumn())
+	}
+
+	return nil
+}
+
 func (e *FunctionCallExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
 	if len(e.typeDeclarations) != 0 {
 		return e.typeDeclarations, nil
 	}
 
-	idExp, ok := e.CallSource.(*IdentifierExpression)
-	if !ok {
-		// TODO change when function-as-first-citizen calling is implemented
-		return nil, errors.New("compiler error: FunctionCallExpression.CallSource is not an IdentifierExpression")
+	callSourceTypes, err := MustSingleReturnType(e.CallSource)
+	if err != nil {
+		return nil, err
 	}
 
-	decl, ok := idExp.IdentifierDeclaration.(*FunctionDeclaration)
+	funcType, ok := callSourceTypes[0].Type.(FunctionType)
 	if !ok {
-		// TODO change when function-as-first-citizen calling is implemented
-		return nil, errors.Errorf("cannot call identifier as a function on line %d column %d", e.UFSourceLine(), e.UFSourceColumn())
+		return nil, errors.Errorf("cannot call value of type '%s' as a function on line %d column %d",
+			callSourceTypes[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
 	}
 
-	funcType := decl.FunctionDefinition.FunctionType
-	funcParams := funcType.Parameters
+	funcParams := funcType.ParameterTypeDeclarations()
 	numFuncParams := len(funcParams)
 	numGivenParams := len(e.Parameters)
 	if numGivenParams != numFuncParams {
@@ -366,28 +949,43 @@ func (e *FunctionCallExpression) ResultingTypeDeclarations() ([]*TypeDeclaration
 	}
 
 	for i, exp := range e.Parameters {
+		expectedType := funcParams[i]
+		SetIntegerLiteralTypes(exp, expectedType)
 		givenTypeArr, err := MustSingleReturnType(exp)
 		if err != nil {
 			return nil, err
 		}
 
 		givenType := givenTypeArr[0]
-		expectedType := funcParams[i].VariableDeclaration.TypeDeclaration
 		if givenType != expectedType {
-			return nil, errors.Errorf("parameter type mismatch: expected '%s' but was given '%d' on line %d column %d",
+			return nil, errors.Errorf("parameter type mismatch: expected '%s' but was given '%s' on line %d column %d",
 				expectedType.Type.TypeName(), givenType.Type.TypeName(), exp.UFSourceLine(), exp.UFSourceColumn())
 		}
 	}
 
-	resultTypes := make([]*TypeDeclaration, 0)
-	for _, f := range funcType.ReturnTypes {
-		resultTypes = append(resultTypes, f.VariableDeclaration.TypeDeclaration)
-	}
-
+	resultTypes := funcType.ReturnTypeDeclarations()
 	e.typeDeclarations = resultTypes
 	return resultTypes, nil
 }
 
+func (e *FunctionLiteralExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	funcType := e.FunctionDefinition.FunctionType
+	td := e.types.getFunctionTypeDeclaration(funcType.ParameterTypeDeclarations(), funcType.ReturnTypeDeclarations())
+	return []*TypeDeclaration{td}, nil
+}
+
+// capture adds a variable of a function around the function literal that it uses.
+func (e *FunctionLiteralExpression) capture(decl *VariableDeclaration) {
+	for _, d := range e.Captures {
+		if d == decl {
+			return
+		}
+	}
+
+	e.Captures = append(e.Captures, decl)
+	decl.Captured = true
+}
+
 func (e baseExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
 	if len(e.typeDeclarations) == 0 {
 		return nil, errors.New("compiler error: baseExpression.typeDeclarations was empty")
@@ -401,6 +999,21 @@ func (e dualInputExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, er
 		return e.baseExpression.typeDeclarations, nil
 	}
 
+	td, err := e.operandTypeDeclaration()
+	if err != nil {
+		return nil, err
+	}
+
+	e.baseExpression.typeDeclarations = []*TypeDeclaration{td}
+	return e.baseExpression.typeDeclarations, nil
+}
+
+func (e dualInputExpression) Operands() (Expression, Expression) {
+	return e.Left, e.Right
+}
+
+// operandTypeDeclaration returns the type shared by the left and right side of the expression.
+func (e dualInputExpression) operandTypeDeclaration() (*TypeDeclaration, error) {
 	tds1, err := MustSingleReturnType(e.Left)
 	if err != nil {
 		return nil, err
@@ -411,28 +1024,121 @@ func (e dualInputExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, er
 		return nil, err
 	}
 
+	// An integer literal takes the integer type of the other side, like Byte in b + 1.
+	if tds1[0] != tds2[0] && IsIntegerType(tds1[0]) && IsIntegerType(tds2[0]) {
+		if isIntegerLiteralValue(e.Right) {
+			SetIntegerLiteralTypes(e.Right, tds1[0])
+		} else if isIntegerLiteralValue(e.Left) {
+			SetIntegerLiteralTypes(e.Left, tds2[0])
+		}
+
+		if tds1, err = MustSingleReturnType(e.Left); err != nil {
+			return nil, err
+		}
+		if tds2, err = MustSingleReturnType(e.Right); err != nil {
+			return nil, err
+		}
+	}
+
 	if tds1[0] != tds2[0] {
+		if IsNumericType(tds1[0]) && IsNumericType(tds2[0]) {
+			return nil, errors.Errorf("cannot mix types '%s' and '%s' without a conversion, like %s(...), on line %d column %d",
+				tds1[0].Type.TypeName(), tds2[0].Type.TypeName(), tds1[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+		}
+
 		return nil, errors.Errorf("cannot operate for different types, '%s' and '%s', on line %d column %d",
 			tds1[0].Type.TypeName(), tds2[0].Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
 	}
 
-	e.baseExpression.typeDeclarations = tds1
-	return tds1, nil
+	return tds1[0], nil
 }
 
-func (e dualInputBoolOutputExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
-	tds, err := MustSingleReturnType(e.dualInputExpression)
+func (e dualInputIntegerExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	td, err := e.operandTypeDeclaration()
 	if err != nil {
 		return nil, err
 	}
 
+	if !IsIntegerType(td) {
+		return nil, errors.Errorf("operator '%s' is only defined for integer types, type '%s' given on line %d column %d",
+			lexer.GetTokenTypeString(e.operator), td.Type.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+	}
+
+	return []*TypeDeclaration{td}, nil
+}
+
+func (e dualInputBoolOutputExpression) ResultingTypeDeclarations() ([]*TypeDeclaration, error) {
+	if _, err := e.operandTypeDeclaration(); err != nil {
+		return nil, err
+	}
+
 	if len(e.baseExpression.typeDeclarations) != 1 {
-		return nil, errors.Errorf("compiler error: expression must have 1 return type but had %d", len(tds))
+		return nil, errors.Errorf("compiler error: expression must have 1 return type but had %d", len(e.baseExpression.typeDeclarations))
 	}
 
 	return e.baseExpression.typeDeclarations, nil
 }
 
+// SetIntegerLiteralTypes gives the integer literals that a value is made of the integer type that the value is used as,
+// like Byte in var b Byte = 255, so they are checked against the range of that type. Integer literals have type Int
+// otherwise.
+func SetIntegerLiteralTypes(exp Expression, td *TypeDeclaration) {
+	if !IsIntegerType(td) {
+		return
+	}
+
+	switch e := exp.(type) {
+	case *IntegerLiteralExpression:
+		e.typeDeclarations = []*TypeDeclaration{td}
+	case *NegateExpression:
+		SetIntegerLiteralTypes(e.Expression, td)
+	case *BitwiseNotExpression:
+		SetIntegerLiteralTypes(e.Expression, td)
+	case *AddExpression, *SubtractExpression, *MultiplyExpression, *DivideExpression, *ModuloExpression,
+		*BitwiseAndExpression, *BitwiseOrExpression, *BitwiseXorExpression, *ShiftLeftExpression, *ShiftRightExpression:
+		left, right := e.(DualInputOperands).Operands()
+		SetIntegerLiteralTypes(left, td)
+		SetIntegerLiteralTypes(right, td)
+	}
+}
+
+// isIntegerLiteralValue returns whether the value of an expression is made of integer literals only, like -(1 << 4).
+func isIntegerLiteralValue(exp Expression) bool {
+	switch e := exp.(type) {
+	case *IntegerLiteralExpression:
+		return true
+	case *NegateExpression:
+		return isIntegerLiteralValue(e.Expression)
+	case *BitwiseNotExpression:
+		return isIntegerLiteralValue(e.Expression)
+	case *AddExpression, *SubtractExpression, *MultiplyExpression, *DivideExpression, *ModuloExpression,
+		*BitwiseAndExpression, *BitwiseOrExpression, *BitwiseXorExpression, *ShiftLeftExpression, *ShiftRightExpression:
+		left, right := e.(DualInputOperands).Operands()
+		return isIntegerLiteralValue(left) && isIntegerLiteralValue(right)
+	default:
+		return false
+	}
+}
+
+// IsNumericType returns whether the type declaration is a built-in numeric type.
+func IsNumericType(td *TypeDeclaration) bool {
+	t, ok := td.Type.(BasicType)
+	return ok && t.IsNumeric()
+}
+
+// IsIntegerType returns whether the type declaration is a built-in integer type.
+func IsIntegerType(td *TypeDeclaration) bool {
+	t, ok := td.Type.(BasicType)
+	if !ok {
+		return false
+	}
+
+	_, _, ok = t.IntegerRange()
+	return ok
+}
+
+// MustSingleReturnType returns the type of an expression that is used as a single value. Only calls of functions that
+// return no value or multiple values can fail this, as all other expressions result in a single value.
 func MustSingleReturnType(expression resultingTypeDeclarations) ([]*TypeDeclaration, error) {
 	tds, err := expression.ResultingTypeDeclarations()
 	if err != nil {
@@ -440,13 +1146,23 @@ func MustSingleReturnType(expression resultingTypeDeclarations) ([]*TypeDeclarat
 	}
 
 	if len(tds) != 1 {
-		return nil, errors.Errorf("compiler error: expression must have 1 return type but had %d", len(tds))
+		n, ok := expression.(Node)
+		if !ok {
+			return nil, errors.Errorf("compiler error: expression must have 1 return type but had %d", len(tds))
+		}
+		if len(tds) == 0 {
+			return nil, errors.Errorf("function call without a value used as a value on line %d column %d",
+				n.UFSourceLine(), n.UFSourceColumn())
+		}
+		return nil, errors.Errorf("function call with %d values used as a single value on line %d column %d",
+			len(tds), n.UFSourceLine(), n.UFSourceColumn())
 	}
 
 	return tds, nil
 }
 
 func (*IntegerLiteralExpression) exprNode()   {}
+func (*FloatLiteralExpression) exprNode()     {}
 func (*CharacterLiteralExpression) exprNode() {}
 func (*StringLiteralExpression) exprNode()    {}
 func (*BooleanLiteralExpression) exprNode()   {}
@@ -455,6 +1171,12 @@ func (*AddExpression) exprNode()              {}
 func (*SubtractExpression) exprNode()         {}
 func (*MultiplyExpression) exprNode()         {}
 func (*DivideExpression) exprNode()           {}
+func (*ModuloExpression) exprNode()           {}
+func (*BitwiseAndExpression) exprNode()       {}
+func (*BitwiseOrExpression) exprNode()        {}
+func (*BitwiseXorExpression) exprNode()       {}
+func (*ShiftLeftExpression) exprNode()        {}
+func (*ShiftRightExpression) exprNode()       {}
 func (*EqualExpression) exprNode()            {}
 func (*NotEqualExpression) exprNode()         {}
 func (*LessExpression) exprNode()             {}
@@ -464,6 +1186,16 @@ func (*GreaterOrEqualExpression) exprNode()   {}
 func (*AndExpression) exprNode()              {}
 func (*OrExpression) exprNode()               {}
 func (*NotExpression) exprNode()              {}
-
-func (*FunctionCallExpression) exprNode() {}
-func (*FunctionCallExpression) stmtNode() {}
+func (*NegateExpression) exprNode()           {}
+func (*BitwiseNotExpression) exprNode()       {}
+func (*ConversionExpression) exprNode()       {}
+func (*FieldAccessExpression) exprNode()      {}
+func (*StructLiteralExpression) exprNode()    {}
+func (*IndexExpression) exprNode()            {}
+func (*SliceExpression) exprNode()            {}
+func (*LenExpression) exprNode()              {}
+func (*AppendExpression) exprNode()           {}
+
+func (*FunctionCallExpression) exprNode()    {}
+func (*FunctionCallExpression) stmtNode()    {}
+func (*FunctionLiteralExpression) exprNode() {}
@@ -1,5 +1,9 @@
 package parser
 
+import (
+	"github.com/milandamen/quisnix/lexer"
+)
+
 type StatementHavingVariableDeclaration interface {
 	GetVariableDeclaration() Declaration
 	SetVariableDeclaration(declaration Declaration)
@@ -15,16 +19,40 @@ type AssignStatement struct {
 	Expression          Expression
 }
 
-type AddAssignStatement struct {
+// Statement assigning each value of a function call to a variable, like q, r = divmod(7, 2).
+type MultiAssignStatement struct {
 	nodeSource
-	VariableDeclaration Declaration
-	Expression          Expression
+	VariableDeclarations []Declaration // A nil declaration discards its value, like q, _ = divmod(7, 2).
+	Expression           Expression
 }
 
-type SubtractAssignStatement struct {
+// Statement computing a value only to discard it, like _ = f().
+type DiscardStatement struct {
 	nodeSource
-	VariableDeclaration Declaration
-	Expression          Expression
+	Expression Expression
+}
+
+// Statement applying an operator to a variable and a value and assigning the result to the variable, like a += 2.
+type CompoundAssignStatement struct {
+	nodeSource
+	Operator   lexer.TokenType // The operator that is applied, like lexer.Add for +=.
+	Expression Expression
+	target     *IdentifierExpression
+	operation  Expression // The target with the operator applied to it and the expression.
+}
+
+// Statement assigning to a field of a struct variable, like p.x = 1.
+type FieldAssignStatement struct {
+	nodeSource
+	Target     *FieldAccessExpression // Field being assigned, its innermost expression is the variable.
+	Expression Expression
+}
+
+// Statement assigning to an element of an array or slice variable, like a[i] = 1.
+type IndexAssignStatement struct {
+	nodeSource
+	Target     *IndexExpression // Element being assigned, its innermost expression is the variable.
+	Expression Expression
 }
 
 type IncrementStatement struct {
@@ -46,6 +74,7 @@ type IfStatement struct {
 
 type ForStatement struct {
 	nodeSource
+	Label      string // Name for break and continue statements to refer to the loop, empty when it has none.
 	Init       Statement
 	Condition  Expression
 	LoopAction Statement
@@ -54,10 +83,25 @@ type ForStatement struct {
 
 type WhileStatement struct {
 	nodeSource
+	Label      string // Name for break and continue statements to refer to the loop, empty when it has none.
 	Condition  Expression
 	Statements []Statement
 }
 
+// Statement leaving a loop, like break or break outer.
+type BreakStatement struct {
+	nodeSource
+	Label string    // Label of the loop, empty for the innermost loop.
+	Loop  Statement // The ForStatement or WhileStatement that is left, nil when there is no such loop.
+}
+
+// Statement continuing with the next iteration of a loop, like continue or continue outer.
+type ContinueStatement struct {
+	nodeSource
+	Label string    // Label of the loop, empty for the innermost loop.
+	Loop  Statement // The ForStatement or WhileStatement that is continued, nil when there is no such loop.
+}
+
 type ReturnStatement struct {
 	nodeSource
 	ReturnExpressions []Expression
@@ -71,20 +115,66 @@ func (s *AssignStatement) SetVariableDeclaration(declaration Declaration) {
 	s.VariableDeclaration = declaration
 }
 
-func (s *AddAssignStatement) GetVariableDeclaration() Declaration {
-	return s.VariableDeclaration
+func newCompoundAssignStatement(source nodeSource, operatorSource nodeSource, operator lexer.TokenType,
+	target *IdentifierExpression, exp Expression, scope Scope) (*CompoundAssignStatement, bool) {
+
+	operation, ok := newBinaryExpression(operator, operatorSource, target, exp, scope)
+	if !ok {
+		return nil, false
+	}
+
+	return &CompoundAssignStatement{
+		nodeSource: source,
+		Operator:   operator,
+		Expression: exp,
+		target:     target,
+		operation:  operation,
+	}, true
 }
 
-func (s *AddAssignStatement) SetVariableDeclaration(declaration Declaration) {
-	s.VariableDeclaration = declaration
+func (s *CompoundAssignStatement) GetVariableDeclaration() Declaration {
+	return s.target.IdentifierDeclaration
 }
 
-func (s *SubtractAssignStatement) GetVariableDeclaration() Declaration {
-	return s.VariableDeclaration
+func (s *CompoundAssignStatement) SetVariableDeclaration(declaration Declaration) {
+	s.target.IdentifierDeclaration = declaration
 }
 
-func (s *SubtractAssignStatement) SetVariableDeclaration(declaration Declaration) {
-	s.VariableDeclaration = declaration
+// Operation returns the expression resulting in the new value of the variable, like a % 2 for a %= 2.
+func (s *CompoundAssignStatement) Operation() Expression {
+	return s.operation
+}
+
+func (s *FieldAssignStatement) GetVariableDeclaration() Declaration {
+	return assignedVariable(s.Target).IdentifierDeclaration
+}
+
+func (s *FieldAssignStatement) SetVariableDeclaration(declaration Declaration) {
+	assignedVariable(s.Target).IdentifierDeclaration = declaration
+}
+
+func (s *IndexAssignStatement) GetVariableDeclaration() Declaration {
+	return assignedVariable(s.Target).IdentifierDeclaration
+}
+
+func (s *IndexAssignStatement) SetVariableDeclaration(declaration Declaration) {
+	assignedVariable(s.Target).IdentifierDeclaration = declaration
+}
+
+// assignedVariable returns the variable at the start of a chain of field accesses and indices that is assigned to.
+func assignedVariable(target Expression) *IdentifierExpression {
+	for true {
+		switch e := target.(type) {
+		case *FieldAccessExpression:
+			target = e.Expression
+		case *IndexExpression:
+			target = e.Expression
+		default:
+			return target.(*IdentifierExpression)
+		}
+	}
+
+	return nil
 }
 
 func (s *IncrementStatement) GetVariableDeclaration() Declaration {
@@ -116,11 +206,16 @@ func (s *WhileStatement) GetCondition() Expression {
 }
 
 func (*AssignStatement) stmtNode()         {}
-func (*AddAssignStatement) stmtNode()      {}
-func (*SubtractAssignStatement) stmtNode() {}
+func (*MultiAssignStatement) stmtNode()    {}
+func (*DiscardStatement) stmtNode()        {}
+func (*CompoundAssignStatement) stmtNode() {}
+func (*FieldAssignStatement) stmtNode()    {}
+func (*IndexAssignStatement) stmtNode()    {}
 func (*IncrementStatement) stmtNode()      {}
 func (*DecrementStatement) stmtNode()      {}
 func (*IfStatement) stmtNode()             {}
 func (*ForStatement) stmtNode()            {}
 func (*WhileStatement) stmtNode()          {}
+func (*BreakStatement) stmtNode()          {}
+func (*ContinueStatement) stmtNode()       {}
 func (*ReturnStatement) stmtNode()         {}
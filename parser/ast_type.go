@@ -1,5 +1,14 @@
 package parser
 
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 type BasicDataType int
 
 const (
@@ -8,6 +17,7 @@ const (
 	ByteDataType
 	StringDataType
 	BoolDataType
+	FloatDataType
 )
 
 type BasicType struct {
@@ -20,8 +30,21 @@ type StructType struct {
 	Name   string
 }
 
+// Fixed-size array like [3]Int. Arrays are values, assigning one copies all its elements.
+type ArrayType struct {
+	ElementType *TypeDeclaration
+	Length      int64
+}
+
+// Growable slice like []Int. A slice refers to elements on the heap, so copies of a slice share its elements.
+type SliceType struct {
+	ElementType *TypeDeclaration
+}
+
+// Signature of a function like func(Int, Bool) Int. Functions are values of their function type, so they can be stored
+// in variables and passed to other functions.
 type FunctionType struct {
-	Parameters  []*Field
+	Parameters  []*Field // Unnamed in a function type that is used as the type of a value.
 	ReturnTypes []*Field // Only the type declaration is used.
 }
 
@@ -36,14 +59,272 @@ func (t BasicType) TypeName() string {
 	return t.Name
 }
 
+// IntegerRange returns the smallest and largest value an integer of this type can hold.
+// When the type is not an integer type, false is returned.
+func (t BasicType) IntegerRange() (*big.Int, *big.Int, bool) {
+	switch t.DataType {
+	case IntDataType:
+		return big.NewInt(math.MinInt32), big.NewInt(math.MaxInt32), true
+	case ByteDataType:
+		return big.NewInt(0), big.NewInt(math.MaxUint8), true
+	default:
+		return nil, nil, false
+	}
+}
+
+// IsNumeric returns whether arithmetic can be done on values of this type, and whether it can be converted
+// to other numeric types.
+func (t BasicType) IsNumeric() bool {
+	return t.DataType == IntDataType || t.DataType == ByteDataType || t.DataType == FloatDataType
+}
+
 func (t StructType) TypeName() string {
 	return t.Name
 }
 
+// FieldIndex returns the index of the field with the given name.
+// When the struct has no such field, false is returned.
+func (t StructType) FieldIndex(name string) (int, bool) {
+	for i, f := range t.Fields {
+		if f.Name == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func (t ArrayType) TypeName() string {
+	return "[" + strconv.FormatInt(t.Length, 10) + "]" + t.ElementType.Type.TypeName()
+}
+
+func (t SliceType) TypeName() string {
+	return "[]" + t.ElementType.Type.TypeName()
+}
+
 func (t FunctionType) TypeName() string {
-	return "func" // FIXME: output parameters and return types?
+	params := make([]string, 0, len(t.Parameters))
+	for _, td := range t.ParameterTypeDeclarations() {
+		params = append(params, td.Type.TypeName())
+	}
+
+	returnTypes := make([]string, 0, len(t.ReturnTypes))
+	for _, td := range t.ReturnTypeDeclarations() {
+		returnTypes = append(returnTypes, td.Type.TypeName())
+	}
+
+	name := "func(" + strings.Join(params, ", ") + ")"
+	switch len(returnTypes) {
+	case 0:
+		return name
+	case 1:
+		return name + " " + returnTypes[0]
+	default:
+		return name + " (" + strings.Join(returnTypes, ", ") + ")"
+	}
+}
+
+// ParameterTypeDeclarations returns the types of the parameters of the function.
+func (t FunctionType) ParameterTypeDeclarations() []*TypeDeclaration {
+	return getFieldTypeDeclarations(t.Parameters)
+}
+
+// ReturnTypeDeclarations returns the types of the values the function returns.
+func (t FunctionType) ReturnTypeDeclarations() []*TypeDeclaration {
+	return getFieldTypeDeclarations(t.ReturnTypes)
+}
+
+func getFieldTypeDeclarations(fields []*Field) []*TypeDeclaration {
+	tds := make([]*TypeDeclaration, 0, len(fields))
+	for _, f := range fields {
+		tds = append(tds, f.VariableDeclaration.TypeDeclaration)
+	}
+
+	return tds
 }
 
 func (t UnknownType) TypeName() string {
 	return t.Name
 }
+
+// typeCache holds the types of a file scope that are created where they are used, like array, slice and function types.
+// They are cached by the types they are made of, so the same type always has the same declaration and can be compared
+// like any other type.
+type typeCache struct {
+	compositeTypes map[compositeTypeKey]*TypeDeclaration
+	functionTypes  functionTypeNode
+}
+
+type compositeTypeKey struct {
+	elementType *TypeDeclaration
+	length      int64 // -1 for slices.
+}
+
+// functionTypeNode is a node in a tree of function types, as the lists of parameter and return types cannot be used as
+// a key. The declaration of a function type is found by following its parameter types from the root, then the return
+// types.
+type functionTypeNode struct {
+	next        map[*TypeDeclaration]*functionTypeNode
+	returnTypes *functionTypeNode // Node after the last parameter type, where the return types start.
+	decl        *TypeDeclaration  // Declaration of the function type that ends at this node.
+}
+
+func newTypeCache() *typeCache {
+	return &typeCache{
+		compositeTypes: make(map[compositeTypeKey]*TypeDeclaration),
+	}
+}
+
+// getArrayTypeDeclaration returns the declaration of an array with the given element type and length.
+func (c *typeCache) getArrayTypeDeclaration(elementType *TypeDeclaration, length int64) *TypeDeclaration {
+	return c.getCompositeTypeDeclaration(compositeTypeKey{elementType: elementType, length: length},
+		ArrayType{ElementType: elementType, Length: length})
+}
+
+// getSliceTypeDeclaration returns the declaration of a slice with the given element type.
+func (c *typeCache) getSliceTypeDeclaration(elementType *TypeDeclaration) *TypeDeclaration {
+	return c.getCompositeTypeDeclaration(compositeTypeKey{elementType: elementType, length: -1},
+		SliceType{ElementType: elementType})
+}
+
+// getFunctionTypeDeclaration returns the declaration of a function type with the given parameter and return types.
+func (c *typeCache) getFunctionTypeDeclaration(parameterTypes []*TypeDeclaration, returnTypes []*TypeDeclaration) *TypeDeclaration {
+	t := FunctionType{
+		Parameters:  make([]*Field, 0, len(parameterTypes)),
+		ReturnTypes: make([]*Field, 0, len(returnTypes)),
+	}
+	for _, td := range parameterTypes {
+		t.Parameters = append(t.Parameters, &Field{VariableDeclaration: &VariableDeclaration{TypeDeclaration: td}})
+	}
+	for _, td := range returnTypes {
+		t.ReturnTypes = append(t.ReturnTypes, &Field{VariableDeclaration: &VariableDeclaration{TypeDeclaration: td}})
+	}
+
+	// A parameter or return type is not known yet, so the declaration is replaced once it is resolved.
+	if !isResolvedType(&TypeDeclaration{Type: t}) {
+		return &TypeDeclaration{Type: t}
+	}
+
+	n := &c.functionTypes
+	for _, td := range parameterTypes {
+		n = n.getNext(td)
+	}
+	if n.returnTypes == nil {
+		n.returnTypes = &functionTypeNode{}
+	}
+	n = n.returnTypes
+	for _, td := range returnTypes {
+		n = n.getNext(td)
+	}
+
+	if n.decl == nil {
+		n.decl = &TypeDeclaration{Type: t}
+	}
+	return n.decl
+}
+
+// getNext returns the node for the type that follows in the list, adding it when it does not exist yet.
+func (n *functionTypeNode) getNext(td *TypeDeclaration) *functionTypeNode {
+	if n.next == nil {
+		n.next = make(map[*TypeDeclaration]*functionTypeNode)
+	}
+
+	next, ok := n.next[td]
+	if !ok {
+		next = &functionTypeNode{}
+		n.next[td] = next
+	}
+	return next
+}
+
+func (c *typeCache) getCompositeTypeDeclaration(key compositeTypeKey, t Type) *TypeDeclaration {
+	// The element type is not known yet, so the declaration is replaced once it is resolved.
+	if !isResolvedType(key.elementType) {
+		return &TypeDeclaration{Type: t}
+	}
+
+	decl, ok := c.compositeTypes[key]
+	if !ok {
+		decl = &TypeDeclaration{Type: t}
+		c.compositeTypes[key] = decl
+	}
+
+	return decl
+}
+
+// isResolvedType returns whether the type and all types it is made of are known.
+func isResolvedType(td *TypeDeclaration) bool {
+	switch t := td.Type.(type) {
+	case UnknownType:
+		return false
+	case ArrayType:
+		return isResolvedType(t.ElementType)
+	case SliceType:
+		return isResolvedType(t.ElementType)
+	case FunctionType:
+		for _, td := range append(t.ParameterTypeDeclarations(), t.ReturnTypeDeclarations()...) {
+			if !isResolvedType(td) {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// resolveTypeDeclaration returns the declaration of a type that was used before it was declared.
+func (c *typeCache) resolveTypeDeclaration(td *TypeDeclaration) (*TypeDeclaration, error) {
+	switch t := td.Type.(type) {
+	case UnknownType:
+		decl := t.Scope.SearchTypeDeclaration(t.Name)
+		if decl == nil {
+			return nil, errors.Errorf("no type found for identifier '%s' at line %d column %d",
+				t.Name, t.nodeSource.UFSourceLine(), t.nodeSource.UFSourceColumn())
+		}
+
+		return decl, nil
+	case ArrayType:
+		elementType, err := c.resolveTypeDeclaration(t.ElementType)
+		if err != nil {
+			return nil, err
+		}
+
+		return c.getArrayTypeDeclaration(elementType, t.Length), nil
+	case SliceType:
+		elementType, err := c.resolveTypeDeclaration(t.ElementType)
+		if err != nil {
+			return nil, err
+		}
+
+		return c.getSliceTypeDeclaration(elementType), nil
+	case FunctionType:
+		parameterTypes, err := c.resolveTypeDeclarations(t.ParameterTypeDeclarations())
+		if err != nil {
+			return nil, err
+		}
+
+		returnTypes, err := c.resolveTypeDeclarations(t.ReturnTypeDeclarations())
+		if err != nil {
+			return nil, err
+		}
+
+		return c.getFunctionTypeDeclaration(parameterTypes, returnTypes), nil
+	default:
+		return td, nil
+	}
+}
+
+func (c *typeCache) resolveTypeDeclarations(tds []*TypeDeclaration) ([]*TypeDeclaration, error) {
+	resolved := make([]*TypeDeclaration, 0, len(tds))
+	for _, td := range tds {
+		r, err := c.resolveTypeDeclaration(td)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved = append(resolved, r)
+	}
+
+	return resolved, nil
+}
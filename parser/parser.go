@@ -1,33 +1,74 @@
 package parser
 
 import (
+	"math"
+	"math/big"
 	"strings"
 
 	"github.com/milandamen/quisnix/lexer"
 	"github.com/pkg/errors"
 )
 
+// blankIdentifier can only be assigned to, which discards the value.
+const blankIdentifier = "_"
+
 type Parser struct {
 	tokens   []lexer.Token
 	tokenPos int
 
+	// The file scope that the unknown types and identifiers below were used in, until they are resolved.
+	fileScope                   *FileScope
 	unknownFieldTypes           []*Field
 	unknownVarFuncIdentifiers   []*IdentifierExpression
 	unknownIdentifierStatements []Statement
+	unknownStructLiterals       []*StructLiteralExpression
+
+	// Set while parsing the condition of an if, for or while statement, where a '{' after an identifier starts
+	// the block of the statement instead of a struct literal. Struct literals can still be used in parentheses.
+	structLiteralsDisabled bool
+
+	// The loops around the statements being parsed, innermost last, that break and continue statements refer to.
+	enclosingLoops []enclosingLoop
+}
+
+type enclosingLoop struct {
+	label string
+	loop  Statement
 }
 
 func (p *Parser) Parse(tokens []lexer.Token) ([]Declaration, *FileScope, error) {
+	fileScope := NewFileScope(NewBuiltInScope())
+	topLevelDeclarations, err := p.ParseFile(tokens, fileScope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := p.ResolveUnknownTypes(); err != nil {
+		return nil, nil, err
+	}
+
+	return topLevelDeclarations, fileScope, nil
+}
+
+// ParseFile parses the tokens of a single source file into the given file scope.
+// Identifiers that could not be found yet are only resolved when ResolveUnknownTypes is called,
+// so multiple files can be parsed into the same file scope and reference each other. Parsing into another file scope
+// forgets the files parsed before.
+func (p *Parser) ParseFile(tokens []lexer.Token, fileScope *FileScope) ([]Declaration, error) {
+	if fileScope != p.fileScope {
+		p.reset()
+		p.fileScope = fileScope
+	}
 	p.tokens = tokens
 	p.tokenPos = 0
+	p.structLiteralsDisabled = false
+	p.enclosingLoops = nil
 
 	topLevelDeclarations := make([]Declaration, 0)
-	builtInScope := NewBuiltInScope()
-	fileScope := NewFileScope(builtInScope)
-
 	for true {
 		tln, err := p.parseTopLevel(fileScope)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		if tln == nil {
 			break
@@ -36,11 +77,29 @@ func (p *Parser) Parse(tokens []lexer.Token) ([]Declaration, *FileScope, error)
 		topLevelDeclarations = append(topLevelDeclarations, tln)
 	}
 
+	return topLevelDeclarations, nil
+}
+
+// ResolveUnknownTypes resolves all types and identifiers that were used before they were declared
+// in the files parsed so far.
+func (p *Parser) ResolveUnknownTypes() error {
+	defer p.reset()
 	if err := p.resolveUnknownTypes(); err != nil {
-		return nil, nil, errors.Wrap(err, "could not resolve unknown types")
+		return errors.Wrap(err, "could not resolve unknown types")
 	}
 
-	return topLevelDeclarations, fileScope, nil
+	return nil
+}
+
+// reset forgets what was left of an earlier parse, also when it failed, so the parser can be used again.
+func (p *Parser) reset() {
+	p.fileScope = nil
+	p.unknownFieldTypes = nil
+	p.unknownVarFuncIdentifiers = nil
+	p.unknownIdentifierStatements = nil
+	p.unknownStructLiterals = nil
+	p.structLiteralsDisabled = false
+	p.enclosingLoops = nil
 }
 
 func (p *Parser) parseTopLevel(currentScope *FileScope) (Declaration, error) {
@@ -54,9 +113,245 @@ func (p *Parser) parseTopLevel(currentScope *FileScope) (Declaration, error) {
 	case lexer.Func:
 		decl, err := p.parseTopLevelFunctionDeclaration(token, currentScope)
 		return decl, errors.Wrapf(err, "could not parse function declaration at line %d column %d", token.UFLine(), token.UFColumn())
+	case lexer.Type:
+		decl, err := p.parseTopLevelTypeDeclaration(token, currentScope)
+		return decl, errors.Wrapf(err, "could not parse type declaration at line %d column %d", token.UFLine(), token.UFColumn())
+	case lexer.Var, lexer.Const:
+		decl, err := p.parseTopLevelVariableDeclaration(token, currentScope)
+		return decl, errors.Wrapf(err, "could not parse %s declaration at line %d column %d",
+			lexer.GetTokenTypeString(tokenType), token.UFLine(), token.UFColumn())
 	default:
-		return nil, unexpectedTokenError(token, lexer.Func)
+		return nil, unexpectedTokenError(token, lexer.Func, lexer.Type, lexer.Var, lexer.Const)
+	}
+}
+
+func (p *Parser) parseTopLevelTypeDeclaration(startToken lexer.Token, currentScope *FileScope) (Declaration, error) {
+	idToken, err := p.parseTypeDeclarationName()
+	if err != nil {
+		return nil, err
+	}
+
+	id := idToken.Identifier()
+	ns := makeNodeSource(startToken)
+	if d := currentScope.SearchDeclaration(id); d != nil {
+		return nil, alreadyDeclaredError(d, ns)
+	}
+	if ssns, ok := currentScope.subScopeDeclarations[id]; ok {
+		return nil, alreadyDeclaredInFile(ns, ssns)
+	}
+
+	t, err := p.parseStructType(id, currentScope)
+	if err != nil {
+		return nil, err
+	}
+
+	decl := &TypeDeclaration{
+		nodeSource: ns,
+		Type:       t,
+	}
+	currentScope.DeclareType(id, decl)
+	return decl, nil
+}
+
+// parseTypeDeclarationStatement parses a type declared inside a block. The type is visible from the point where it is
+// declared, and inside its own fields.
+func (p *Parser) parseTypeDeclarationStatement(startToken lexer.Token, currentScope Scope) (Statement, Scope, error) {
+	idToken, err := p.parseTypeDeclarationName()
+	if err != nil {
+		return nil, currentScope, err
+	}
+
+	id := idToken.Identifier()
+	ns := makeNodeSource(startToken)
+	if d := currentScope.SearchDeclaration(id); d != nil {
+		return nil, currentScope, alreadyDeclaredError(d, ns)
+	}
+
+	// Fields are parsed in the scope the type will be declared in, so a field referring to the type itself is
+	// resolved together with the other unknown types.
+	currentScope = currentScope.CloneShallow()
+	t, err := p.parseStructType(id, currentScope)
+	if err != nil {
+		return nil, currentScope, err
+	}
+
+	decl := &TypeDeclaration{
+		nodeSource: ns,
+		Type:       t,
+	}
+	currentScope.DeclareType(id, decl)
+	return decl, currentScope, nil
+}
+
+func (p *Parser) parseTypeDeclarationName() (lexer.IdentifierToken, error) {
+	token := p.getNextToken()
+	if token == nil {
+		return lexer.IdentifierToken{}, unexpectedEOF()
+	}
+	if token.Type() != lexer.Identifier {
+		return lexer.IdentifierToken{}, unexpectedTokenError(token, lexer.Identifier)
+	}
+
+	idToken, ok := token.(lexer.IdentifierToken)
+	if !ok {
+		return lexer.IdentifierToken{}, unexpectedTokenCastError(token)
+	}
+
+	return idToken, nil
+}
+
+// parseStructType parses the fields of a struct type like "{ x Int; y Int; }".
+func (p *Parser) parseStructType(name string, currentScope Scope) (StructType, error) {
+	token := p.getNextToken()
+	if token == nil {
+		return StructType{}, unexpectedEOF()
+	}
+	if token.Type() != lexer.LeftBrace {
+		return StructType{}, unexpectedTokenError(token, lexer.LeftBrace)
+	}
+
+	fields := make([]Field, 0)
+	fieldSources := make(map[string]nodeSource)
+	for true {
+		token = p.getNextToken()
+		if token == nil {
+			return StructType{}, unexpectedEOF()
+		}
+		if token.Type() == lexer.RightBrace {
+			break
+		}
+		if token.Type() != lexer.Identifier {
+			return StructType{}, unexpectedTokenError(token, lexer.Identifier, lexer.RightBrace)
+		}
+
+		nameToken, ok := token.(lexer.IdentifierToken)
+		if !ok {
+			return StructType{}, unexpectedTokenCastError(token)
+		}
+
+		fieldName := nameToken.Identifier()
+		ns := makeNodeSource(nameToken)
+		if prev, ok := fieldSources[fieldName]; ok {
+			return StructType{}, errors.Errorf("field '%s' on line %d column %d is already declared on line %d column %d",
+				fieldName, ns.UFSourceLine(), ns.UFSourceColumn(), prev.UFSourceLine(), prev.UFSourceColumn())
+		}
+		fieldSources[fieldName] = ns
+
+		typeDecl, err := p.parseTypeReference(currentScope)
+		if err != nil {
+			return StructType{}, err
+		}
+
+		fields = append(fields, *p.newTypedField(fieldName, typeDecl, ns))
+
+		token = p.getNextToken()
+		if token == nil {
+			return StructType{}, unexpectedEOF()
+		}
+		if token.Type() != lexer.Semicolon {
+			return StructType{}, unexpectedTokenError(token, lexer.Semicolon)
+		}
+	}
+
+	return StructType{
+		Fields: fields,
+		Name:   name,
+	}, nil
+}
+
+// parseTopLevelVariableDeclaration parses a variable or constant in the file scope, like "var a Int = 1;" or
+// "const b = 2;". The type may be left out when there is a value, and is then taken from the value by the semantic
+// analyzer. The value may refer to declarations further down in the file.
+func (p *Parser) parseTopLevelVariableDeclaration(startToken lexer.Token, currentScope *FileScope) (Declaration, error) {
+	token := p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+	if token.Type() != lexer.Identifier {
+		return nil, unexpectedTokenError(token, lexer.Identifier)
+	}
+
+	idToken, ok := token.(lexer.IdentifierToken)
+	if !ok {
+		return nil, unexpectedTokenCastError(token)
+	}
+
+	id := idToken.Identifier()
+	ns := makeNodeSource(startToken)
+	if d := currentScope.SearchDeclaration(id); d != nil {
+		return nil, alreadyDeclaredError(d, ns)
+	}
+	if ssns, ok := currentScope.subScopeDeclarations[id]; ok {
+		return nil, alreadyDeclaredInFile(ns, ssns)
+	}
+
+	constant := startToken.Type() == lexer.Const
+	typeDecl, value, err := p.parseVariableTypeAndValue(constant, currentScope)
+	if err != nil {
+		return nil, err
+	}
+
+	varDecl := &VariableDeclaration{
+		nodeSource:      ns,
+		Name:            id,
+		TypeDeclaration: typeDecl,
+		Value:           value,
+		Constant:        constant,
+	}
+	if typeDecl != nil && !isResolvedType(typeDecl) {
+		p.unknownIdentifierStatements = append(p.unknownIdentifierStatements, varDecl)
+	}
+
+	currentScope.DeclareVariable(id, varDecl)
+	return varDecl, nil
+}
+
+// parseVariableTypeAndValue parses the rest of a variable declaration after its name, up to and including the
+// semicolon. Either the type or the value may be left out, in which case nil is returned for it.
+func (p *Parser) parseVariableTypeAndValue(valueRequired bool, currentScope Scope) (*TypeDeclaration, Expression, error) {
+	token := p.peekNextToken()
+	if token == nil {
+		return nil, nil, unexpectedEOF()
+	}
+
+	var typeDecl *TypeDeclaration
+	if token.Type() != lexer.Assign {
+		var err error
+		typeDecl, err = p.parseTypeReference(currentScope)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	token = p.getNextToken()
+	if token == nil {
+		return nil, nil, unexpectedEOF()
+	}
+
+	var value Expression
+	if token.Type() == lexer.Assign {
+		var err error
+		value, err = p.parseExpression(0, currentScope)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		token = p.getNextToken()
+		if token == nil {
+			return nil, nil, unexpectedEOF()
+		}
+	} else if valueRequired {
+		return nil, nil, unexpectedTokenError(token, lexer.Assign)
+	}
+
+	if token.Type() != lexer.Semicolon {
+		if value == nil {
+			return nil, nil, unexpectedTokenError(token, lexer.Assign, lexer.Semicolon)
+		}
+		return nil, nil, unexpectedTokenError(token, lexer.Semicolon)
 	}
+
+	return typeDecl, value, nil
 }
 
 func (p *Parser) parseTopLevelFunctionDeclaration(startToken lexer.Token, currentScope *FileScope) (Declaration, error) {
@@ -82,7 +377,7 @@ func (p *Parser) parseTopLevelFunctionDeclaration(startToken lexer.Token, curren
 		return nil, alreadyDeclaredInFile(ns, ssns)
 	}
 
-	def, err := p.parseFunctionDefinition(currentScope)
+	def, err := p.parseFunctionDefinition(NewBasicScope(currentScope, FunctionScopeType))
 	if err != nil {
 		return nil, err
 	}
@@ -92,8 +387,8 @@ func (p *Parser) parseTopLevelFunctionDeclaration(startToken lexer.Token, curren
 	return decl, nil
 }
 
-func (p *Parser) parseFunctionDefinition(currentScope Scope) (*FunctionDefinition, error) {
-	var funcScope Scope = NewBasicScope(currentScope, FunctionScopeType)
+// parseFunctionDefinition parses the parameters, return types and statements of a function into its function scope.
+func (p *Parser) parseFunctionDefinition(funcScope Scope) (*FunctionDefinition, error) {
 	var parameters []*Field
 	var err error
 	parameters, funcScope, err = p.parseFunctionParameters(funcScope)
@@ -185,22 +480,13 @@ func (p *Parser) parseFunctionParameters(currentScope Scope) ([]*Field, Scope, e
 			})
 		}
 
-		token = p.getNextToken()
-		if token == nil {
-			return nil, currentScope, unexpectedEOF()
-		}
-		if token.Type() != lexer.Identifier {
-			return nil, currentScope, unexpectedTokenError(token, lexer.Identifier)
-		}
-
-		typeToken, ok := token.(lexer.IdentifierToken)
-		if !ok {
-			return nil, currentScope, unexpectedTokenCastError(token)
+		typeDecl, err := p.parseTypeReference(currentScope)
+		if err != nil {
+			return nil, currentScope, err
 		}
 
 		var f *Field
-		var err error
-		f, currentScope, err = p.getTypedField(id, typeToken, makeNodeSource(nameToken), currentScope)
+		f, currentScope, err = p.getTypedField(id, typeDecl, makeNodeSource(nameToken), currentScope)
 		if err != nil {
 			return nil, currentScope, err
 		}
@@ -218,16 +504,14 @@ func (p *Parser) parseFunctionReturnTypes(currentScope Scope) ([]*Field, Scope,
 	}
 
 	returnTypes := make([]*Field, 0)
-	if token.Type() == lexer.Identifier {
-		p.getNextToken()
-		typeToken, ok := token.(lexer.IdentifierToken)
-		if !ok {
-			return nil, currentScope, unexpectedTokenCastError(token)
+	if token.Type() == lexer.Identifier || token.Type() == lexer.LeftBracket || token.Type() == lexer.Func {
+		typeDecl, err := p.parseTypeReference(currentScope)
+		if err != nil {
+			return nil, currentScope, err
 		}
 
 		var f *Field
-		var err error
-		f, currentScope, err = p.getTypedField("", typeToken, makeNodeSource(typeToken), currentScope)
+		f, currentScope, err = p.getTypedField("", typeDecl, makeNodeSource(token), currentScope)
 		if err != nil {
 			return nil, currentScope, err
 		}
@@ -240,12 +524,11 @@ func (p *Parser) parseFunctionReturnTypes(currentScope Scope) ([]*Field, Scope,
 	}
 
 	for true {
-		token := p.getNextToken()
-		if token == nil {
-			return nil, currentScope, unexpectedEOF()
-		}
-
 		if len(returnTypes) > 0 {
+			token := p.getNextToken()
+			if token == nil {
+				return nil, currentScope, unexpectedEOF()
+			}
 			if token.Type() == lexer.RightParenthesis {
 				break
 			}
@@ -253,25 +536,20 @@ func (p *Parser) parseFunctionReturnTypes(currentScope Scope) ([]*Field, Scope,
 			if token.Type() != lexer.Comma {
 				return nil, currentScope, unexpectedTokenError(token, lexer.RightParenthesis, lexer.Comma)
 			}
-
-			token = p.getNextToken()
-			if token == nil {
-				return nil, currentScope, unexpectedEOF()
-			}
 		}
 
-		if token.Type() != lexer.Identifier {
-			return nil, currentScope, unexpectedTokenError(token, lexer.Identifier)
+		token := p.peekNextToken()
+		if token == nil {
+			return nil, currentScope, unexpectedEOF()
 		}
 
-		typeToken, ok := token.(lexer.IdentifierToken)
-		if !ok {
-			return nil, currentScope, unexpectedTokenCastError(token)
+		typeDecl, err := p.parseTypeReference(currentScope)
+		if err != nil {
+			return nil, currentScope, err
 		}
 
 		var f *Field
-		var err error
-		f, currentScope, err = p.getTypedField("", typeToken, makeNodeSource(typeToken), currentScope)
+		f, currentScope, err = p.getTypedField("", typeDecl, makeNodeSource(token), currentScope)
 		if err != nil {
 			return nil, currentScope, err
 		}
@@ -282,37 +560,8 @@ func (p *Parser) parseFunctionReturnTypes(currentScope Scope) ([]*Field, Scope,
 	return returnTypes, currentScope, nil
 }
 
-func (p *Parser) getTypedField(fieldName string, typeToken lexer.IdentifierToken, ns nodeSource, currentScope Scope) (*Field, Scope, error) {
-	// TODO what if its a complex type?
-	var f *Field
-	typeId := typeToken.Identifier()
-	typeDecl := currentScope.SearchTypeDeclaration(typeId)
-
-	varDecl := &VariableDeclaration{
-		nodeSource:      ns,
-		TypeDeclaration: typeDecl,
-	}
-
-	if typeDecl != nil {
-		f = &Field{
-			Name:                fieldName,
-			VariableDeclaration: varDecl,
-		}
-	} else {
-		typeDecl = &TypeDeclaration{
-			nodeSource: nodeSource{},
-			Type:       UnknownType{Name: typeId, Scope: currentScope, nodeSource: makeNodeSource(typeToken)},
-		}
-
-		varDecl.TypeDeclaration = typeDecl
-
-		f = &Field{
-			Name:                fieldName,
-			VariableDeclaration: varDecl,
-		}
-
-		p.unknownFieldTypes = append(p.unknownFieldTypes, f)
-	}
+func (p *Parser) getTypedField(fieldName string, typeDecl *TypeDeclaration, ns nodeSource, currentScope Scope) (*Field, Scope, error) {
+	f := p.newTypedField(fieldName, typeDecl, ns)
 
 	if fieldName != "" {
 		if d := currentScope.SearchDeclaration(fieldName); d != nil {
@@ -320,31 +569,196 @@ func (p *Parser) getTypedField(fieldName string, typeToken lexer.IdentifierToken
 		}
 
 		currentScope = currentScope.CloneShallow()
-		currentScope.DeclareVariable(fieldName, varDecl)
+		currentScope.DeclareVariable(fieldName, f.VariableDeclaration)
 	}
 
 	return f, currentScope, nil
 }
 
-func (p *Parser) parseStatements(currentScope Scope) ([]Statement, error) {
-	statements := make([]Statement, 0)
-	for true {
-		token := p.getNextToken()
-		if token == nil {
-			return nil, unexpectedEOF()
-		}
+// newTypedField creates a field with the given type, without declaring it in the scope.
+// When the type is not known yet, it is resolved later.
+func (p *Parser) newTypedField(fieldName string, typeDecl *TypeDeclaration, ns nodeSource) *Field {
+	f := &Field{
+		Name: fieldName,
+		VariableDeclaration: &VariableDeclaration{
+			nodeSource:      ns,
+			Name:            fieldName,
+			TypeDeclaration: typeDecl,
+		},
+	}
 
-		switch token.Type() {
-		// TODO implement For
-		case lexer.If:
-			stmt, err := p.parseIfStatement(token, currentScope)
-			if err != nil {
-				return nil, err
-			}
+	if !isResolvedType(typeDecl) {
+		p.unknownFieldTypes = append(p.unknownFieldTypes, f)
+	}
+
+	return f
+}
+
+// parseTypeReference parses the type where it is used, like "Int", "[3]Int", "[]Point" or "func(Int) Bool". When the
+// type, or the type it is made of, is not known yet, the returned declaration must be resolved later.
+func (p *Parser) parseTypeReference(currentScope Scope) (*TypeDeclaration, error) {
+	token := p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	switch token.Type() {
+	case lexer.Identifier:
+		typeToken, ok := token.(lexer.IdentifierToken)
+		if !ok {
+			return nil, unexpectedTokenCastError(token)
+		}
+
+		typeId := typeToken.Identifier()
+		if decl := currentScope.SearchTypeDeclaration(typeId); decl != nil {
+			return decl, nil
+		}
+
+		return &TypeDeclaration{
+			nodeSource: nodeSource{},
+			Type:       UnknownType{Name: typeId, Scope: currentScope, nodeSource: makeNodeSource(typeToken)},
+		}, nil
+	case lexer.LeftBracket:
+		token = p.getNextToken()
+		if token == nil {
+			return nil, unexpectedEOF()
+		}
+
+		length := int64(-1)
+		if token.Type() == lexer.Integer {
+			intToken, ok := token.(lexer.IntegerToken)
+			if !ok {
+				return nil, unexpectedTokenCastError(token)
+			}
+
+			// The length must fit in an Int, as that is the type of indices and of len().
+			if !intToken.Integer().IsInt64() || intToken.Integer().Int64() > math.MaxInt32 {
+				return nil, errors.Errorf("array length %s is too large on line %d column %d",
+					intToken.Integer().String(), intToken.UFLine(), intToken.UFColumn())
+			}
+			length = intToken.Integer().Int64()
+
+			token = p.getNextToken()
+			if token == nil {
+				return nil, unexpectedEOF()
+			}
+			if token.Type() != lexer.RightBracket {
+				return nil, unexpectedTokenError(token, lexer.RightBracket)
+			}
+		} else if token.Type() != lexer.RightBracket {
+			return nil, unexpectedTokenError(token, lexer.Integer, lexer.RightBracket)
+		}
+
+		elementType, err := p.parseTypeReference(currentScope)
+		if err != nil {
+			return nil, err
+		}
+
+		if length < 0 {
+			return p.fileScope.types.getSliceTypeDeclaration(elementType), nil
+		}
+
+		return p.fileScope.types.getArrayTypeDeclaration(elementType, length), nil
+	case lexer.Func:
+		return p.parseFunctionTypeReference(currentScope)
+	default:
+		return nil, unexpectedTokenError(token, lexer.Identifier, lexer.LeftBracket, lexer.Func)
+	}
+}
+
+// parseFunctionTypeReference parses a function type like "func(Int, Bool) Int" or "func() (Int, Int)", after the "func".
+func (p *Parser) parseFunctionTypeReference(currentScope Scope) (*TypeDeclaration, error) {
+	token := p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+	if token.Type() != lexer.LeftParenthesis {
+		return nil, unexpectedTokenError(token, lexer.LeftParenthesis)
+	}
+
+	parameterTypes, err := p.parseTypeReferenceList(currentScope)
+	if err != nil {
+		return nil, err
+	}
+
+	returnTypes := make([]*TypeDeclaration, 0)
+	if token = p.peekNextToken(); token != nil {
+		switch token.Type() {
+		case lexer.Identifier, lexer.LeftBracket, lexer.Func:
+			typeDecl, err := p.parseTypeReference(currentScope)
+			if err != nil {
+				return nil, err
+			}
+
+			returnTypes = append(returnTypes, typeDecl)
+		case lexer.LeftParenthesis:
+			p.getNextToken()
+			returnTypes, err = p.parseTypeReferenceList(currentScope)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p.fileScope.types.getFunctionTypeDeclaration(parameterTypes, returnTypes), nil
+}
+
+// parseTypeReferenceList parses types separated by commas up to and including the ')', after the '('.
+func (p *Parser) parseTypeReferenceList(currentScope Scope) ([]*TypeDeclaration, error) {
+	typeDecls := make([]*TypeDeclaration, 0)
+	for true {
+		token := p.peekNextToken()
+		if token == nil {
+			return nil, unexpectedEOF()
+		}
+		if token.Type() == lexer.RightParenthesis {
+			p.getNextToken()
+			break
+		}
+
+		if len(typeDecls) > 0 {
+			p.getNextToken()
+			if token.Type() != lexer.Comma {
+				return nil, unexpectedTokenError(token, lexer.RightParenthesis, lexer.Comma)
+			}
+		}
+
+		typeDecl, err := p.parseTypeReference(currentScope)
+		if err != nil {
+			return nil, err
+		}
+
+		typeDecls = append(typeDecls, typeDecl)
+	}
+
+	return typeDecls, nil
+}
+
+func (p *Parser) parseStatements(currentScope Scope) ([]Statement, error) {
+	statements := make([]Statement, 0)
+	for true {
+		token := p.getNextToken()
+		if token == nil {
+			return nil, unexpectedEOF()
+		}
+
+		switch token.Type() {
+		case lexer.If:
+			stmt, err := p.parseIfStatement(token, currentScope)
+			if err != nil {
+				return nil, err
+			}
+
+			statements = append(statements, stmt)
+		case lexer.For:
+			stmt, err := p.parseForStatement(token, "", currentScope)
+			if err != nil {
+				return nil, err
+			}
 
 			statements = append(statements, stmt)
 		case lexer.While:
-			stmt, err := p.parseWhileStatement(token, currentScope)
+			stmt, err := p.parseWhileStatement(token, "", currentScope)
 			if err != nil {
 				return nil, err
 			}
@@ -358,6 +772,15 @@ func (p *Parser) parseStatements(currentScope Scope) ([]Statement, error) {
 				return nil, err
 			}
 
+			statements = append(statements, stmt)
+		case lexer.Type:
+			var stmt Statement
+			var err error
+			stmt, currentScope, err = p.parseTypeDeclarationStatement(token, currentScope)
+			if err != nil {
+				return nil, err
+			}
+
 			statements = append(statements, stmt)
 		case lexer.Identifier:
 			idToken, ok := token.(lexer.IdentifierToken)
@@ -365,7 +788,14 @@ func (p *Parser) parseStatements(currentScope Scope) ([]Statement, error) {
 				return nil, unexpectedTokenCastError(token)
 			}
 
-			stmt, err := p.parseIdentifierStatement(idToken, currentScope)
+			var stmt Statement
+			var err error
+			if pToken := p.peekNextToken(); pToken != nil && pToken.Type() == lexer.Colon {
+				p.getNextToken()
+				stmt, err = p.parseLabelledStatement(idToken, currentScope)
+			} else {
+				stmt, err = p.parseIdentifierStatement(idToken, currentScope)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -377,20 +807,19 @@ func (p *Parser) parseStatements(currentScope Scope) ([]Statement, error) {
 				return nil, err
 			}
 
-			token = p.getNextToken()
-			if token == nil {
-				return nil, unexpectedEOF()
-			}
-			if token.Type() != lexer.RightBrace {
-				return nil, unexpectedTokenError(token, lexer.RightBrace)
+			statements = append(statements, stmt)
+		case lexer.Break, lexer.Continue:
+			stmt, err := p.parseBranchStatement(token)
+			if err != nil {
+				return nil, err
 			}
 
-			// Return must be the last statement in the block.
-			return append(statements, stmt), nil
+			statements = append(statements, stmt)
 		case lexer.RightBrace:
 			return statements, nil
 		default:
-			return nil, unexpectedTokenError(token, lexer.Identifier, lexer.If, lexer.For, lexer.While, lexer.Var, lexer.Return, lexer.RightBrace)
+			return nil, unexpectedTokenError(token, lexer.Identifier, lexer.If, lexer.For, lexer.While, lexer.Var, lexer.Type, lexer.Return,
+				lexer.Break, lexer.Continue, lexer.RightBrace)
 		}
 	}
 
@@ -398,7 +827,7 @@ func (p *Parser) parseStatements(currentScope Scope) ([]Statement, error) {
 }
 
 func (p *Parser) parseIfStatement(startToken lexer.Token, currentScope Scope) (Statement, error) {
-	conditionExp, err := p.parseExpression(0, currentScope)
+	conditionExp, err := p.parseConditionExpression(currentScope)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not parse if statement condition")
 	}
@@ -428,14 +857,24 @@ func (p *Parser) parseIfStatement(startToken lexer.Token, currentScope Scope) (S
 		if lbToken == nil {
 			return nil, unexpectedEOF()
 		}
-		if lbToken.Type() != lexer.LeftBrace {
-			return nil, unexpectedTokenError(lbToken, lexer.LeftBrace) // FIXME: Currently does not support "else if"
-		}
 
-		elseStmtsScope := NewBasicScope(currentScope, BlockScopeType)
-		elseStmts, err = p.parseStatements(elseStmtsScope)
-		if err != nil {
-			return nil, err
+		switch lbToken.Type() {
+		case lexer.If:
+			// An "else if" chain is represented by an if statement that is the only else statement.
+			elseIfStmt, err := p.parseIfStatement(lbToken, currentScope)
+			if err != nil {
+				return nil, err
+			}
+
+			elseStmts = append(elseStmts, elseIfStmt)
+		case lexer.LeftBrace:
+			elseStmtsScope := NewBasicScope(currentScope, BlockScopeType)
+			elseStmts, err = p.parseStatements(elseStmtsScope)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, unexpectedTokenError(lbToken, lexer.LeftBrace, lexer.If)
 		}
 	}
 
@@ -447,8 +886,109 @@ func (p *Parser) parseIfStatement(startToken lexer.Token, currentScope Scope) (S
 	}, nil
 }
 
-func (p *Parser) parseWhileStatement(startToken lexer.Token, currentScope Scope) (Statement, error) {
-	conditionExp, err := p.parseExpression(0, currentScope)
+// parseForStatement parses "for init; condition; action { ... }" where each of the three clauses may be left empty.
+// The init statement gets its own block scope, so a variable declared in it is only visible inside the loop.
+func (p *Parser) parseForStatement(startToken lexer.Token, label string, currentScope Scope) (Statement, error) {
+	forScope := Scope(NewBasicScope(currentScope, BlockScopeType))
+
+	token := p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	var initStmt Statement
+	switch token.Type() {
+	case lexer.Semicolon:
+	case lexer.Var:
+		var err error
+		initStmt, forScope, err = p.parseVariableDeclarationStatement(token, forScope)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse for statement init")
+		}
+	case lexer.Identifier:
+		idToken, ok := token.(lexer.IdentifierToken)
+		if !ok {
+			return nil, unexpectedTokenCastError(token)
+		}
+
+		var err error
+		initStmt, err = p.parseIdentifierStatement(idToken, forScope)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse for statement init")
+		}
+	default:
+		return nil, unexpectedTokenError(token, lexer.Var, lexer.Identifier, lexer.Semicolon)
+	}
+
+	token = p.peekNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	var conditionExp Expression
+	if token.Type() != lexer.Semicolon {
+		var err error
+		conditionExp, err = p.parseConditionExpression(forScope)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse for statement condition")
+		}
+	}
+
+	token = p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+	if token.Type() != lexer.Semicolon {
+		return nil, unexpectedTokenError(token, lexer.Semicolon)
+	}
+
+	token = p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	var actionStmt Statement
+	if token.Type() == lexer.Identifier {
+		idToken, ok := token.(lexer.IdentifierToken)
+		if !ok {
+			return nil, unexpectedTokenCastError(token)
+		}
+
+		var err error
+		actionStmt, err = p.parseSimpleIdentifierStatement(idToken, forScope)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse for statement action")
+		}
+
+		token = p.getNextToken()
+		if token == nil {
+			return nil, unexpectedEOF()
+		}
+	}
+	if token.Type() != lexer.LeftBrace {
+		return nil, unexpectedTokenError(token, lexer.Identifier, lexer.LeftBrace)
+	}
+
+	stmt := &ForStatement{
+		nodeSource: makeNodeSource(startToken),
+		Label:      label,
+		Init:       initStmt,
+		Condition:  conditionExp,
+		LoopAction: actionStmt,
+	}
+
+	stmtsScope := NewBasicScope(forScope, BlockScopeType)
+	stmts, err := p.parseLoopStatements(stmt, label, stmtsScope)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt.Statements = stmts
+	return stmt, nil
+}
+
+func (p *Parser) parseWhileStatement(startToken lexer.Token, label string, currentScope Scope) (Statement, error) {
+	conditionExp, err := p.parseConditionExpression(currentScope)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not parse while statement condition")
 	}
@@ -461,16 +1001,101 @@ func (p *Parser) parseWhileStatement(startToken lexer.Token, currentScope Scope)
 		return nil, unexpectedTokenError(lbToken, lexer.LeftBrace)
 	}
 
+	stmt := &WhileStatement{
+		nodeSource: makeNodeSource(startToken),
+		Label:      label,
+		Condition:  conditionExp,
+	}
+
 	stmtsScope := NewBasicScope(currentScope, BlockScopeType)
-	stmts, err := p.parseStatements(stmtsScope)
+	stmts, err := p.parseLoopStatements(stmt, label, stmtsScope)
 	if err != nil {
 		return nil, err
 	}
 
-	return &WhileStatement{
+	stmt.Statements = stmts
+	return stmt, nil
+}
+
+// parseLabelledStatement parses the loop after a label, like the while statement in "outer: while ...".
+func (p *Parser) parseLabelledStatement(labelToken lexer.IdentifierToken, currentScope Scope) (Statement, error) {
+	label := labelToken.Identifier()
+	for _, l := range p.enclosingLoops {
+		if l.label == label {
+			return nil, errors.Errorf("label '%s' at line %d column %d is already used by a surrounding loop at line %d column %d",
+				label, labelToken.UFLine(), labelToken.UFColumn(), l.loop.UFSourceLine(), l.loop.UFSourceColumn())
+		}
+	}
+
+	token := p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	switch token.Type() {
+	case lexer.For:
+		return p.parseForStatement(token, label, currentScope)
+	case lexer.While:
+		return p.parseWhileStatement(token, label, currentScope)
+	default:
+		return nil, unexpectedTokenError(token, lexer.For, lexer.While)
+	}
+}
+
+// parseLoopStatements parses the statements of a loop, which break and continue statements in them can refer to.
+func (p *Parser) parseLoopStatements(loop Statement, label string, currentScope Scope) ([]Statement, error) {
+	p.enclosingLoops = append(p.enclosingLoops, enclosingLoop{label: label, loop: loop})
+	stmts, err := p.parseStatements(currentScope)
+	p.enclosingLoops = p.enclosingLoops[:len(p.enclosingLoops)-1]
+
+	return stmts, err
+}
+
+// parseBranchStatement parses a break or continue statement, with the label of the loop it refers to when given.
+func (p *Parser) parseBranchStatement(startToken lexer.Token) (Statement, error) {
+	token := p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	var label string
+	if token.Type() == lexer.Identifier {
+		idToken, ok := token.(lexer.IdentifierToken)
+		if !ok {
+			return nil, unexpectedTokenCastError(token)
+		}
+		label = idToken.Identifier()
+
+		token = p.getNextToken()
+		if token == nil {
+			return nil, unexpectedEOF()
+		}
+	}
+	if token.Type() != lexer.Semicolon {
+		return nil, unexpectedTokenError(token, lexer.Identifier, lexer.Semicolon)
+	}
+
+	// When no loop is found, it is left nil for the semantic analyzer to report.
+	var loop Statement
+	for i := len(p.enclosingLoops) - 1; i >= 0; i-- {
+		if label == "" || p.enclosingLoops[i].label == label {
+			loop = p.enclosingLoops[i].loop
+			break
+		}
+	}
+
+	if startToken.Type() == lexer.Break {
+		return &BreakStatement{
+			nodeSource: makeNodeSource(startToken),
+			Label:      label,
+			Loop:       loop,
+		}, nil
+	}
+
+	return &ContinueStatement{
 		nodeSource: makeNodeSource(startToken),
-		Condition:  conditionExp,
-		Statements: stmts,
+		Label:      label,
+		Loop:       loop,
 	}, nil
 }
 
@@ -494,107 +1119,189 @@ func (p *Parser) parseVariableDeclarationStatement(startToken lexer.Token, curre
 		return nil, currentScope, alreadyDeclaredError(d, ns)
 	}
 
-	token = p.getNextToken()
-	if token == nil {
-		return nil, currentScope, unexpectedEOF()
-	}
-	if token.Type() != lexer.Identifier {
-		return nil, currentScope, unexpectedTokenError(token, lexer.Identifier)
+	// The variable is not visible in its own value.
+	typeDecl, value, err := p.parseVariableTypeAndValue(false, currentScope)
+	if err != nil {
+		return nil, currentScope, err
 	}
 
-	typeToken, ok := token.(lexer.IdentifierToken)
-	if !ok {
-		return nil, currentScope, unexpectedTokenError(token)
+	varDecl := &VariableDeclaration{
+		nodeSource:      ns,
+		Name:            id,
+		TypeDeclaration: typeDecl,
+		Value:           value,
 	}
-
-	typeId := typeToken.Identifier()
-	var varDecl *VariableDeclaration
-	decl := currentScope.SearchTypeDeclaration(typeId)
-	if decl != nil {
-		varDecl = &VariableDeclaration{
-			nodeSource:      ns,
-			TypeDeclaration: decl,
-		}
-	} else {
-		varDecl = &VariableDeclaration{
-			nodeSource: ns,
-			TypeDeclaration: &TypeDeclaration{
-				nodeSource: nodeSource{},
-				Type:       UnknownType{Name: typeId, Scope: currentScope, nodeSource: makeNodeSource(typeToken)},
-			},
-		}
-
+	if typeDecl != nil && !isResolvedType(typeDecl) {
 		p.unknownIdentifierStatements = append(p.unknownIdentifierStatements, varDecl)
 	}
 
 	currentScope = currentScope.CloneShallow()
 	currentScope.DeclareVariable(id, varDecl)
+	return varDecl, currentScope, nil
+}
 
-	token = p.getNextToken()
+func (p *Parser) parseIdentifierStatement(idToken lexer.IdentifierToken, currentScope Scope) (Statement, error) {
+	stmt, err := p.parseSimpleIdentifierStatement(idToken, currentScope)
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.getNextToken()
 	if token == nil {
-		return nil, currentScope, unexpectedEOF()
+		return nil, unexpectedEOF()
 	}
 	if token.Type() != lexer.Semicolon {
-		return nil, currentScope, unexpectedTokenError(token, lexer.Semicolon)
+		return nil, unexpectedTokenError(token, lexer.Semicolon)
 	}
 
-	return varDecl, currentScope, nil
+	return stmt, nil
 }
 
-func (p *Parser) parseIdentifierStatement(idToken lexer.IdentifierToken, currentScope Scope) (Statement, error) {
+// parseSimpleIdentifierStatement parses a statement starting with an identifier, like an assignment or function call,
+// without the semicolon that ends it.
+func (p *Parser) parseSimpleIdentifierStatement(idToken lexer.IdentifierToken, currentScope Scope) (Statement, error) {
 	token := p.getNextToken()
 	if token == nil {
 		return nil, unexpectedEOF()
 	}
 
 	id := idToken.Identifier()
-	var varDecl Declaration
-	var addUnknownIdentifierStmt bool
-	if d := currentScope.SearchVariableDeclaration(id); d != nil {
-		varDecl = d
-	} else {
-		addUnknownIdentifierStmt = true
-		varDecl = &UnknownDeclaration{
-			nodeSource: makeNodeSource(idToken),
-			Identifier: id,
-			Scope:      currentScope,
-		}
+	if id == blankIdentifier && token.Type() != lexer.Assign && token.Type() != lexer.Comma {
+		return nil, blankIdentifierError(idToken)
 	}
 
-	var stmt Statement
-	switch token.Type() {
-	case lexer.Assign:
+	varDecl, known := searchAssignedVariable(idToken, currentScope)
+	addUnknownIdentifierStmt := !known
+
+	var stmt Statement
+	switch token.Type() {
+	case lexer.Assign:
+		exp, err := p.parseExpression(0, currentScope)
+		if err != nil {
+			return nil, err
+		}
+
+		if id == blankIdentifier {
+			stmt = &DiscardStatement{
+				nodeSource: makeNodeSource(idToken),
+				Expression: exp,
+			}
+			break
+		}
+
+		stmt = &AssignStatement{
+			nodeSource:          makeNodeSource(idToken),
+			VariableDeclaration: varDecl,
+			Expression:          exp,
+		}
+	case lexer.Comma:
+		varDecls := []Declaration{varDecl}
+		for token.Type() == lexer.Comma {
+			token = p.getNextToken()
+			if token == nil {
+				return nil, unexpectedEOF()
+			}
+			if token.Type() != lexer.Identifier {
+				return nil, unexpectedTokenError(token, lexer.Identifier)
+			}
+
+			nextIdToken, ok := token.(lexer.IdentifierToken)
+			if !ok {
+				return nil, unexpectedTokenCastError(token)
+			}
+
+			nextVarDecl, known := searchAssignedVariable(nextIdToken, currentScope)
+			if !known {
+				addUnknownIdentifierStmt = true
+			}
+			varDecls = append(varDecls, nextVarDecl)
+
+			token = p.getNextToken()
+			if token == nil {
+				return nil, unexpectedEOF()
+			}
+		}
+		if token.Type() != lexer.Assign {
+			return nil, unexpectedTokenError(token, lexer.Comma, lexer.Assign)
+		}
+
 		exp, err := p.parseExpression(0, currentScope)
 		if err != nil {
 			return nil, err
 		}
 
-		stmt = &AssignStatement{
-			nodeSource:          makeNodeSource(idToken),
-			VariableDeclaration: varDecl,
-			Expression:          exp,
-		}
-	case lexer.AddAssign:
-		exp, err := p.parseExpression(0, currentScope)
-		if err != nil {
-			return nil, err
+		stmt = &MultiAssignStatement{
+			nodeSource:           makeNodeSource(idToken),
+			VariableDeclarations: varDecls,
+			Expression:           exp,
 		}
+	case lexer.Period, lexer.LeftBracket:
+		var target Expression = newIdentifierExpression(makeNodeSource(idToken), varDecl, p.fileScope.types)
+		for token.Type() == lexer.Period || token.Type() == lexer.LeftBracket {
+			if token.Type() == lexer.Period {
+				token = p.getNextToken()
+				if token == nil {
+					return nil, unexpectedEOF()
+				}
+				if token.Type() != lexer.Identifier {
+					return nil, unexpectedTokenError(token, lexer.Identifier)
+				}
 
-		stmt = &AddAssignStatement{
-			nodeSource:          makeNodeSource(idToken),
-			VariableDeclaration: varDecl,
-			Expression:          exp,
+				fieldToken, ok := token.(lexer.IdentifierToken)
+				if !ok {
+					return nil, unexpectedTokenCastError(token)
+				}
+
+				target = newFieldAccessExpression(makeNodeSource(fieldToken), target, fieldToken.Identifier())
+			} else {
+				index, err := p.parseEnclosedExpression(currentScope)
+				if err != nil {
+					return nil, err
+				}
+
+				target = newIndexExpression(makeNodeSource(token), target, index)
+
+				token = p.getNextToken()
+				if token == nil {
+					return nil, unexpectedEOF()
+				}
+				if token.Type() != lexer.RightBracket {
+					return nil, unexpectedTokenError(token, lexer.RightBracket)
+				}
+			}
+
+			token = p.getNextToken()
+			if token == nil {
+				return nil, unexpectedEOF()
+			}
 		}
-	case lexer.SubtractAssign:
+		if _, ok := lexer.CompoundAssignOperator(token.Type()); ok {
+			// Computing the target once for both reading and writing it is not supported yet.
+			return nil, errors.Errorf("cannot use '%s' on a field or element at line %d column %d: only a variable can be the target",
+				lexer.GetTokenTypeString(token.Type()), token.UFLine(), token.UFColumn())
+		}
+		if token.Type() != lexer.Assign {
+			return nil, unexpectedTokenError(token, lexer.Period, lexer.LeftBracket, lexer.Assign)
+		}
+
 		exp, err := p.parseExpression(0, currentScope)
 		if err != nil {
 			return nil, err
 		}
 
-		stmt = &SubtractAssignStatement{
-			nodeSource:          makeNodeSource(idToken),
-			VariableDeclaration: varDecl,
-			Expression:          exp,
+		switch t := target.(type) {
+		case *FieldAccessExpression:
+			stmt = &FieldAssignStatement{
+				nodeSource: makeNodeSource(idToken),
+				Target:     t,
+				Expression: exp,
+			}
+		case *IndexExpression:
+			stmt = &IndexAssignStatement{
+				nodeSource: makeNodeSource(idToken),
+				Target:     t,
+				Expression: exp,
+			}
 		}
 	case lexer.Increment:
 		// TODO check that varDecl has type Int
@@ -619,7 +1326,7 @@ func (p *Parser) parseIdentifierStatement(idToken lexer.IdentifierToken, current
 			}
 		}
 
-		exp := newIdentifierExpression(makeNodeSource(idToken), varDecl)
+		exp := newIdentifierExpression(makeNodeSource(idToken), varDecl, p.fileScope.types)
 
 		if addUnknownIdentifierExp {
 			p.unknownVarFuncIdentifiers = append(p.unknownVarFuncIdentifiers, exp)
@@ -631,22 +1338,49 @@ func (p *Parser) parseIdentifierStatement(idToken lexer.IdentifierToken, current
 			return nil, err
 		}
 	default:
-		return nil, unexpectedTokenError(token, lexer.Assign, lexer.AddAssign, lexer.SubtractAssign, lexer.Increment, lexer.Decrement, lexer.LeftParenthesis)
+		operator, ok := lexer.CompoundAssignOperator(token.Type())
+		if !ok {
+			expected := append([]lexer.TokenType{lexer.Assign}, lexer.CompoundAssignTokenTypes()...)
+			expected = append(expected, lexer.Increment, lexer.Decrement, lexer.LeftParenthesis, lexer.Period, lexer.LeftBracket,
+				lexer.Comma)
+			return nil, unexpectedTokenError(token, expected...)
+		}
+
+		exp, err := p.parseExpression(0, currentScope)
+		if err != nil {
+			return nil, err
+		}
+
+		target := newIdentifierExpression(makeNodeSource(idToken), varDecl, p.fileScope.types)
+		stmt, ok = newCompoundAssignStatement(makeNodeSource(idToken), makeNodeSource(token), operator, target, exp, currentScope)
+		if !ok {
+			return nil, errors.Errorf("compiler error: no operator for compound assignment '%s'", lexer.GetTokenTypeString(token.Type()))
+		}
 	}
 
 	if addUnknownIdentifierStmt {
 		p.unknownIdentifierStatements = append(p.unknownIdentifierStatements, stmt)
 	}
 
-	token = p.getNextToken()
-	if token == nil {
-		return nil, unexpectedEOF()
+	return stmt, nil
+}
+
+// searchAssignedVariable returns the variable that the identifier refers to. When the variable is not declared yet, an
+// UnknownDeclaration is returned that must be resolved after parsing, together with false. The blank identifier refers
+// to no variable, so nil is returned for it.
+func searchAssignedVariable(idToken lexer.IdentifierToken, currentScope Scope) (Declaration, bool) {
+	if idToken.Identifier() == blankIdentifier {
+		return nil, true
 	}
-	if token.Type() != lexer.Semicolon {
-		return nil, unexpectedTokenError(token, lexer.Semicolon)
+	if d := currentScope.SearchVariableDeclaration(idToken.Identifier()); d != nil {
+		return d, true
 	}
 
-	return stmt, nil
+	return &UnknownDeclaration{
+		nodeSource: makeNodeSource(idToken),
+		Identifier: idToken.Identifier(),
+		Scope:      currentScope,
+	}, false
 }
 
 func (p *Parser) parseReturnStatement(startToken lexer.Token, currentScope Scope) (Statement, error) {
@@ -689,10 +1423,14 @@ func (p *Parser) parseExpression(prevOperatorPrecedence int, currentScope Scope)
 		return nil, unexpectedEOF()
 	}
 
-	var notExp *NotExpression
 	ns := makeNodeSource(token)
-	if token.Type() == lexer.Not {
-		notExp = newNotExpression(ns, nil, currentScope)
+	if oToken, ok := token.(lexer.OperatorToken); ok && oToken.PrefixOperatorPrecedence() != 0 {
+		exp, err := p.parsePrefixExpression(oToken, currentScope)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.parseOperatorExpression(exp, prevOperatorPrecedence, currentScope)
 	}
 
 	var exp Expression
@@ -704,13 +1442,20 @@ func (p *Parser) parseExpression(prevOperatorPrecedence int, currentScope Scope)
 		}
 
 		exp = newIntegerLiteralExpression(ns, intToken.Integer(), currentScope)
+	case lexer.Float:
+		floatToken, ok := token.(lexer.FloatToken)
+		if !ok {
+			return nil, unexpectedTokenCastError(token)
+		}
+
+		exp = newFloatLiteralExpression(ns, floatToken.Float(), currentScope)
 	case lexer.Character:
 		charToken, ok := token.(lexer.CharacterToken)
 		if !ok {
 			return nil, unexpectedTokenCastError(token)
 		}
 
-		exp = newCharacterLiteralExpression(ns, charToken.Character(), currentScope)
+		exp = newCharacterLiteralExpression(ns, charToken.Character(), charToken.IsByte(), currentScope)
 	case lexer.String:
 		stringToken, ok := token.(lexer.StringToken)
 		if !ok {
@@ -732,12 +1477,45 @@ func (p *Parser) parseExpression(prevOperatorPrecedence int, currentScope Scope)
 		var decl Declaration
 		if d := currentScope.SearchVariableDeclaration(id); d == nil {
 			if d2 := currentScope.SearchFunctionDeclaration(id); d2 == nil {
+				if pToken := p.peekNextToken(); pToken != nil && pToken.Type() == lexer.LeftParenthesis && (id == "len" || id == "append") {
+					p.getNextToken()
+					var err error
+					exp, err = p.parseBuiltInCallExpression(idToken, currentScope)
+					if err != nil {
+						return nil, err
+					}
+					break
+				}
+
+				if pToken := p.peekNextToken(); pToken != nil && pToken.Type() == lexer.LeftBrace && !p.structLiteralsDisabled {
+					p.getNextToken()
+					var err error
+					exp, err = p.parseStructLiteralExpression(idToken, currentScope)
+					if err != nil {
+						return nil, err
+					}
+					break
+				}
+
+				if td := currentScope.SearchTypeDeclaration(id); td != nil {
+					if pToken := p.peekNextToken(); pToken != nil && pToken.Type() == lexer.LeftParenthesis {
+						p.getNextToken()
+						convExp, err := p.parseParenthesizedExpression(currentScope)
+						if err != nil {
+							return nil, err
+						}
+
+						exp = newConversionExpression(ns, td, convExp)
+						break
+					}
+				}
+
 				decl = &UnknownDeclaration{
 					nodeSource: makeNodeSource(idToken),
 					Identifier: id,
 					Scope:      currentScope,
 				}
-				idExp := newIdentifierExpression(ns, decl)
+				idExp := newIdentifierExpression(ns, decl, p.fileScope.types)
 
 				p.unknownVarFuncIdentifiers = append(p.unknownVarFuncIdentifiers, idExp)
 				exp = idExp
@@ -749,33 +1527,82 @@ func (p *Parser) parseExpression(prevOperatorPrecedence int, currentScope Scope)
 			decl = d
 		}
 
-		exp = newIdentifierExpression(ns, decl)
+		exp = newIdentifierExpression(ns, decl, p.fileScope.types)
 	case lexer.LeftParenthesis:
 		var err error
 		exp, err = p.parseParenthesizedExpression(currentScope)
 		if err != nil {
 			return nil, err
 		}
+	case lexer.Func:
+		var err error
+		exp, err = p.parseFunctionLiteralExpression(token, currentScope)
+		if err != nil {
+			return nil, err
+		}
 	default:
-		return nil, unexpectedTokenError(token, lexer.Integer, lexer.Character, lexer.String, lexer.True, lexer.False, lexer.Identifier, lexer.LeftParenthesis)
+		return nil, unexpectedTokenError(token, lexer.Integer, lexer.Float, lexer.Character, lexer.String, lexer.True,
+			lexer.False, lexer.Identifier, lexer.LeftParenthesis, lexer.Func, lexer.Subtract, lexer.Not, lexer.BitwiseNot)
+	}
+
+	return p.parseOperatorExpression(exp, prevOperatorPrecedence, currentScope)
+}
+
+// parseFunctionLiteralExpression parses a function without a name like "func(x Int) Int { return x + y; }", after the
+// "func". The variables of the functions around it that it uses are captured while its statements are parsed.
+func (p *Parser) parseFunctionLiteralExpression(startToken lexer.Token, currentScope Scope) (Expression, error) {
+	exp := &FunctionLiteralExpression{
+		baseExpression: newBaseExpression(makeNodeSource(startToken)),
+		types:          p.fileScope.types,
+	}
+	declareFunctionLiteral(currentScope, exp)
+
+	// The function literal has statements of its own, which cannot leave the loops around it, and struct literals can
+	// be used in them even when the function literal is in a condition.
+	enclosingLoops, structLiteralsDisabled := p.enclosingLoops, p.structLiteralsDisabled
+	p.enclosingLoops, p.structLiteralsDisabled = nil, false
+	def, err := p.parseFunctionDefinition(NewFunctionLiteralScope(currentScope, exp))
+	p.enclosingLoops, p.structLiteralsDisabled = enclosingLoops, structLiteralsDisabled
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse function literal at line %d column %d", startToken.UFLine(), startToken.UFColumn())
 	}
 
-	if notExp != nil {
-		notExp.Expression = exp
-		exp = notExp
+	exp.FunctionDefinition = def
+	return exp, nil
+}
+
+// parsePrefixExpression parses the operand of a prefix operator. The operand only includes the operators that have a
+// higher precedence than the prefix operator, so -a.b[0] negates a.b[0] while -a * b multiplies -a.
+func (p *Parser) parsePrefixExpression(oToken lexer.OperatorToken, currentScope Scope) (Expression, error) {
+	exp, err := p.parseExpression(oToken.PrefixOperatorPrecedence(), currentScope)
+	if err != nil {
+		return nil, err
 	}
 
-	isCallableExp := func(e Expression) bool {
-		if _, ok := e.(*IdentifierExpression); ok {
-			return true
+	source := makeNodeSource(oToken)
+	switch oToken.Type() {
+	case lexer.Subtract:
+		// A negative number is a literal by itself, so the smallest value of a type can be written down.
+		switch e := exp.(type) {
+		case *IntegerLiteralExpression:
+			return newIntegerLiteralExpression(source, new(big.Int).Neg(e.Value), currentScope), nil
+		case *FloatLiteralExpression:
+			return newFloatLiteralExpression(source, -e.Value, currentScope), nil
 		}
-		// TODO implement function-as-first-citizen calling.
-		//if _, ok := e.(*FunctionCallExpression); ok {
-		//	return true
-		//}
-		return false
+
+		return newNegateExpression(source, exp), nil
+	case lexer.Not:
+		return newNotExpression(source, exp, currentScope), nil
+	case lexer.BitwiseNot:
+		return newBitwiseNotExpression(source, exp), nil
+	default:
+		return nil, unexpectedTokenError(oToken, lexer.Subtract, lexer.Not, lexer.BitwiseNot)
 	}
+}
 
+// parseOperatorExpression parses the operators following an expression, like binary operators, field accesses, indices
+// and calls. Binary operators are only included when they have a higher precedence than the previous operator.
+func (p *Parser) parseOperatorExpression(exp Expression, prevOperatorPrecedence int, currentScope Scope) (Expression, error) {
 	for true {
 		pToken := p.peekNextToken()
 		if pToken == nil {
@@ -795,61 +1622,243 @@ func (p *Parser) parseExpression(prevOperatorPrecedence int, currentScope Scope)
 				return nil, err
 			}
 
-			source := makeNodeSource(oToken)
-			switch oToken.Type() {
-			case lexer.Multiply:
-				exp = newMultiplyExpression(source, exp, exp2)
-			case lexer.Divide:
-				exp = newDivideExpression(source, exp, exp2)
-			case lexer.Add:
-				exp = newAddExpression(source, exp, exp2)
-			case lexer.Subtract:
-				exp = newSubtractExpression(source, exp, exp2)
-			case lexer.Equal:
-				exp = newEqualExpression(source, exp, exp2, currentScope)
-			case lexer.NotEqual:
-				exp = newNotEqualExpression(source, exp, exp2, currentScope)
-			case lexer.Less:
-				exp = newLessExpression(source, exp, exp2, currentScope)
-			case lexer.LessOrEqual:
-				exp = newLessOrEqualExpression(source, exp, exp2, currentScope)
-			case lexer.Greater:
-				exp = newGreaterExpression(source, exp, exp2, currentScope)
-			case lexer.GreaterOrEqual:
-				exp = newGreaterOrEqualExpression(source, exp, exp2, currentScope)
-			case lexer.And:
-				exp = newAndExpression(source, exp, exp2, currentScope)
-			case lexer.Or:
-				exp = newOrExpression(source, exp, exp2, currentScope)
-			default:
-				return nil, unexpectedTokenError(oToken, lexer.Multiply, lexer.Divide, lexer.Add, lexer.Subtract,
+			binExp, ok := newBinaryExpression(oToken.Type(), makeNodeSource(oToken), exp, exp2, currentScope)
+			if !ok {
+				return nil, unexpectedTokenError(oToken, lexer.Multiply, lexer.Divide, lexer.Modulo, lexer.Add,
+					lexer.Subtract, lexer.BitwiseAnd, lexer.BitwiseOr, lexer.BitwiseXor, lexer.ShiftLeft, lexer.ShiftRight,
 					lexer.Equal, lexer.NotEqual, lexer.Less, lexer.LessOrEqual, lexer.Greater, lexer.GreaterOrEqual,
 					lexer.And, lexer.Or)
 			}
+			exp = binExp
+		} else if pToken.Type() == lexer.Period {
+			p.getNextToken()
+			token := p.getNextToken()
+			if token == nil {
+				return nil, unexpectedEOF()
+			}
+			if token.Type() != lexer.Identifier {
+				return nil, unexpectedTokenError(token, lexer.Identifier)
+			}
+
+			fieldToken, ok := token.(lexer.IdentifierToken)
+			if !ok {
+				return nil, unexpectedTokenCastError(token)
+			}
+
+			exp = newFieldAccessExpression(makeNodeSource(fieldToken), exp, fieldToken.Identifier())
+		} else if pToken.Type() == lexer.LeftBracket {
+			p.getNextToken()
+			var err error
+			exp, err = p.parseIndexOrSliceExpression(pToken, exp, currentScope)
+			if err != nil {
+				return nil, err
+			}
+		} else if pToken.Type() == lexer.LeftParenthesis {
+			// Any expression can result in a function, whether it does is checked with the types.
+			p.getNextToken()
+			var err error
+			exp, err = p.parseFunctionCallExpression(pToken, exp, currentScope)
+			if err != nil {
+				return nil, err
+			}
 		} else {
-			if pToken.Type() == lexer.LeftParenthesis && isCallableExp(exp) {
-				p.getNextToken()
-				var err error
-				exp, err = p.parseFunctionCallExpression(pToken, exp, currentScope)
-				if err != nil {
-					return nil, err
-				}
-			} else {
+			break
+		}
+	}
+
+	return exp, nil
+}
+
+// parseConditionExpression parses the condition of an if, for or while statement.
+func (p *Parser) parseConditionExpression(currentScope Scope) (Expression, error) {
+	prev := p.structLiteralsDisabled
+	p.structLiteralsDisabled = true
+	exp, err := p.parseExpression(0, currentScope)
+	p.structLiteralsDisabled = prev
+	return exp, err
+}
+
+// parseEnclosedExpression parses an expression that is enclosed by delimiters, like parentheses, so struct literals
+// can be used even inside a condition.
+func (p *Parser) parseEnclosedExpression(currentScope Scope) (Expression, error) {
+	prev := p.structLiteralsDisabled
+	p.structLiteralsDisabled = false
+	exp, err := p.parseExpression(0, currentScope)
+	p.structLiteralsDisabled = prev
+	return exp, err
+}
+
+// parseStructLiteralExpression parses the fields of a struct literal like "Point{x: 1, y: 2}", after the '{'.
+func (p *Parser) parseStructLiteralExpression(typeToken lexer.IdentifierToken, currentScope Scope) (*StructLiteralExpression, error) {
+	fields := make([]*StructLiteralField, 0)
+	for true {
+		token := p.getNextToken()
+		if token == nil {
+			return nil, unexpectedEOF()
+		}
+		if token.Type() == lexer.RightBrace {
+			break
+		}
+
+		if len(fields) > 0 {
+			if token.Type() != lexer.Comma {
+				return nil, unexpectedTokenError(token, lexer.RightBrace, lexer.Comma)
+			}
+
+			// Allow a trailing comma.
+			token = p.getNextToken()
+			if token == nil {
+				return nil, unexpectedEOF()
+			}
+			if token.Type() == lexer.RightBrace {
 				break
 			}
 		}
+
+		if token.Type() != lexer.Identifier {
+			return nil, unexpectedTokenError(token, lexer.Identifier, lexer.RightBrace)
+		}
+
+		nameToken, ok := token.(lexer.IdentifierToken)
+		if !ok {
+			return nil, unexpectedTokenCastError(token)
+		}
+
+		fieldName := nameToken.Identifier()
+		ns := makeNodeSource(nameToken)
+		for _, f := range fields {
+			if f.Name == fieldName {
+				return nil, errors.Errorf("field '%s' on line %d column %d is already given on line %d column %d",
+					fieldName, ns.UFSourceLine(), ns.UFSourceColumn(), f.UFSourceLine(), f.UFSourceColumn())
+			}
+		}
+
+		token = p.getNextToken()
+		if token == nil {
+			return nil, unexpectedEOF()
+		}
+		if token.Type() != lexer.Colon {
+			return nil, unexpectedTokenError(token, lexer.Colon)
+		}
+
+		exp, err := p.parseEnclosedExpression(currentScope)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, &StructLiteralField{
+			nodeSource: ns,
+			Name:       fieldName,
+			Expression: exp,
+		})
+	}
+
+	typeId := typeToken.Identifier()
+	typeDecl := currentScope.SearchTypeDeclaration(typeId)
+	if typeDecl == nil {
+		typeDecl = &TypeDeclaration{
+			nodeSource: nodeSource{},
+			Type:       UnknownType{Name: typeId, Scope: currentScope, nodeSource: makeNodeSource(typeToken)},
+		}
+	}
+
+	exp := newStructLiteralExpression(makeNodeSource(typeToken), typeDecl, fields)
+	if _, ok := typeDecl.Type.(UnknownType); ok {
+		p.unknownStructLiterals = append(p.unknownStructLiterals, exp)
 	}
 
 	return exp, nil
 }
 
+// parseIndexOrSliceExpression parses an index like "a[i]" or a slice like "a[1:3]", after the '['. Both bounds of a slice
+// may be left out.
+func (p *Parser) parseIndexOrSliceExpression(startToken lexer.Token, exp Expression, currentScope Scope) (Expression, error) {
+	token := p.peekNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	var low Expression
+	if token.Type() != lexer.Colon {
+		var err error
+		low, err = p.parseEnclosedExpression(currentScope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token = p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+	if token.Type() == lexer.RightBracket && low != nil {
+		return newIndexExpression(makeNodeSource(startToken), exp, low), nil
+	}
+	if token.Type() != lexer.Colon {
+		return nil, unexpectedTokenError(token, lexer.RightBracket, lexer.Colon)
+	}
+
+	token = p.peekNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+
+	var high Expression
+	if token.Type() != lexer.RightBracket {
+		var err error
+		high, err = p.parseEnclosedExpression(currentScope)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token = p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
+	if token.Type() != lexer.RightBracket {
+		return nil, unexpectedTokenError(token, lexer.RightBracket)
+	}
+
+	return newSliceExpression(makeNodeSource(startToken), exp, low, high, p.fileScope.types), nil
+}
+
+// parseBuiltInCallExpression parses a call of the built-in functions len and append, after the '('. These cannot be
+// declared as normal functions, as they work on arrays and slices of any element type.
+func (p *Parser) parseBuiltInCallExpression(idToken lexer.IdentifierToken, currentScope Scope) (Expression, error) {
+	call, err := p.parseFunctionCallExpression(idToken, nil, currentScope)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := makeNodeSource(idToken)
+	params := call.Parameters
+	if idToken.Identifier() == "len" {
+		if len(params) != 1 {
+			return nil, errors.Errorf("number of parameters mismatch: expected 1 but was given %d on line %d column %d",
+				len(params), ns.UFSourceLine(), ns.UFSourceColumn())
+		}
+
+		return newLenExpression(ns, params[0], currentScope), nil
+	}
+
+	if len(params) == 0 {
+		return nil, errors.Errorf("append needs a slice to append to on line %d column %d", ns.UFSourceLine(), ns.UFSourceColumn())
+	}
+
+	return newAppendExpression(ns, params[0], params[1:]), nil
+}
+
 func (p *Parser) parseParenthesizedExpression(currentScope Scope) (Expression, error) {
-	exp, err := p.parseExpression(0, currentScope)
+	exp, err := p.parseEnclosedExpression(currentScope)
 	if err != nil {
 		return nil, err
 	}
 
 	token := p.getNextToken()
+	if token == nil {
+		return nil, unexpectedEOF()
+	}
 	if token.Type() != lexer.RightParenthesis {
 		return nil, unexpectedTokenError(token, lexer.RightParenthesis)
 	}
@@ -877,7 +1886,7 @@ func (p *Parser) parseFunctionCallExpression(startToken lexer.Token, callSource
 			}
 		}
 
-		exp, err := p.parseExpression(0, currentScope)
+		exp, err := p.parseEnclosedExpression(currentScope)
 		if err != nil {
 			return nil, err
 		}
@@ -908,19 +1917,12 @@ func (p *Parser) peekNextToken() lexer.Token {
 
 func (p *Parser) resolveUnknownTypes() error {
 	for _, f := range p.unknownFieldTypes {
-		t := f.VariableDeclaration.TypeDeclaration.Type
-		if ut, ok := t.(UnknownType); ok {
-			typeId := ut.Name
-			decl := ut.Scope.SearchTypeDeclaration(typeId)
-			if decl != nil {
-				f.VariableDeclaration.TypeDeclaration = decl
-			} else {
-				return errors.Errorf("no type found for identifier '%s' at line %d column %d",
-					typeId, ut.nodeSource.UFSourceLine(), ut.nodeSource.UFSourceColumn())
-			}
-		} else {
-			return errors.New("error resolving unknownFieldTypes: expected type of TypeDeclaration.Type to be UnknownType")
+		decl, err := p.fileScope.types.resolveTypeDeclaration(f.VariableDeclaration.TypeDeclaration)
+		if err != nil {
+			return err
 		}
+
+		f.VariableDeclaration.TypeDeclaration = decl
 	}
 
 	for _, exp := range p.unknownVarFuncIdentifiers {
@@ -946,20 +1948,41 @@ func (p *Parser) resolveUnknownTypes() error {
 		}
 	}
 
+	for _, exp := range p.unknownStructLiterals {
+		if ut, ok := exp.TypeDeclaration.Type.(UnknownType); ok {
+			decl := ut.Scope.SearchTypeDeclaration(ut.Name)
+			if decl == nil {
+				return errors.Errorf("no type found for identifier '%s' at line %d column %d",
+					ut.Name, ut.nodeSource.UFSourceLine(), ut.nodeSource.UFSourceColumn())
+			}
+
+			exp.TypeDeclaration = decl
+		} else {
+			return errors.New("error resolving unknownStructLiterals: expected type of TypeDeclaration.Type to be UnknownType")
+		}
+	}
+
 	for _, stmt := range p.unknownIdentifierStatements {
 		if varDecl, ok := stmt.(*VariableDeclaration); ok {
-			t := varDecl.TypeDeclaration.Type
-			if ut, ok := t.(UnknownType); ok {
-				typeId := ut.Name
-				decl := ut.Scope.SearchTypeDeclaration(typeId)
-				if decl != nil {
-					varDecl.TypeDeclaration = decl
-				} else {
-					return errors.Errorf("no type found for identifier '%s' at line %d column %d",
-						typeId, ut.nodeSource.UFSourceLine(), ut.nodeSource.UFSourceColumn())
+			decl, err := p.fileScope.types.resolveTypeDeclaration(varDecl.TypeDeclaration)
+			if err != nil {
+				return err
+			}
+
+			varDecl.TypeDeclaration = decl
+		} else if s, ok := stmt.(*MultiAssignStatement); ok {
+			for i, varDecl := range s.VariableDeclarations {
+				d, ok := varDecl.(*UnknownDeclaration)
+				if !ok {
+					continue
 				}
-			} else {
-				return errors.New("error resolving unknownIdentifierStatements: expected type of VariableDeclaration.TypeDeclaration.Type to be UnknownType")
+
+				decl := d.Scope.SearchVariableDeclaration(d.Identifier)
+				if decl == nil {
+					return errors.Errorf("no variable found for identifier '%s' at line %d column %d",
+						d.Identifier, d.UFSourceLine(), d.UFSourceColumn())
+				}
+				s.VariableDeclarations[i] = decl
 			}
 		} else if s, ok := stmt.(StatementHavingVariableDeclaration); ok {
 			if d, ok := s.GetVariableDeclaration().(*UnknownDeclaration); ok {
@@ -1013,6 +2036,10 @@ func unexpectedEOF() error {
 	return errors.New("unexpected end of file")
 }
 
+func blankIdentifierError(token lexer.Token) error {
+	return errors.Errorf("cannot use '%s' as a value at line %d column %d", blankIdentifier, token.UFLine(), token.UFColumn())
+}
+
 func alreadyDeclaredError(d Declaration, currentNodeSource nodeSource) error {
 	t := d.DeclarationType()
 	if t == "unknown" {
@@ -48,7 +48,39 @@ type Scope interface {
 	CloneShallow() Scope
 }
 
-var cachedBuiltInScopeTypes map[string]*TypeDeclaration
+// The built-in types are shared by all compilations, and are never changed.
+var builtInTypeDeclarations = map[string]*TypeDeclaration{
+	"Int": {
+		Type: BasicType{
+			DataType: IntDataType,
+			Name:     "Int",
+		},
+	},
+	"Byte": {
+		Type: BasicType{
+			DataType: ByteDataType,
+			Name:     "Byte",
+		},
+	},
+	"String": {
+		Type: BasicType{
+			DataType: StringDataType,
+			Name:     "String",
+		},
+	},
+	"Bool": {
+		Type: BasicType{
+			DataType: BoolDataType,
+			Name:     "Bool",
+		},
+	},
+	"Float": {
+		Type: BasicType{
+			DataType: FloatDataType,
+			Name:     "Float",
+		},
+	},
+}
 
 type BuiltInScope struct{}
 
@@ -64,6 +96,19 @@ type FileScope struct {
 	// FIXME: move to packages scope
 	AllTypeDeclarations []*TypeDeclaration
 
+	// List of all function literals in this file, each before the function literals inside it.
+	// FIXME: move to packages scope
+	AllFunctionLiterals []*FunctionLiteralExpression
+
+	// The variables and constants of this file in the order their values must be computed, set by the semantic
+	// analysis.
+	// FIXME: move to packages scope
+	InitializationOrder []*VariableDeclaration
+
+	// The values of the constants, and of the variables of this file that are known at compile time, set by the
+	// semantic analysis. Integers are a *big.Int, floats a float64 and booleans a bool.
+	ConstantValues map[*VariableDeclaration]interface{}
+
 	// Note every sub-scope declaration in this file scope so that declaration
 	// clashes can be found when a file-scope declaration is done after a sub-scope
 	// declaration might have been done already.
@@ -72,12 +117,17 @@ type FileScope struct {
 	// declared in a sub-scope.
 	// FIXME: move to packages scope
 	subScopeDeclarations map[string]nodeSource
+
+	// Array, slice and function types used in this file.
+	// FIXME: move to packages scope
+	types *typeCache
 }
 
 func NewFileScope(parentScope Scope) *FileScope {
 	return &FileScope{
 		BasicScope:           *NewBasicScope(parentScope, FileScopeType),
 		subScopeDeclarations: make(map[string]nodeSource),
+		types:                newTypeCache(),
 	}
 }
 
@@ -88,6 +138,10 @@ type BasicScope struct {
 
 	parentScope Scope
 	scopeType   ScopeType
+
+	// Set on the function scope of a function literal, through which the scopes of the functions around it are
+	// searched too.
+	functionLiteral *FunctionLiteralExpression
 }
 
 func NewBasicScope(parentScope Scope, scopeType ScopeType) *BasicScope {
@@ -101,10 +155,21 @@ func NewBasicScope(parentScope Scope, scopeType ScopeType) *BasicScope {
 	}
 }
 
+// NewFunctionLiteralScope returns the function scope of a function literal. Unlike the scope of a declared function,
+// the variables of the functions around it can be found from it.
+func NewFunctionLiteralScope(parentScope Scope, literal *FunctionLiteralExpression) *BasicScope {
+	s := NewBasicScope(parentScope, FunctionScopeType)
+	s.functionLiteral = literal
+	return s
+}
+
+// SearchVariableDeclaration searches the scopes up to the function scope, and then the top-level scopes. A variable
+// found in a function around a function literal is captured by every function literal the search went out of.
 func (s *BasicScope) SearchVariableDeclaration(identifier string) *VariableDeclaration {
 	var currentScope Scope
 	currentScope = s
 	skipTillTopLevel := false
+	var literals []*FunctionLiteralExpression
 
 	for currentScope != nil {
 		if skipTillTopLevel {
@@ -112,17 +177,26 @@ func (s *BasicScope) SearchVariableDeclaration(identifier string) *VariableDecla
 				currentScope = currentScope.GetParentScope()
 				continue
 			}
-		} else {
-			if currentScope.ScopeType() == FunctionScopeType {
-				skipTillTopLevel = true
-			}
 		}
 
 		decl := currentScope.GetVariableDeclaration(identifier)
 		if decl != nil {
+			if currentScope.ScopeType() != FileScopeType && currentScope.ScopeType() != BuiltInScopeType {
+				for _, literal := range literals {
+					literal.capture(decl)
+				}
+			}
 			return decl
 		}
 
+		if !skipTillTopLevel && currentScope.ScopeType() == FunctionScopeType {
+			if literal := getFunctionLiteral(currentScope); literal != nil {
+				literals = append(literals, literal)
+			} else {
+				skipTillTopLevel = true
+			}
+		}
+
 		currentScope = currentScope.GetParentScope()
 	}
 
@@ -141,7 +215,7 @@ func (s *BasicScope) SearchTypeDeclaration(identifier string) *TypeDeclaration {
 				continue
 			}
 		} else {
-			if currentScope.ScopeType() == FunctionScopeType {
+			if currentScope.ScopeType() == FunctionScopeType && getFunctionLiteral(currentScope) == nil {
 				skipTillTopLevel = true
 			}
 		}
@@ -169,7 +243,7 @@ func (s *BasicScope) SearchFunctionDeclaration(identifier string) *FunctionDecla
 				continue
 			}
 		} else {
-			if currentScope.ScopeType() == FunctionScopeType {
+			if currentScope.ScopeType() == FunctionScopeType && getFunctionLiteral(currentScope) == nil {
 				skipTillTopLevel = true
 			}
 		}
@@ -322,9 +396,38 @@ func (s *BasicScope) CloneShallow() Scope {
 		functionDeclarations: funcDecls,
 		parentScope:          s.parentScope,
 		scopeType:            s.scopeType,
+		functionLiteral:      s.functionLiteral,
+	}
+}
+
+// getFunctionLiteral returns the function literal of which the scope is the function scope, or nil.
+func getFunctionLiteral(scope Scope) *FunctionLiteralExpression {
+	if s, ok := scope.(*BasicScope); ok {
+		return s.functionLiteral
+	}
+
+	return nil
+}
+
+// declareFunctionLiteral notes the function literal in the file scope, so its statements can be checked apart from the
+// function it is in.
+func declareFunctionLiteral(scope Scope, literal *FunctionLiteralExpression) {
+	currentScope := scope
+	for currentScope != nil {
+		if fs, ok := currentScope.(*FileScope); ok {
+			fs.AllFunctionLiterals = append(fs.AllFunctionLiterals, literal)
+			return
+		}
+
+		currentScope = currentScope.GetParentScope()
 	}
 }
 
+func (s *FileScope) DeclareType(identifier string, declaration *TypeDeclaration) {
+	s.typeDeclarations[identifier] = declaration
+	s.AllTypeDeclarations = append(s.AllTypeDeclarations, declaration)
+}
+
 func (s *FileScope) ScopeType() ScopeType {
 	return FileScopeType
 }
@@ -353,6 +456,7 @@ func (s *FileScope) CloneShallow() Scope {
 			scopeType:            s.scopeType,
 		},
 		subScopeDeclarations: make(map[string]nodeSource),
+		types:                s.types,
 	}
 }
 
@@ -399,36 +503,7 @@ func (b *BuiltInScope) GetVariableDeclaration(string) *VariableDeclaration {
 }
 
 func (b *BuiltInScope) GetTypeDeclaration(identifier string) *TypeDeclaration {
-	if len(cachedBuiltInScopeTypes) == 0 {
-		cachedBuiltInScopeTypes = map[string]*TypeDeclaration{
-			"Int": {
-				Type: BasicType{
-					DataType: IntDataType,
-					Name:     "Int",
-				},
-			},
-			"Byte": {
-				Type: BasicType{
-					DataType: ByteDataType,
-					Name:     "Byte",
-				},
-			},
-			"String": {
-				Type: BasicType{
-					DataType: StringDataType,
-					Name:     "String",
-				},
-			},
-			"Bool": {
-				Type: BasicType{
-					DataType: BoolDataType,
-					Name:     "Bool",
-				},
-			},
-		}
-	}
-
-	decl, ok := cachedBuiltInScopeTypes[identifier]
+	decl, ok := builtInTypeDeclarations[identifier]
 	if !ok {
 		return nil
 	}
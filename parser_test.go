@@ -100,8 +100,9 @@ func test(asd Int) Int {
 		expectStringLiteralExpression(assignStmt.Expression, "abc")
 
 		stmt = testFuncDef.Statements[6]
-		subAssignStmt := stmt.(*parser.SubtractAssignStatement)
-		Expect(subAssignStmt.VariableDeclaration).To(Equal(varADecl))
+		subAssignStmt := stmt.(*parser.CompoundAssignStatement)
+		Expect(subAssignStmt.Operator).To(Equal(lexer.Subtract))
+		Expect(subAssignStmt.GetVariableDeclaration()).To(Equal(varADecl))
 		addExp = subAssignStmt.Expression.(*parser.AddExpression)
 		mulExp := addExp.Right.(*parser.MultiplyExpression)
 		expectIntLiteralExpression(addExp.Left, 2)
@@ -119,8 +120,837 @@ func test(asd Int) Int {
 		expectIdentifierExpression(addExp.Left, varADecl)
 		expectIdentifierExpression(addExp.Right, varASDDecl)
 	})
+	It("should parse a for statement", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func test() {
+	var a Int;
+	for var i Int; i < 10; i++ {
+		a += i;
+	}
+	for ; ; {
+	}
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		testFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		Expect(len(testFuncDef.Statements)).To(Equal(3))
+		varADecl := testFuncDef.Statements[0].(*parser.VariableDeclaration)
+
+		forStmt := testFuncDef.Statements[1].(*parser.ForStatement)
+		Expect(forStmt.UFSourceLine()).To(Equal(4))
+		Expect(forStmt.UFSourceColumn()).To(Equal(2))
+		varIDecl := forStmt.Init.(*parser.VariableDeclaration)
+		Expect(varIDecl.Name).To(Equal("i"))
+		lessExp := forStmt.Condition.(*parser.LessExpression)
+		expectIdentifierExpression(lessExp.Left, varIDecl)
+		expectIntLiteralExpression(lessExp.Right, 10)
+		Expect(forStmt.LoopAction.(*parser.IncrementStatement).VariableDeclaration).To(Equal(varIDecl))
+		Expect(len(forStmt.Statements)).To(Equal(1))
+		addAssignStmt := forStmt.Statements[0].(*parser.CompoundAssignStatement)
+		Expect(addAssignStmt.Operator).To(Equal(lexer.Add))
+		Expect(addAssignStmt.GetVariableDeclaration()).To(Equal(varADecl))
+		expectIdentifierExpression(addAssignStmt.Expression, varIDecl)
+
+		forStmt = testFuncDef.Statements[2].(*parser.ForStatement)
+		Expect(forStmt.Init).To(BeNil())
+		Expect(forStmt.Condition).To(BeNil())
+		Expect(forStmt.LoopAction).To(BeNil())
+		Expect(len(forStmt.Statements)).To(Equal(0))
+	})
+	It("should scope the for statement init variable to the loop", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func test() {
+	for var i Int; i < 10; i++ {
+	}
+	i = 2;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		_, _, err = p.Parse(tokens)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(ContainSubstring("no variable found for identifier 'i' at line 5 column 2"))
+	})
+	It("should parse else if chains as nested if statements", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func test(a Int) Int {
+	if a < 1 {
+		return 1;
+	} else if a < 2 {
+		return 2;
+	} else if a < 3 {
+		return 3;
+	} else {
+		return 4;
+	}
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		testFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		Expect(len(testFuncDef.Statements)).To(Equal(1))
+
+		ifStmt := testFuncDef.Statements[0].(*parser.IfStatement)
+		for i := 1; i <= 3; i++ {
+			lessExp := ifStmt.Condition.(*parser.LessExpression)
+			expectIntLiteralExpression(lessExp.Right, i)
+			expectIntLiteralExpression(ifStmt.ThenStatements[0].(*parser.ReturnStatement).ReturnExpressions[0], i)
+			Expect(len(ifStmt.ElseStatements)).To(Equal(1))
+
+			if i < 3 {
+				ifStmt = ifStmt.ElseStatements[0].(*parser.IfStatement)
+				Expect(ifStmt.UFSourceLine()).To(Equal(3 + i*2))
+				Expect(ifStmt.UFSourceColumn()).To(Equal(9))
+			}
+		}
+		expectIntLiteralExpression(ifStmt.ElseStatements[0].(*parser.ReturnStatement).ReturnExpressions[0], 4)
+	})
+	It("should parse return statements anywhere in a block", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func test(a Int) Int {
+	if a < 0 {
+		return 0;
+		a = 1;
+	}
+	return a;
+	a = 2;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		testFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		Expect(len(testFuncDef.Statements)).To(Equal(3))
+		ifStmt := testFuncDef.Statements[0].(*parser.IfStatement)
+		Expect(ifStmt.ThenStatements[0]).To(BeAssignableToTypeOf(&parser.ReturnStatement{}))
+		Expect(ifStmt.ThenStatements[1]).To(BeAssignableToTypeOf(&parser.AssignStatement{}))
+		Expect(testFuncDef.Statements[1]).To(BeAssignableToTypeOf(&parser.ReturnStatement{}))
+		Expect(testFuncDef.Statements[2]).To(BeAssignableToTypeOf(&parser.AssignStatement{}))
+	})
+	It("should parse break and continue statements with the loops they refer to", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func main() {
+	outer: for var i = 0; i < 3; i++ {
+		while true {
+			if i == 1 {
+				continue outer;
+			}
+			break;
+		}
+		break outer;
+	}
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		mainFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		forStmt := mainFuncDef.Statements[0].(*parser.ForStatement)
+		Expect(forStmt.Label).To(Equal("outer"))
+		Expect(forStmt.UFSourceLine()).To(Equal(3))
+		Expect(forStmt.UFSourceColumn()).To(Equal(9))
+
+		whileStmt := forStmt.Statements[0].(*parser.WhileStatement)
+		Expect(whileStmt.Label).To(Equal(""))
+
+		continueStmt := whileStmt.Statements[0].(*parser.IfStatement).ThenStatements[0].(*parser.ContinueStatement)
+		Expect(continueStmt.Label).To(Equal("outer"))
+		Expect(continueStmt.Loop).To(BeIdenticalTo(forStmt))
+
+		breakStmt := whileStmt.Statements[1].(*parser.BreakStatement)
+		Expect(breakStmt.Label).To(Equal(""))
+		Expect(breakStmt.Loop).To(BeIdenticalTo(whileStmt))
+
+		outerBreakStmt := forStmt.Statements[1].(*parser.BreakStatement)
+		Expect(outerBreakStmt.UFSourceLine()).To(Equal(10))
+		Expect(outerBreakStmt.UFSourceColumn()).To(Equal(3))
+		Expect(outerBreakStmt.Loop).To(BeIdenticalTo(forStmt))
+	})
+	It("should fail a label that is already used by a surrounding loop", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func main() {
+	loop: while true {
+		loop: while true {
+		}
+	}
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		_, _, err = p.Parse(tokens)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(ContainSubstring("label 'loop' at line 4 column 3 is already used by a surrounding loop at line 3 column 8"))
+	})
+	It("should parse struct type declarations", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+type Line { from Point; to Point; }
+
+func test() {
+	type Pair { first Int; second Line; }
+	var pair Pair;
+}
+
+type Point {
+	x Int;
+	y Int;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+		Expect(len(declarations)).To(Equal(3))
+
+		lineDecl := declarations[0].(*parser.TypeDeclaration)
+		Expect(lineDecl.UFSourceLine()).To(Equal(2))
+		Expect(lineDecl.UFSourceColumn()).To(Equal(1))
+		pointDecl := declarations[2].(*parser.TypeDeclaration)
+		Expect(fileScope.SearchTypeDeclaration("Line")).To(BeIdenticalTo(lineDecl))
+		Expect(fileScope.SearchTypeDeclaration("Point")).To(BeIdenticalTo(pointDecl))
+
+		lineType := lineDecl.Type.(parser.StructType)
+		Expect(lineType.Name).To(Equal("Line"))
+		Expect(len(lineType.Fields)).To(Equal(2))
+		Expect(lineType.Fields[0].Name).To(Equal("from"))
+		Expect(lineType.Fields[0].VariableDeclaration.TypeDeclaration).To(BeIdenticalTo(pointDecl))
+		Expect(lineType.Fields[1].Name).To(Equal("to"))
+		Expect(lineType.Fields[1].VariableDeclaration.TypeDeclaration).To(BeIdenticalTo(pointDecl))
+
+		pointType := pointDecl.Type.(parser.StructType)
+		Expect(len(pointType.Fields)).To(Equal(2))
+		Expect(pointType.Fields[1].VariableDeclaration.UFSourceLine()).To(Equal(11))
+		expectTypeDeclaration(pointType.Fields[1].VariableDeclaration.TypeDeclaration, "Int", parser.IntDataType)
+
+		testFuncDef := expectFunctionDeclaration(declarations[1]).FunctionDefinition
+		pairDecl := testFuncDef.Statements[0].(*parser.TypeDeclaration)
+		Expect(pairDecl.Type.(parser.StructType).Fields[1].VariableDeclaration.TypeDeclaration).To(BeIdenticalTo(lineDecl))
+		Expect(testFuncDef.Statements[1].(*parser.VariableDeclaration).TypeDeclaration).To(BeIdenticalTo(pairDecl))
+
+		Expect(fileScope.AllTypeDeclarations).To(ConsistOf(lineDecl, pairDecl, pointDecl))
+	})
+	It("should resolve struct fields referring to their own type", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func test() {
+	type Node { value Int; next Node; }
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		nodeDecl := expectFunctionDeclaration(declarations[0]).FunctionDefinition.Statements[0].(*parser.TypeDeclaration)
+		Expect(nodeDecl.Type.(parser.StructType).Fields[1].VariableDeclaration.TypeDeclaration).To(BeIdenticalTo(nodeDecl))
+	})
+	It("should fail a struct with a duplicate field", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		tokens, err := l.Parse(bytes.NewBufferString("type Point { x Int; x Int; }"))
+		Expect(err).To(Succeed())
+
+		_, _, err = p.Parse(tokens)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(ContainSubstring("field 'x' on line 1 column 21 is already declared on line 1 column 14"))
+	})
+	It("should parse field access, field assignments and struct literals", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func test() Int {
+	var l Line;
+	l.from.x = 2;
+	l.to = Point{x: 1, y: l.from.x,};
+	if l.to.x == (Point{}).x {
+	}
+	return l.to.y;
+}
+
+type Line { from Point; to Point; }
+type Point { x Int; y Int; }
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		pointDecl := declarations[2].(*parser.TypeDeclaration)
+		testFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		varLDecl := testFuncDef.Statements[0].(*parser.VariableDeclaration)
+
+		fieldAssignStmt := testFuncDef.Statements[1].(*parser.FieldAssignStatement)
+		Expect(fieldAssignStmt.GetVariableDeclaration()).To(Equal(varLDecl))
+		Expect(fieldAssignStmt.Target.FieldName).To(Equal("x"))
+		Expect(fieldAssignStmt.Target.UFSourceColumn()).To(Equal(9))
+		fromExp := fieldAssignStmt.Target.Expression.(*parser.FieldAccessExpression)
+		Expect(fromExp.FieldName).To(Equal("from"))
+		expectIdentifierExpression(fromExp.Expression, varLDecl)
+		expectIntLiteralExpression(fieldAssignStmt.Expression, 2)
+
+		fieldAssignStmt = testFuncDef.Statements[2].(*parser.FieldAssignStatement)
+		literalExp := fieldAssignStmt.Expression.(*parser.StructLiteralExpression)
+		Expect(literalExp.TypeDeclaration).To(BeIdenticalTo(pointDecl))
+		Expect(len(literalExp.Fields)).To(Equal(2))
+		Expect(literalExp.Fields[0].Name).To(Equal("x"))
+		expectIntLiteralExpression(literalExp.Fields[0].Expression, 1)
+		Expect(literalExp.Fields[1].Name).To(Equal("y"))
+		Expect(literalExp.Fields[1].Expression.(*parser.FieldAccessExpression).FieldName).To(Equal("x"))
+
+		ifStmt := testFuncDef.Statements[3].(*parser.IfStatement)
+		equalExp := ifStmt.Condition.(*parser.EqualExpression)
+		Expect(equalExp.Left.(*parser.FieldAccessExpression).FieldName).To(Equal("x"))
+		literalExp = equalExp.Right.(*parser.FieldAccessExpression).Expression.(*parser.StructLiteralExpression)
+		Expect(len(literalExp.Fields)).To(Equal(0))
+	})
+	It("should parse array and slice types, indices, slices and the built-in functions", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func test(points []Point) [2]Int {
+	var a [2]Int;
+	var b []Int;
+	a[1] = len(points);
+	b = append(a[:], points[0].x, 3);
+	b = b[1:a[0]];
+	return a;
+}
+
+type Point { x Int; grid [3][]Point; }
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		pointDecl := declarations[1].(*parser.TypeDeclaration)
+		gridType := pointDecl.Type.(parser.StructType).Fields[1].VariableDeclaration.TypeDeclaration.Type.(parser.ArrayType)
+		Expect(gridType.TypeName()).To(Equal("[3][]Point"))
+		Expect(gridType.Length).To(Equal(int64(3)))
+		Expect(gridType.ElementType.Type.(parser.SliceType).ElementType).To(BeIdenticalTo(pointDecl))
+
+		testFuncDecl := expectFunctionDeclaration(declarations[0])
+		testFuncDef := testFuncDecl.FunctionDefinition
+		pointsDecl := testFuncDef.FunctionType.Parameters[0].VariableDeclaration
+		Expect(pointsDecl.TypeDeclaration.Type.(parser.SliceType).ElementType).To(BeIdenticalTo(pointDecl))
+
+		// The same array type used in different places has the same declaration.
+		varADecl := testFuncDef.Statements[0].(*parser.VariableDeclaration)
+		Expect(varADecl.TypeDeclaration.Type.TypeName()).To(Equal("[2]Int"))
+		Expect(varADecl.TypeDeclaration).To(BeIdenticalTo(testFuncDef.FunctionType.ReturnTypes[0].VariableDeclaration.TypeDeclaration))
+		varBDecl := testFuncDef.Statements[1].(*parser.VariableDeclaration)
+		expectTypeDeclaration(varBDecl.TypeDeclaration.Type.(parser.SliceType).ElementType, "Int", parser.IntDataType)
+
+		indexAssignStmt := testFuncDef.Statements[2].(*parser.IndexAssignStatement)
+		Expect(indexAssignStmt.GetVariableDeclaration()).To(Equal(varADecl))
+		Expect(indexAssignStmt.Target.UFSourceColumn()).To(Equal(3))
+		expectIntLiteralExpression(indexAssignStmt.Target.Index, 1)
+		expectIdentifierExpression(indexAssignStmt.Expression.(*parser.LenExpression).Expression, pointsDecl)
+
+		appendExp := testFuncDef.Statements[3].(*parser.AssignStatement).Expression.(*parser.AppendExpression)
+		sliceExp := appendExp.Slice.(*parser.SliceExpression)
+		expectIdentifierExpression(sliceExp.Expression, varADecl)
+		Expect(sliceExp.Low).To(BeNil())
+		Expect(sliceExp.High).To(BeNil())
+		Expect(len(appendExp.Elements)).To(Equal(2))
+		fieldExp := appendExp.Elements[0].(*parser.FieldAccessExpression)
+		expectIdentifierExpression(fieldExp.Expression.(*parser.IndexExpression).Expression, pointsDecl)
+		expectIntLiteralExpression(appendExp.Elements[1], 3)
+
+		sliceExp = testFuncDef.Statements[4].(*parser.AssignStatement).Expression.(*parser.SliceExpression)
+		expectIntLiteralExpression(sliceExp.Low, 1)
+		expectIntLiteralExpression(sliceExp.High.(*parser.IndexExpression).Index, 0)
+	})
+	It("should parse variables and constants in the file scope", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+var total = limit * 2;
+const limit Int = 10;
+var count Int;
+
+func main() Int {
+	count = total;
+	return limit;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+		Expect(len(declarations)).To(Equal(4))
+
+		totalDecl := declarations[0].(*parser.VariableDeclaration)
+		Expect(totalDecl.Name).To(Equal("total"))
+		Expect(totalDecl.Constant).To(BeFalse())
+		Expect(totalDecl.TypeDeclaration).To(BeNil())
+
+		limitDecl := declarations[1].(*parser.VariableDeclaration)
+		Expect(limitDecl.DeclarationType()).To(Equal("constant"))
+		expectTypeDeclaration(limitDecl.TypeDeclaration, "Int", parser.IntDataType)
+		expectIntLiteralExpression(limitDecl.Value, 10)
+		expectIdentifierExpression(totalDecl.Value.(*parser.MultiplyExpression).Left, limitDecl)
+
+		countDecl := declarations[2].(*parser.VariableDeclaration)
+		Expect(countDecl.Value).To(BeNil())
+		Expect(fileScope.SearchVariableDeclaration("count")).To(BeIdenticalTo(countDecl))
+
+		mainFuncDef := expectFunctionDeclaration(declarations[3]).FunctionDefinition
+		assignStmt := mainFuncDef.Statements[0].(*parser.AssignStatement)
+		Expect(assignStmt.VariableDeclaration).To(Equal(countDecl))
+		expectIdentifierExpression(assignStmt.Expression, totalDecl)
+	})
+	It("should parse variable declarations with values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func main() {
+	var a Int = 1 + 2;
+	var b = a;
+	for var i = 0; i < b; i++ {
+	}
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		mainFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		varADecl := mainFuncDef.Statements[0].(*parser.VariableDeclaration)
+		expectTypeDeclaration(varADecl.TypeDeclaration, "Int", parser.IntDataType)
+		addExp := varADecl.Value.(*parser.AddExpression)
+		expectIntLiteralExpression(addExp.Left, 1)
+		expectIntLiteralExpression(addExp.Right, 2)
+
+		// The type is taken from the value by the semantic analyzer.
+		varBDecl := mainFuncDef.Statements[1].(*parser.VariableDeclaration)
+		Expect(varBDecl.TypeDeclaration).To(BeNil())
+		expectIdentifierExpression(varBDecl.Value, varADecl)
+
+		forStmt := mainFuncDef.Statements[2].(*parser.ForStatement)
+		varIDecl := forStmt.Init.(*parser.VariableDeclaration)
+		expectIntLiteralExpression(varIDecl.Value, 0)
+		expectIdentifierExpression(forStmt.Condition.(*parser.LessExpression).Left, varIDecl)
+	})
+	It("should parse prefix operators before postfix operators and after binary operators", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func main() {
+	var a [2]Int;
+	var b = -a[0] * 2;
+	var c = !(b < -5) == !true;
+	var d = 3 - -a[1];
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		mainFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		varADecl := mainFuncDef.Statements[0].(*parser.VariableDeclaration)
+
+		mulExp := mainFuncDef.Statements[1].(*parser.VariableDeclaration).Value.(*parser.MultiplyExpression)
+		negExp := mulExp.Left.(*parser.NegateExpression)
+		Expect(negExp.UFSourceColumn()).To(Equal(10))
+		expectIdentifierExpression(negExp.Expression.(*parser.IndexExpression).Expression, varADecl)
+		expectIntLiteralExpression(mulExp.Right, 2)
+
+		eqExp := mainFuncDef.Statements[2].(*parser.VariableDeclaration).Value.(*parser.EqualExpression)
+		lessExp := eqExp.Left.(*parser.NotExpression).Expression.(*parser.LessExpression)
+		expectIntLiteralExpression(lessExp.Right, -5)
+		Expect(eqExp.Right.(*parser.NotExpression).Expression.(*parser.BooleanLiteralExpression).Value).To(BeTrue())
+
+		subExp := mainFuncDef.Statements[3].(*parser.VariableDeclaration).Value.(*parser.SubtractExpression)
+		expectIntLiteralExpression(subExp.Left, 3)
+		Expect(subExp.Right.(*parser.NegateExpression).Expression).To(BeAssignableToTypeOf(&parser.IndexExpression{}))
+	})
+	It("should parse integer operators with their precedence and compound assignments", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func main() {
+	var a = 5;
+	var b = a + 1 << 2 & ~a | 3;
+	b %= a ^ 2;
+	b >>= 1;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		mainFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		varADecl := mainFuncDef.Statements[0].(*parser.VariableDeclaration)
+		varBDecl := mainFuncDef.Statements[1].(*parser.VariableDeclaration)
+
+		orExp := varBDecl.Value.(*parser.BitwiseOrExpression)
+		expectIntLiteralExpression(orExp.Right, 3)
+		addExp := orExp.Left.(*parser.AddExpression)
+		expectIdentifierExpression(addExp.Left, varADecl)
+		andExp := addExp.Right.(*parser.BitwiseAndExpression)
+		shiftExp := andExp.Left.(*parser.ShiftLeftExpression)
+		expectIntLiteralExpression(shiftExp.Left, 1)
+		expectIntLiteralExpression(shiftExp.Right, 2)
+		expectIdentifierExpression(andExp.Right.(*parser.BitwiseNotExpression).Expression, varADecl)
+
+		modStmt := mainFuncDef.Statements[2].(*parser.CompoundAssignStatement)
+		Expect(modStmt.Operator).To(Equal(lexer.Modulo))
+		Expect(modStmt.GetVariableDeclaration()).To(Equal(varBDecl))
+		xorExp := modStmt.Expression.(*parser.BitwiseXorExpression)
+		expectIdentifierExpression(xorExp.Left, varADecl)
+		expectIntLiteralExpression(xorExp.Right, 2)
+
+		shiftStmt := mainFuncDef.Statements[3].(*parser.CompoundAssignStatement)
+		Expect(shiftStmt.Operator).To(Equal(lexer.ShiftRight))
+		Expect(shiftStmt.Operation()).To(BeAssignableToTypeOf(&parser.ShiftRightExpression{}))
+	})
+	It("should parse assignments of multiple values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func main() {
+	var q Int;
+	q, r = divmod(7, 2);
+}
+var r Int;
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		divmodDecl := expectFunctionDeclaration(declarations[0])
+		Expect(len(divmodDecl.FunctionDefinition.FunctionType.ReturnTypes)).To(Equal(2))
+		retStmt := divmodDecl.FunctionDefinition.Statements[0].(*parser.ReturnStatement)
+		Expect(len(retStmt.ReturnExpressions)).To(Equal(2))
+
+		mainFuncDef := expectFunctionDeclaration(declarations[1]).FunctionDefinition
+		varQDecl := mainFuncDef.Statements[0].(*parser.VariableDeclaration)
+		assignStmt := mainFuncDef.Statements[1].(*parser.MultiAssignStatement)
+		Expect(assignStmt.UFSourceLine()).To(Equal(7))
+		Expect(assignStmt.UFSourceColumn()).To(Equal(2))
+		Expect(assignStmt.VariableDeclarations).To(Equal([]parser.Declaration{varQDecl, declarations[2]}))
+		callExp := assignStmt.Expression.(*parser.FunctionCallExpression)
+		Expect(callExp.CallSource.(*parser.IdentifierExpression).IdentifierDeclaration).To(Equal(divmodDecl))
+	})
+	It("should parse call statements and discarded values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func main() {
+	divmod(7, 2);
+	_ = divmod(7, 2);
+	var q Int;
+	_, q = divmod(7, 2);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		divmodDecl := expectFunctionDeclaration(declarations[0])
+		mainFuncDef := expectFunctionDeclaration(declarations[1]).FunctionDefinition
+
+		callExp := mainFuncDef.Statements[0].(*parser.FunctionCallExpression)
+		Expect(callExp.CallSource.(*parser.IdentifierExpression).IdentifierDeclaration).To(Equal(divmodDecl))
+
+		discardStmt := mainFuncDef.Statements[1].(*parser.DiscardStatement)
+		Expect(discardStmt.UFSourceLine()).To(Equal(7))
+		Expect(discardStmt.UFSourceColumn()).To(Equal(2))
+		Expect(discardStmt.Expression).To(BeAssignableToTypeOf(&parser.FunctionCallExpression{}))
+
+		varQDecl := mainFuncDef.Statements[2].(*parser.VariableDeclaration)
+		assignStmt := mainFuncDef.Statements[3].(*parser.MultiAssignStatement)
+		Expect(assignStmt.VariableDeclarations).To(Equal([]parser.Declaration{nil, varQDecl}))
+	})
+	It("should parse function types and calls through function values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+func apply(f func(Int, Bool) Int, x Int) Int {
+	return f(x, true);
+}
+func pick() func() (Int, Int) {
+	return pick()();
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		applyFuncDef := expectFunctionDeclaration(declarations[0]).FunctionDefinition
+		paramFDecl := applyFuncDef.FunctionType.Parameters[0]
+		Expect(paramFDecl.VariableDeclaration.TypeDeclaration.Type.TypeName()).To(Equal("func(Int, Bool) Int"))
+		Expect(applyFuncDef.FunctionType.TypeName()).To(Equal("func(func(Int, Bool) Int, Int) Int"))
+
+		returnStmt := applyFuncDef.Statements[0].(*parser.ReturnStatement)
+		callExp := returnStmt.ReturnExpressions[0].(*parser.FunctionCallExpression)
+		Expect(callExp.CallSource.(*parser.IdentifierExpression).IdentifierDeclaration).To(Equal(paramFDecl.VariableDeclaration))
+
+		pickDecl := expectFunctionDeclaration(declarations[1])
+		Expect(pickDecl.FunctionDefinition.FunctionType.TypeName()).To(Equal("func() func() (Int, Int)"))
+
+		returnStmt = pickDecl.FunctionDefinition.Statements[0].(*parser.ReturnStatement)
+		callExp = returnStmt.ReturnExpressions[0].(*parser.FunctionCallExpression)
+		innerCallExp := callExp.CallSource.(*parser.FunctionCallExpression)
+		Expect(innerCallExp.CallSource.(*parser.IdentifierExpression).IdentifierDeclaration).To(Equal(pickDecl))
+	})
+	It("should parse function literals with the variables they capture", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		program := `
+var g Int;
+func counter(start Int) func() Int {
+	var n = start;
+	var step = 1;
+	return func() Int {
+		var inc = func() {
+			n += step + g;
+		};
+		inc();
+		return n;
+	};
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		counterFuncDef := expectFunctionDeclaration(declarations[1]).FunctionDefinition
+		varStartDecl := counterFuncDef.FunctionType.Parameters[0].VariableDeclaration
+		varNDecl := counterFuncDef.Statements[0].(*parser.VariableDeclaration)
+		varStepDecl := counterFuncDef.Statements[1].(*parser.VariableDeclaration)
+		Expect(varStartDecl.Captured).To(BeFalse())
+		Expect(varNDecl.Captured).To(BeTrue())
+		Expect(varStepDecl.Captured).To(BeTrue())
+		Expect(declarations[0].(*parser.VariableDeclaration).Captured).To(BeFalse())
+
+		returnStmt := counterFuncDef.Statements[2].(*parser.ReturnStatement)
+		outerLiteral := returnStmt.ReturnExpressions[0].(*parser.FunctionLiteralExpression)
+		Expect(outerLiteral.UFSourceLine()).To(Equal(6))
+		Expect(outerLiteral.UFSourceColumn()).To(Equal(9))
+		Expect(outerLiteral.FunctionDefinition.FunctionType.TypeName()).To(Equal("func() Int"))
+		Expect(outerLiteral.Captures).To(Equal([]*parser.VariableDeclaration{varNDecl, varStepDecl}))
+
+		// The inner function literal captures the variables through the outer one.
+		varIncDecl := outerLiteral.FunctionDefinition.Statements[0].(*parser.VariableDeclaration)
+		innerLiteral := varIncDecl.Value.(*parser.FunctionLiteralExpression)
+		Expect(innerLiteral.Captures).To(Equal([]*parser.VariableDeclaration{varNDecl, varStepDecl}))
+		Expect(varIncDecl.Captured).To(BeFalse())
+
+		addAssignStmt := innerLiteral.FunctionDefinition.Statements[0].(*parser.CompoundAssignStatement)
+		Expect(addAssignStmt.GetVariableDeclaration()).To(Equal(varNDecl))
+
+		Expect(fileScope.AllFunctionLiterals).To(Equal([]*parser.FunctionLiteralExpression{outerLiteral, innerLiteral}))
+	})
+	It("should fail using the blank identifier as a value", func() {
+		expectParseErrors(map[string]string{
+			`
+func main() {
+	_++;
+}
+`: "cannot use '_' as a value at line 3 column 2",
+			`
+func main() {
+	_ += 1;
+}
+`: "cannot use '_' as a value at line 3 column 2",
+		})
+	})
+	It("should fail compound assignments on fields and elements", func() {
+		expectParseErrors(map[string]string{
+			`
+type Point {
+	x Int;
+	ys []Int;
+}
+
+func main() {
+	var p Point;
+	p.x += 1;
+}
+`: "cannot use '+=' on a field or element at line 9 column 6: only a variable can be the target",
+			`
+func main() {
+	var a [3]Int;
+	a[0] *= 2;
+}
+`: "cannot use '*=' on a field or element at line 4 column 7: only a variable can be the target",
+			`
+type Point {
+	ys []Int;
+}
+
+func main() {
+	var p Point;
+	var i = 0;
+	p.ys[i] -= 1;
+}
+`: "cannot use '-=' on a field or element at line 9 column 10: only a variable can be the target",
+		})
+	})
+	It("should fail programs that end too early", func() {
+		expectParseErrors(map[string]string{
+			"var a = Float(1":     "unexpected end of file",
+			"var a = (1 + 2":      "unexpected end of file",
+			"var a = f(1, 2":      "unexpected end of file",
+			"func main() { a[0":   "unexpected end of file",
+			"func main() { if a ": "unexpected end of file",
+		})
+
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		tokens, err := l.Parse(bytes.NewBufferString(`
+func main() Int {
+	var p = Point{x: Float(1) * (2.0 + 3.0)};
+	for var i = 0; i < 3; i++ {
+		p.x = p.x + 1.0;
+	}
+	return Int(p.x);
+}
+
+type Point {
+	x Float;
+}
+`))
+		Expect(err).To(Succeed())
+
+		_, _, err = p.Parse(tokens)
+		Expect(err).To(Succeed())
+		for i := 1; i < len(tokens); i++ {
+			_, _, err = p.Parse(tokens[:i])
+			Expect(err).ToNot(Succeed(), "%d tokens", i)
+		}
+	})
+	It("should fail a constant without a value", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		tokens, err := l.Parse(bytes.NewBufferString("const a Int;"))
+		Expect(err).To(Succeed())
+
+		_, _, err = p.Parse(tokens)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(ContainSubstring("unexpected token ';' at line 1 column 12: expected: '='"))
+	})
+	It("should parse a program with a parser that failed to parse another program", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+
+		tokens, err := l.Parse(bytes.NewBufferString(`
+func main() Int {
+	var x = y;
+	x = x +;
+}
+`))
+		Expect(err).To(Succeed())
+
+		_, _, err = p.Parse(tokens)
+		Expect(err).ToNot(Succeed())
+
+		tokens, err = l.Parse(bytes.NewBufferString(`
+func main() Int {
+	var x = 1;
+	return x;
+}
+`))
+		Expect(err).To(Succeed())
+
+		declarations, _, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+		Expect(len(declarations)).To(Equal(1))
+	})
 })
 
+func expectParseErrors(programs map[string]string) {
+	l := lexer.Lexer{}
+	p := parser.Parser{}
+
+	for program, message := range programs {
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		_, _, err = p.Parse(tokens)
+		Expect(err).ToNot(Succeed(), program)
+		Expect(err.Error()).To(ContainSubstring(message), program)
+	}
+}
+
 func expectFunctionDeclaration(declaration parser.Declaration) *parser.FunctionDeclaration {
 	d, ok := declaration.(*parser.FunctionDeclaration)
 	Expect(ok).To(BeTrue())
@@ -137,10 +967,10 @@ func expectTypeDeclaration(declaration *parser.TypeDeclaration, typeId string, b
 
 func expectIntLiteralExpression(expression parser.Expression, value int) {
 	exp := expression.(*parser.IntegerLiteralExpression)
-	Expect(exp.Value).To(Equal(value))
+	Expect(exp.Value.Int64()).To(Equal(int64(value)))
 }
 
-func expectCharLiteralExpression(expression parser.Expression, value byte) {
+func expectCharLiteralExpression(expression parser.Expression, value rune) {
 	exp := expression.(*parser.CharacterLiteralExpression)
 	Expect(exp.Value).To(Equal(value))
 }
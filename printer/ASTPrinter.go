@@ -0,0 +1,368 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/milandamen/quisnix/lexer"
+	"github.com/milandamen/quisnix/parser"
+)
+
+// ASTPrinter prints the declaration tree with one node per line, indented by depth.
+// Every node is followed by its user friendly position, and identifiers are followed by the
+// declaration they resolved to. The output is deterministic so it can be compared between runs.
+type ASTPrinter struct {
+	// Print the types resulting from each expression. Must only be used after the semantic analyzer succeeded.
+	Types bool
+
+	w   io.Writer
+	err error
+}
+
+func (p *ASTPrinter) Print(w io.Writer, declarations []parser.Declaration) error {
+	p.w = w
+	p.err = nil
+	for _, decl := range declarations {
+		p.printDeclaration(decl, 0)
+	}
+
+	return p.err
+}
+
+func (p *ASTPrinter) printDeclaration(declaration parser.Declaration, depth int) {
+	switch d := declaration.(type) {
+	case *parser.FunctionDeclaration:
+		p.printLine(depth, d, "func %s%s", d.Name, getFunctionSignature(d.FunctionDefinition.FunctionType))
+		p.printStatements(d.FunctionDefinition.Statements, depth+1)
+	case *parser.VariableDeclaration:
+		keyword := "var"
+		if d.Constant {
+			keyword = "const"
+		}
+		if d.TypeDeclaration != nil {
+			p.printLine(depth, d, "%s %s %s", keyword, d.Name, d.TypeDeclaration.Type.TypeName())
+		} else {
+			p.printLine(depth, d, "%s %s", keyword, d.Name)
+		}
+		if d.Value != nil {
+			p.printExpression(d.Value, depth+1)
+		}
+	case *parser.TypeDeclaration:
+		p.printLine(depth, d, "type %s", d.Type.TypeName())
+		if st, ok := d.Type.(parser.StructType); ok {
+			for _, f := range st.Fields {
+				p.printLine(depth+1, f.VariableDeclaration, "field %s %s", f.Name, f.VariableDeclaration.TypeDeclaration.Type.TypeName())
+			}
+		}
+	default:
+		p.printLine(depth, d, "<unknown declaration %T>", d)
+	}
+}
+
+func (p *ASTPrinter) printStatements(statements []parser.Statement, depth int) {
+	for _, stmt := range statements {
+		p.printStatement(stmt, depth)
+	}
+}
+
+func (p *ASTPrinter) printStatement(statement parser.Statement, depth int) {
+	switch s := statement.(type) {
+	case *parser.VariableDeclaration:
+		p.printDeclaration(s, depth)
+	case *parser.TypeDeclaration:
+		p.printDeclaration(s, depth)
+	case *parser.AssignStatement:
+		p.printLine(depth, s, "assign %s", getDeclarationReference(s.VariableDeclaration))
+		p.printExpression(s.Expression, depth+1)
+	case *parser.MultiAssignStatement:
+		refs := make([]string, 0, len(s.VariableDeclarations))
+		for _, d := range s.VariableDeclarations {
+			refs = append(refs, getDeclarationReference(d))
+		}
+		p.printLine(depth, s, "assign %s", strings.Join(refs, ", "))
+		p.printExpression(s.Expression, depth+1)
+	case *parser.DiscardStatement:
+		p.printLine(depth, s, "discard")
+		p.printExpression(s.Expression, depth+1)
+	case *parser.FieldAssignStatement:
+		p.printLine(depth, s, "assign-field")
+		p.printExpression(s.Target, depth+1)
+		p.printExpression(s.Expression, depth+1)
+	case *parser.IndexAssignStatement:
+		p.printLine(depth, s, "assign-index")
+		p.printExpression(s.Target, depth+1)
+		p.printExpression(s.Expression, depth+1)
+	case *parser.CompoundAssignStatement:
+		p.printLine(depth, s, "compound-assign %s %s", lexer.GetTokenTypeString(s.Operator), getDeclarationReference(s.GetVariableDeclaration()))
+		p.printExpression(s.Expression, depth+1)
+	case *parser.IncrementStatement:
+		p.printLine(depth, s, "increment %s", getDeclarationReference(s.VariableDeclaration))
+	case *parser.DecrementStatement:
+		p.printLine(depth, s, "decrement %s", getDeclarationReference(s.VariableDeclaration))
+	case *parser.IfStatement:
+		p.printLine(depth, s, "if")
+		p.printExpression(s.Condition, depth+1)
+		p.printBlock("then", s.ThenStatements, depth+1)
+		if len(s.ElseStatements) != 0 {
+			p.printBlock("else", s.ElseStatements, depth+1)
+		}
+	case *parser.ForStatement:
+		p.printLine(depth, s, "for%s", getLabelSuffix(s.Label))
+		if s.Init != nil {
+			p.printBlock("init", []parser.Statement{s.Init}, depth+1)
+		}
+		if s.Condition != nil {
+			p.printExpression(s.Condition, depth+1)
+		}
+		if s.LoopAction != nil {
+			p.printBlock("action", []parser.Statement{s.LoopAction}, depth+1)
+		}
+		p.printBlock("do", s.Statements, depth+1)
+	case *parser.WhileStatement:
+		p.printLine(depth, s, "while%s", getLabelSuffix(s.Label))
+		p.printExpression(s.Condition, depth+1)
+		p.printBlock("do", s.Statements, depth+1)
+	case *parser.BreakStatement:
+		p.printLine(depth, s, "break%s%s", getLabelSuffix(s.Label), getLoopReference(s.Loop))
+	case *parser.ContinueStatement:
+		p.printLine(depth, s, "continue%s%s", getLabelSuffix(s.Label), getLoopReference(s.Loop))
+	case *parser.ReturnStatement:
+		p.printLine(depth, s, "return")
+		for _, exp := range s.ReturnExpressions {
+			p.printExpression(exp, depth+1)
+		}
+	case parser.Expression:
+		p.printExpression(s, depth)
+	default:
+		p.printLine(depth, s, "<unknown statement %T>", s)
+	}
+}
+
+func (p *ASTPrinter) printBlock(name string, statements []parser.Statement, depth int) {
+	p.printIndented(depth, name)
+	p.printStatements(statements, depth+1)
+}
+
+func (p *ASTPrinter) printExpression(expression parser.Expression, depth int) {
+	switch e := expression.(type) {
+	case *parser.IntegerLiteralExpression:
+		p.printExpressionLine(depth, e, "integer %d", e.Value)
+	case *parser.FloatLiteralExpression:
+		p.printExpressionLine(depth, e, "float %s", strconv.FormatFloat(e.Value, 'g', -1, 64))
+	case *parser.CharacterLiteralExpression:
+		p.printExpressionLine(depth, e, "character %s", strconv.QuoteRuneToASCII(e.Value))
+	case *parser.StringLiteralExpression:
+		p.printExpressionLine(depth, e, "string %s", strconv.QuoteToASCII(e.Value))
+	case *parser.BooleanLiteralExpression:
+		p.printExpressionLine(depth, e, "bool %t", e.Value)
+	case *parser.IdentifierExpression:
+		p.printExpressionLine(depth, e, "identifier %s", getDeclarationReference(e.IdentifierDeclaration))
+	case *parser.NotExpression:
+		p.printExpressionLine(depth, e, "not")
+		p.printExpression(e.Expression, depth+1)
+	case *parser.NegateExpression:
+		p.printExpressionLine(depth, e, "negate")
+		p.printExpression(e.Expression, depth+1)
+	case *parser.BitwiseNotExpression:
+		p.printExpressionLine(depth, e, "bitwise-not")
+		p.printExpression(e.Expression, depth+1)
+	case *parser.ConversionExpression:
+		p.printExpressionLine(depth, e, "convert %s", e.TypeDeclaration.Type.TypeName())
+		p.printExpression(e.Expression, depth+1)
+	case *parser.FieldAccessExpression:
+		p.printExpressionLine(depth, e, "field %s", e.FieldName)
+		p.printExpression(e.Expression, depth+1)
+	case *parser.StructLiteralExpression:
+		p.printExpressionLine(depth, e, "struct %s", e.TypeDeclaration.Type.TypeName())
+		for _, f := range e.Fields {
+			p.printLine(depth+1, f, "field %s", f.Name)
+			p.printExpression(f.Expression, depth+2)
+		}
+	case *parser.IndexExpression:
+		p.printExpressionLine(depth, e, "index")
+		p.printExpression(e.Expression, depth+1)
+		p.printExpression(e.Index, depth+1)
+	case *parser.SliceExpression:
+		p.printExpressionLine(depth, e, "slice")
+		p.printExpression(e.Expression, depth+1)
+		if e.Low != nil {
+			p.printIndented(depth+1, "low")
+			p.printExpression(e.Low, depth+2)
+		}
+		if e.High != nil {
+			p.printIndented(depth+1, "high")
+			p.printExpression(e.High, depth+2)
+		}
+	case *parser.LenExpression:
+		p.printExpressionLine(depth, e, "len")
+		p.printExpression(e.Expression, depth+1)
+	case *parser.AppendExpression:
+		p.printExpressionLine(depth, e, "append")
+		p.printExpression(e.Slice, depth+1)
+		for _, element := range e.Elements {
+			p.printExpression(element, depth+1)
+		}
+	case *parser.FunctionCallExpression:
+		p.printExpressionLine(depth, e, "call")
+		p.printExpression(e.CallSource, depth+1)
+		for _, param := range e.Parameters {
+			p.printExpression(param, depth+1)
+		}
+	case *parser.FunctionLiteralExpression:
+		p.printExpressionLine(depth, e, "func%s", getFunctionSignature(e.FunctionDefinition.FunctionType))
+		for _, varDecl := range e.Captures {
+			p.printIndented(depth+1, "capture "+getDeclarationReference(varDecl))
+		}
+		p.printStatements(e.FunctionDefinition.Statements, depth+1)
+	case *parser.AddExpression:
+		p.printDualInputExpression(depth, e, "add", e.Left, e.Right)
+	case *parser.SubtractExpression:
+		p.printDualInputExpression(depth, e, "subtract", e.Left, e.Right)
+	case *parser.MultiplyExpression:
+		p.printDualInputExpression(depth, e, "multiply", e.Left, e.Right)
+	case *parser.DivideExpression:
+		p.printDualInputExpression(depth, e, "divide", e.Left, e.Right)
+	case *parser.ModuloExpression:
+		p.printDualInputExpression(depth, e, "modulo", e.Left, e.Right)
+	case *parser.BitwiseAndExpression:
+		p.printDualInputExpression(depth, e, "bitwise-and", e.Left, e.Right)
+	case *parser.BitwiseOrExpression:
+		p.printDualInputExpression(depth, e, "bitwise-or", e.Left, e.Right)
+	case *parser.BitwiseXorExpression:
+		p.printDualInputExpression(depth, e, "bitwise-xor", e.Left, e.Right)
+	case *parser.ShiftLeftExpression:
+		p.printDualInputExpression(depth, e, "shift-left", e.Left, e.Right)
+	case *parser.ShiftRightExpression:
+		p.printDualInputExpression(depth, e, "shift-right", e.Left, e.Right)
+	case *parser.EqualExpression:
+		p.printDualInputExpression(depth, e, "equal", e.Left, e.Right)
+	case *parser.NotEqualExpression:
+		p.printDualInputExpression(depth, e, "not-equal", e.Left, e.Right)
+	case *parser.LessExpression:
+		p.printDualInputExpression(depth, e, "less", e.Left, e.Right)
+	case *parser.LessOrEqualExpression:
+		p.printDualInputExpression(depth, e, "less-or-equal", e.Left, e.Right)
+	case *parser.GreaterExpression:
+		p.printDualInputExpression(depth, e, "greater", e.Left, e.Right)
+	case *parser.GreaterOrEqualExpression:
+		p.printDualInputExpression(depth, e, "greater-or-equal", e.Left, e.Right)
+	case *parser.AndExpression:
+		p.printDualInputExpression(depth, e, "and", e.Left, e.Right)
+	case *parser.OrExpression:
+		p.printDualInputExpression(depth, e, "or", e.Left, e.Right)
+	default:
+		p.printExpressionLine(depth, e, "<unknown expression %T>", e)
+	}
+}
+
+func (p *ASTPrinter) printDualInputExpression(depth int, e parser.Expression, name string, left, right parser.Expression) {
+	p.printExpressionLine(depth, e, name)
+	p.printExpression(left, depth+1)
+	p.printExpression(right, depth+1)
+}
+
+func (p *ASTPrinter) printExpressionLine(depth int, e parser.Expression, format string, args ...interface{}) {
+	if !p.Types {
+		p.printLine(depth, e, format, args...)
+		return
+	}
+
+	tds, err := e.ResultingTypeDeclarations()
+	if err != nil {
+		p.setError(errors.Wrap(err, "cannot get resulting types of expression"))
+		return
+	}
+
+	// A call of a function without return values has no types to print.
+	if len(tds) == 0 {
+		p.printLine(depth, e, format, args...)
+		return
+	}
+
+	typeNames := make([]string, 0, len(tds))
+	for _, td := range tds {
+		typeNames = append(typeNames, td.Type.TypeName())
+	}
+
+	p.printLine(depth, e, format+" : %s", append(args, strings.Join(typeNames, ", "))...)
+}
+
+func (p *ASTPrinter) printLine(depth int, n parser.Node, format string, args ...interface{}) {
+	p.printIndented(depth, fmt.Sprintf(format, args...)+" "+getNodePosition(n))
+}
+
+func (p *ASTPrinter) printIndented(depth int, line string) {
+	if p.err != nil {
+		return
+	}
+
+	_, err := fmt.Fprintf(p.w, "%s%s\n", strings.Repeat("  ", depth), line)
+	p.setError(err)
+}
+
+func (p *ASTPrinter) setError(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func getNodePosition(n parser.Node) string {
+	return fmt.Sprintf("@%d:%d", n.UFSourceLine(), n.UFSourceColumn())
+}
+
+// getDeclarationReference describes the declaration an identifier resolved to.
+func getDeclarationReference(declaration parser.Declaration) string {
+	switch d := declaration.(type) {
+	case nil:
+		return "_"
+	case *parser.VariableDeclaration:
+		return fmt.Sprintf("%s -> var%s", d.Name, getNodePosition(d))
+	case *parser.FunctionDeclaration:
+		return fmt.Sprintf("%s -> func%s", d.Name, getNodePosition(d))
+	case *parser.UnknownDeclaration:
+		return fmt.Sprintf("%s -> unresolved", d.Identifier)
+	default:
+		return fmt.Sprintf("-> %s%s", declaration.DeclarationType(), getNodePosition(declaration))
+	}
+}
+
+func getLabelSuffix(label string) string {
+	if label == "" {
+		return ""
+	}
+
+	return " " + label
+}
+
+func getLoopReference(loop parser.Statement) string {
+	if loop == nil {
+		return " -> unresolved"
+	}
+
+	return " -> loop" + getNodePosition(loop)
+}
+
+func getFunctionSignature(t parser.FunctionType) string {
+	params := make([]string, 0, len(t.Parameters))
+	for _, f := range t.Parameters {
+		params = append(params, f.Name+" "+f.VariableDeclaration.TypeDeclaration.Type.TypeName())
+	}
+
+	returnTypes := make([]string, 0, len(t.ReturnTypes))
+	for _, f := range t.ReturnTypes {
+		returnTypes = append(returnTypes, f.VariableDeclaration.TypeDeclaration.Type.TypeName())
+	}
+
+	s := "(" + strings.Join(params, ", ") + ")"
+	if len(returnTypes) == 1 {
+		s += " " + returnTypes[0]
+	} else if len(returnTypes) > 1 {
+		s += " (" + strings.Join(returnTypes, ", ") + ")"
+	}
+
+	return s
+}
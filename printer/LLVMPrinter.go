@@ -3,24 +3,69 @@ package printer
 import (
 	"fmt"
 	"io"
+	"math/big"
 
 	"github.com/llir/llvm/ir/value"
 
 	"github.com/pkg/errors"
 
+	"github.com/milandamen/quisnix/lexer"
 	"github.com/milandamen/quisnix/parser"
 
 	"github.com/llir/llvm/ir"
 	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
 	"github.com/llir/llvm/ir/types"
 )
 
+// envParamName is the name of the first parameter of the code of a function value, which points to its captured
+// variables.
+const envParamName = "qx.env"
+
 type LLVMPrinter struct {
-	module *ir.Module
+	fileScope    *parser.FileScope
+	module       *ir.Module
+	runtimeFuncs map[string]*ir.Func
+	numStrings   int
+	globals      map[*parser.VariableDeclaration]*ir.Global
+	initFunc     *ir.Func // Computes the values of global variables that are not constant, nil when there are none.
+	loops        map[parser.Statement]*loopTargets
+
+	// The memory on the heap of the variables that function literals capture, by the function using them.
+	cells               map[*ir.Func]map[*parser.VariableDeclaration]value.Value
+	numFunctionLiterals map[*ir.Func]int
+	funcValues          map[*parser.FunctionDeclaration]*ir.Func // Called through the function values of declared functions.
+}
+
+// loopTargets are the blocks that the break and continue statements of a loop jump to, together with the jumps made to
+// them so far.
+type loopTargets struct {
+	vars          []*parser.VariableDeclaration // Variables from outside the loop that are assigned in it.
+	exitBlock     *ir.Block
+	continueBlock *ir.Block
+	breaks        []loopEdge
+	continues     []loopEdge
 }
 
-func (p *LLVMPrinter) Print(w io.Writer, declarations []parser.Declaration) error {
+// loopEdge is a jump from a block to a target of a loop, with the values of the variables of the loop at that moment.
+type loopEdge struct {
+	block *ir.Block
+	vals  map[*parser.VariableDeclaration]value.Value
+}
+
+// Print prints the declarations as LLVM IR. The file scope must have been analyzed, as the initialization order and the
+// constant values are taken from it.
+func (p *LLVMPrinter) Print(w io.Writer, declarations []parser.Declaration, fileScope *parser.FileScope) error {
+	p.fileScope = fileScope
 	p.module = ir.NewModule()
+	p.runtimeFuncs = make(map[string]*ir.Func)
+	p.numStrings = 0
+	p.globals = make(map[*parser.VariableDeclaration]*ir.Global)
+	p.initFunc = nil
+	p.loops = make(map[parser.Statement]*loopTargets)
+	p.cells = make(map[*ir.Func]map[*parser.VariableDeclaration]value.Value)
+	p.numFunctionLiterals = make(map[*ir.Func]int)
+	p.funcValues = make(map[*parser.FunctionDeclaration]*ir.Func)
 	funcList := make(map[*parser.FunctionDeclaration]*ir.Func)
 	for _, decl := range declarations {
 		switch d := decl.(type) {
@@ -29,13 +74,35 @@ func (p *LLVMPrinter) Print(w io.Writer, declarations []parser.Declaration) erro
 			if err != nil {
 				return errors.Wrapf(err, "cannot print function '%s'", d.Name)
 			}
+		case *parser.VariableDeclaration:
+			if d.Constant {
+				continue // Constants are printed as values where they are used.
+			}
+
+			err := p.addGlobalVariable(d)
+			if err != nil {
+				return errors.Wrapf(err, "cannot print variable '%s'", d.Name)
+			}
+		case *parser.TypeDeclaration:
+			// Types only describe the layout of values, so they are printed where they are used.
 		default:
 			return errors.New("unknown declaration type")
 		}
 	}
 
-	for funcDecl, f := range funcList {
-		err := p.addFunctionStatements(funcDecl, f, funcList)
+	if err := p.addInitFunction(funcList); err != nil {
+		return errors.Wrap(err, "cannot print initialization of global variables")
+	}
+
+	// Functions are printed in the order they were declared, so the runtime functions they use are added to the module
+	// in the same order every time.
+	for _, decl := range declarations {
+		funcDecl, ok := decl.(*parser.FunctionDeclaration)
+		if !ok {
+			continue
+		}
+
+		err := p.addFunctionStatements(funcDecl, funcList[funcDecl], funcList)
 		if err != nil {
 			return errors.Wrapf(err, "cannot print function '%s'", funcDecl.Name)
 		}
@@ -60,25 +127,7 @@ func (p *LLVMPrinter) Print(w io.Writer, declarations []parser.Declaration) erro
 }
 
 func (p *LLVMPrinter) addFunctionDeclaration(decl *parser.FunctionDeclaration, funcList map[*parser.FunctionDeclaration]*ir.Func) error {
-	retTypeFields := decl.FunctionDefinition.FunctionType.ReturnTypes
-	var retTypes []types.Type
-	for _, f := range retTypeFields {
-		typ, err := getLLVMType(f.VariableDeclaration.TypeDeclaration.Type)
-		if err != nil {
-			return errors.Wrap(err, "cannot not get LLVM type for return type")
-		}
-
-		retTypes = append(retTypes, typ)
-	}
-
-	var retType types.Type
-	if len(retTypes) == 1 {
-		retType = retTypes[0]
-	} else {
-		retType = types.Void
-	}
-
-	params, err := getLLVMFunctionParams(decl.FunctionDefinition.FunctionType.Parameters, retTypes)
+	retType, params, err := getLLVMFunctionSignature(decl.FunctionDefinition.FunctionType)
 	if err != nil {
 		return err
 	}
@@ -92,322 +141,2028 @@ func (p *LLVMPrinter) addFunctionDeclaration(decl *parser.FunctionDeclaration, f
 	return nil
 }
 
+// addGlobalVariable adds a global variable to the module. A constant value is stored in the module itself, other
+// values are computed by the init function.
+func (p *LLVMPrinter) addGlobalVariable(decl *parser.VariableDeclaration) error {
+	init, ok, err := p.getConstantValue(decl)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		zeroVal, err := p.getZeroValue(decl.TypeDeclaration.Type)
+		if err != nil {
+			return errors.Wrap(err, "cannot get zero value for variable")
+		}
+		init = zeroVal.(constant.Constant)
+	}
+
+	p.globals[decl] = p.module.NewGlobalDef("qx_uv_"+decl.Name, init)
+	return nil
+}
+
+// addInitFunction adds the function that computes the values of the global variables that are not constant, in
+// initialization order. The main function calls it before anything else.
+func (p *LLVMPrinter) addInitFunction(funcList map[*parser.FunctionDeclaration]*ir.Func) error {
+	var b *ir.Block
+	for _, decl := range p.fileScope.InitializationOrder {
+		if decl.Constant || decl.Value == nil {
+			continue
+		}
+		if _, ok := p.fileScope.ConstantValues[decl]; ok {
+			continue // Already stored in the module.
+		}
+
+		if p.initFunc == nil {
+			p.initFunc = p.module.NewFunc("qx.init", types.Void)
+			p.initFunc.Linkage = enum.LinkageInternal
+			b = p.initFunc.NewBlock("")
+		}
+
+		noVars := make(map[*parser.VariableDeclaration]value.Value)
+		b, vals, err := p.getExpressionValues(b, decl.Value, noVars, noVars, noVars, funcList)
+		if err != nil {
+			return errors.Wrapf(err, "cannot print value of variable '%s'", decl.Name)
+		}
+
+		b.NewStore(vals[0], p.globals[decl])
+	}
+
+	if b != nil {
+		b.NewRet(nil)
+	}
+
+	return nil
+}
+
 func (p *LLVMPrinter) addFunctionStatements(decl *parser.FunctionDeclaration, f *ir.Func, funcList map[*parser.FunctionDeclaration]*ir.Func) error {
 	b := f.NewBlock("")
+	if decl.Name == "main" && p.initFunc != nil {
+		b.NewCall(p.initFunc)
+	}
+
+	return p.addFunctionBody(b, decl.FunctionDefinition, funcList)
+}
 
-	variableScope, err := getFuncVariableScope(decl.FunctionDefinition.FunctionType.Parameters, f.Params)
+// addFunctionBody adds the statements of the function definition to the function of the block, starting in the block.
+func (p *LLVMPrinter) addFunctionBody(b *ir.Block, def *parser.FunctionDefinition, funcList map[*parser.FunctionDeclaration]*ir.Func) error {
+	f := b.Parent
+	variableScope, err := getFuncVariableScope(def.FunctionType.Parameters, f.Params)
 	if err != nil {
 		return err
 	}
 
-	overwrittenVars, err := p.addStatements(b, decl.FunctionDefinition.Statements, variableScope, funcList)
+	for _, param := range def.FunctionType.Parameters {
+		if varDecl := param.VariableDeclaration; varDecl.Captured || varDecl.Addressed {
+			p.addCell(b, varDecl, variableScope[varDecl])
+			delete(variableScope, varDecl)
+		}
+	}
+
+	b, _, err = p.addStatements(b, def.Statements, variableScope, funcList)
 	if err != nil {
 		return err
 	}
 
-	_ = overwrittenVars // TODO use PHI with overwritten vars
-
-	// When to allocate on the heap instead of the stack:
-	//  1. When the lifetime of the value exceeds the current function
-	//  2. When the value can grow (arrays), put the whole struct on the heap, and return pointer to value (like append() in Go)
+	if b.Term == nil {
+		if f.Sig.RetType.Equal(types.Void) {
+			b.NewRet(nil)
+		} else {
+			b.NewUnreachable()
+		}
+	}
 
 	return nil
 }
 
+// addStatements adds the statements to the given block and returns the block where execution continues, together with
+// the new values of the variables from outside the statements that were assigned.
 func (p *LLVMPrinter) addStatements(b *ir.Block,
 	statements []parser.Statement,
 	outsideScopeVars map[*parser.VariableDeclaration]value.Value,
-	funcList map[*parser.FunctionDeclaration]*ir.Func) (map[*parser.VariableDeclaration]value.Value, error) {
+	funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, map[*parser.VariableDeclaration]value.Value, error) {
 
 	overwrittenVars := make(map[*parser.VariableDeclaration]value.Value)
 	scope := make(map[*parser.VariableDeclaration]value.Value)
-	// TODO use PHI with overwritten vars
 
 	for _, statement := range statements {
-		if stmtHVarDecl, ok := statement.(parser.StatementHavingVariableDeclaration); ok {
-			decl := stmtHVarDecl.GetVariableDeclaration()
-			varDecl, ok2 := decl.(*parser.VariableDeclaration)
-			if !ok2 {
-				return nil, errors.New("compiler error: statement having declaration is not a variable declaration")
+		var err error
+		b, err = p.addStatement(b, statement, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return b, overwrittenVars, nil
+}
+
+func (p *LLVMPrinter) addStatement(b *ir.Block, statement parser.Statement, scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value, funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, error) {
+
+	if stmtHVarDecl, ok := statement.(parser.StatementHavingVariableDeclaration); ok {
+		decl := stmtHVarDecl.GetVariableDeclaration()
+		varDecl, ok2 := decl.(*parser.VariableDeclaration)
+		if !ok2 {
+			return nil, errors.New("compiler error: statement having declaration is not a variable declaration")
+		}
+		var varVal value.Value
+		var err error
+		ptr, inMemory := p.getVariablePointer(b, varDecl)
+		if inMemory {
+			// A variable in memory is only loaded when its current value is used.
+			switch statement.(type) {
+			case *parser.IncrementStatement, *parser.DecrementStatement:
+				varVal = b.NewLoad(ptr.Type().(*types.PointerType).ElemType, ptr)
 			}
-			varVal, inScope, err := p.getScopeVariableValue(varDecl, scope, overwrittenVars, outsideScopeVars)
+		} else {
+			varVal, _, err = p.getScopeVariableValue(varDecl, scope, overwrittenVars, outsideScopeVars)
 			if err != nil {
 				return nil, err
 			}
+		}
 
-			var newVal value.Value
-			var vals []value.Value
-			switch s := statement.(type) {
-			case *parser.AssignStatement:
-				vals, err = p.getExpressionValues(b, s.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
-				if err != nil {
-					return nil, err
-				}
-				if len(vals) != 1 {
-					return nil, errors.New("compiler error: resulting expression values must have len 1")
-				}
-
-				newVal = vals[0]
-			case *parser.AddAssignStatement:
-				vals, err = p.getExpressionValues(b, s.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
-				if err != nil {
-					return nil, err
-				}
-				if len(vals) != 1 {
-					return nil, errors.New("compiler error: resulting expression values must have len 1")
-				}
-
-				newVal = b.NewAdd(varVal, vals[0])
-			case *parser.SubtractAssignStatement:
-				vals, err = p.getExpressionValues(b, s.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
-				if err != nil {
-					return nil, err
-				}
-				if len(vals) != 1 {
-					return nil, errors.New("compiler error: resulting expression values must have len 1")
-				}
-
-				newVal = b.NewSub(varVal, vals[0])
-			case *parser.IncrementStatement:
-				newVal = b.NewAdd(varVal, constant.NewInt(types.I32, 1))
-			case *parser.DecrementStatement:
-				newVal = b.NewSub(varVal, constant.NewInt(types.I32, 1))
-			default:
-				return nil, errors.New("compiler error: unknown statement")
+		var newVal value.Value
+		var vals []value.Value
+		switch s := statement.(type) {
+		case *parser.AssignStatement:
+			b, vals, err = p.getExpressionValues(b, s.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, err
+			}
+			if len(vals) != 1 {
+				return nil, errors.New("compiler error: resulting expression values must have len 1")
 			}
 
-			if inScope {
-				scope[varDecl] = newVal
-			} else {
-				overwrittenVars[varDecl] = newVal
+			newVal = vals[0]
+		case *parser.FieldAssignStatement:
+			b, vals, err = p.getExpressionValues(b, s.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, err
+			}
+			if len(vals) != 1 {
+				return nil, errors.New("compiler error: resulting expression values must have len 1")
 			}
-		} else if stmt, ok := statement.(*parser.ReturnStatement); ok {
-			if len(stmt.ReturnExpressions) == 1 {
-				vals, err := p.getExpressionValues(b, stmt.ReturnExpressions[0], scope, overwrittenVars, outsideScopeVars, funcList)
-				if err != nil {
-					return nil, err
-				}
-				if len(vals) != 1 {
-					return nil, errors.New("compiler error: resulting expression values must have len 1")
-				}
 
-				b.NewRet(vals[0])
-			} else {
-				return nil, errors.New("multiple return values not yet supported")
+			b, newVal, err = p.getAssignedValue(b, s.Target, vals[0], scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, err
 			}
-		} else if stmt, ok := statement.(*parser.VariableDeclaration); ok {
-			if _, ok2 := stmt.TypeDeclaration.Type.(parser.BasicType); !ok2 {
-				return nil, errors.New("declaring a non-basic variable is not yet supported")
+		case *parser.IndexAssignStatement:
+			b, vals, err = p.getExpressionValues(b, s.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, err
+			}
+			if len(vals) != 1 {
+				return nil, errors.New("compiler error: resulting expression values must have len 1")
 			}
 
-			zeroVal, err := p.getZeroValue(stmt.TypeDeclaration.Type)
+			b, newVal, err = p.getAssignedValue(b, s.Target, vals[0], scope, overwrittenVars, outsideScopeVars, funcList)
 			if err != nil {
-				return nil, errors.Wrap(err, "cannot get zero value for variable")
+				return nil, err
 			}
-			scope[stmt] = zeroVal
-		} else {
-			return nil, errors.New("compiler error: unsupported statement")
+		case *parser.CompoundAssignStatement:
+			b, vals, err = p.getExpressionValues(b, s.Operation(), scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, err
+			}
+			if len(vals) != 1 {
+				return nil, errors.New("compiler error: resulting expression values must have len 1")
+			}
+
+			newVal = vals[0]
+		case *parser.IncrementStatement:
+			newVal = b.NewAdd(varVal, constant.NewInt(types.I32, 1))
+		case *parser.DecrementStatement:
+			newVal = b.NewSub(varVal, constant.NewInt(types.I32, 1))
+		default:
+			return nil, errors.New("compiler error: unknown statement")
 		}
-	}
 
-	return overwrittenVars, nil
-}
+		if newVal == nil {
+			return b, nil // Only an element on the heap was changed.
+		}
 
-func (p *LLVMPrinter) getScopeVariableValue(varDecl *parser.VariableDeclaration, scope, overwrittenVars,
-	outsideScopeVars map[*parser.VariableDeclaration]value.Value) (value.Value, bool, error) {
+		if inMemory {
+			b.NewStore(newVal, ptr)
+		} else {
+			setVariableValue(varDecl, newVal, scope, overwrittenVars)
+		}
+	} else if stmt, ok := statement.(*parser.ReturnStatement); ok {
+		var retVals []value.Value
+		for _, exp := range stmt.ReturnExpressions {
+			var vals []value.Value
+			var err error
+			b, vals, err = p.getExpressionValues(b, exp, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, err
+			}
+			retVals = append(retVals, vals...)
+		}
 
-	varVal, inScope := scope[varDecl]
-	if !inScope {
-		var ok3 bool
-		varVal, ok3 = overwrittenVars[varDecl]
-		if !ok3 {
-			var ok4 bool
-			varVal, ok4 = outsideScopeVars[varDecl]
-			if !ok4 {
-				return nil, false, errors.New("compiler error: variable declaration not in scope")
+		switch len(retVals) {
+		case 0:
+			b.NewRet(nil)
+		case 1:
+			b.NewRet(retVals[0])
+		default:
+			// The values are written to the memory that the pointers in the first parameters point to.
+			retParams := getMultipleReturnParams(b.Parent)
+			for i, val := range retVals {
+				b.NewStore(val, retParams[i])
 			}
+			b.NewRet(nil)
+		}
+	} else if stmt, ok := statement.(*parser.MultiAssignStatement); ok {
+		var vals []value.Value
+		var err error
+		b, vals, err = p.getExpressionValues(b, stmt.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) != len(stmt.VariableDeclarations) {
+			return nil, errors.New("compiler error: resulting expression values must have the number of variables")
 		}
-	}
 
-	return varVal, inScope, nil
-}
+		for i, decl := range stmt.VariableDeclarations {
+			if decl == nil {
+				continue // The value is discarded.
+			}
 
-func (p *LLVMPrinter) getExpressionValues(b *ir.Block, expression parser.Expression, scope, overwrittenVars,
-	outsideScopeVars map[*parser.VariableDeclaration]value.Value, funcList map[*parser.FunctionDeclaration]*ir.Func) ([]value.Value, error) {
+			varDecl, ok := decl.(*parser.VariableDeclaration)
+			if !ok {
+				return nil, errors.New("compiler error: statement having declaration is not a variable declaration")
+			}
 
-	switch exp := expression.(type) {
-	case *parser.IntegerLiteralExpression:
-		val := constant.NewInt(types.I32, int64(exp.Value)) // TODO find out how to use other bit sizes.
-		return []value.Value{val}, nil
-	case *parser.IdentifierExpression:
-		val, _, err := p.getScopeVariableValue(exp.IdentifierDeclaration.(*parser.VariableDeclaration), scope, overwrittenVars, outsideScopeVars)
+			if ptr, inMemory := p.getVariablePointer(b, varDecl); inMemory {
+				b.NewStore(vals[i], ptr)
+			} else {
+				setVariableValue(varDecl, vals[i], scope, overwrittenVars)
+			}
+		}
+	} else if stmt, ok := statement.(*parser.DiscardStatement); ok {
+		var err error
+		b, _, err = p.getExpressionValues(b, stmt.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
 		if err != nil {
 			return nil, err
 		}
-		return []value.Value{val}, nil
-	case *parser.AddExpression:
-		val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+	} else if stmt, ok := statement.(*parser.FunctionCallExpression); ok {
+		var err error
+		b, _, err = p.getExpressionValues(b, stmt, scope, overwrittenVars, outsideScopeVars, funcList)
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Left")
+			return nil, err
 		}
-		val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Right")
+	} else if stmt, ok := statement.(*parser.BreakStatement); ok {
+		targets, ok := p.loops[stmt.Loop]
+		if !ok {
+			return nil, errors.New("compiler error: break statement outside of its loop")
 		}
 
-		add := b.NewAdd(val1[0], val2[0]) // TODO what if adding strings?
-		return []value.Value{add}, nil
-	case *parser.SubtractExpression:
-		val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+		edge, err := p.getLoopEdge(b, targets, scope, overwrittenVars, outsideScopeVars)
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Left")
+			return nil, err
 		}
-		val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Right")
+		targets.breaks = append(targets.breaks, edge)
+		b.NewBr(targets.exitBlock)
+	} else if stmt, ok := statement.(*parser.ContinueStatement); ok {
+		targets, ok := p.loops[stmt.Loop]
+		if !ok {
+			return nil, errors.New("compiler error: continue statement outside of its loop")
 		}
 
-		sub := b.NewSub(val1[0], val2[0])
-		return []value.Value{sub}, nil
-	case *parser.MultiplyExpression:
-		val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+		edge, err := p.getLoopEdge(b, targets, scope, overwrittenVars, outsideScopeVars)
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Left")
+			return nil, err
 		}
-		val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
-		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Right")
+		targets.continues = append(targets.continues, edge)
+		b.NewBr(targets.continueBlock)
+	} else if stmt, ok := statement.(*parser.VariableDeclaration); ok {
+		if stmt.Value == nil {
+			zeroVal, err := p.getZeroValue(stmt.TypeDeclaration.Type)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot get zero value for variable")
+			}
+			p.setDeclaredVariableValue(b, stmt, zeroVal, scope)
+			return b, nil
 		}
 
-		mul := b.NewMul(val1[0], val2[0])
-		return []value.Value{mul}, nil
-	case *parser.DivideExpression:
-		val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+		var vals []value.Value
+		var err error
+		b, vals, err = p.getExpressionValues(b, stmt.Value, scope, overwrittenVars, outsideScopeVars, funcList)
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Left")
+			return nil, errors.Wrapf(err, "cannot print value of variable '%s'", stmt.Name)
 		}
-		val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
+		if len(vals) != 1 {
+			return nil, errors.New("compiler error: resulting expression values must have len 1")
+		}
+		p.setDeclaredVariableValue(b, stmt, vals[0], scope)
+	} else if _, ok := statement.(*parser.TypeDeclaration); ok {
+		// Types only describe the layout of values, so they are printed where they are used.
+	} else if stmt, ok := statement.(*parser.IfStatement); ok {
+		return p.addIfStatement(b, stmt, scope, overwrittenVars, outsideScopeVars, funcList)
+	} else if stmt, ok := statement.(*parser.ForStatement); ok {
+		return p.addForStatement(b, stmt, scope, overwrittenVars, outsideScopeVars, funcList)
+	} else if stmt, ok := statement.(*parser.WhileStatement); ok {
+		loopVars := getVisibleVariables(scope, overwrittenVars, outsideScopeVars)
+		b, loopOverwrittenVars, err := p.addLoop(b, stmt, stmt.Condition, stmt.Statements, nil, loopVars, funcList)
 		if err != nil {
-			return nil, errors.Wrap(err, "cannot 'add' with Right")
+			return nil, err
 		}
 
-		mul := b.NewSDiv(val1[0], val2[0]) // TODO division by zero causes undefined behavior, so code must assert error
-		return []value.Value{mul}, nil
-	case *parser.FunctionCallExpression:
-		switch idExp := exp.CallSource.(type) {
-		case *parser.IdentifierExpression:
-			switch funcDecl := idExp.IdentifierDeclaration.(type) {
-			case *parser.FunctionDeclaration:
-				f, ok := funcList[funcDecl]
-				if !ok {
-					return nil, errors.New("compiler error: function not found for value of exp.CallSource.IdentifierDeclaration")
-				}
+		for varDecl, val := range loopOverwrittenVars {
+			setVariableValue(varDecl, val, scope, overwrittenVars)
+		}
+		return b, nil
+	} else {
+		return nil, errors.New("compiler error: unsupported statement")
+	}
 
-				params := make([]value.Value, len(exp.Parameters))
-				for i, paramExp := range exp.Parameters {
-					val, err := p.getExpressionValues(b, paramExp, scope, overwrittenVars, outsideScopeVars, funcList)
-					if err != nil {
-						return nil, errors.Wrapf(err, "cannot parse parameter at index %d", i)
-					}
-					params[i] = val[0] // TODO support multiple return values
-				}
+	return b, nil
+}
 
-				call := b.NewCall(f, params...)
-				returnTypes := funcDecl.FunctionDefinition.FunctionType.ReturnTypes
-				if len(returnTypes) == 0 {
-					call.Typ = types.Void
-				} else if len(returnTypes) == 1 {
-					switch t := returnTypes[0].VariableDeclaration.TypeDeclaration.Type.(type) {
-					case parser.BasicType:
-						switch t.DataType {
-						case parser.IntDataType:
-							call.Typ = types.I32
-						default:
-							return nil, errors.Errorf("compiler error: basic data type '%d' is not implemented", t.DataType)
-						}
-					default:
-						return nil, errors.New("compiler error: unsupported function return type")
-					}
-				} else {
-					return nil, errors.New("Returning multiple values from a function is not yet supported")
-				}
+// addIfStatement adds an if statement, with a block for each branch that both continue in a new block, unless every
+// branch returns. Every variable from outside the statement that is assigned in a branch gets a phi node in that new
+// block, merging the values at the end of the branches that reach it.
+func (p *LLVMPrinter) addIfStatement(b *ir.Block, stmt *parser.IfStatement, scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value, funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, error) {
 
-				return []value.Value{call}, nil
-			case *parser.VariableDeclaration:
-				return nil, errors.New("calling a function in a variable is not yet supported")
-			default:
-				return nil, errors.New("compiler error: unsupported exp.CallSource.IdentifierDeclaration")
-			}
-		case *parser.FunctionCallExpression:
-			return nil, errors.New("calling a function resulting from the call of a function is not yet supported")
-		default:
-			return nil, errors.New("compiler error: unsupported exp.CallSource")
+	b, vals, err := p.getExpressionValues(b, stmt.Condition, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot print if condition")
+	}
+
+	f := b.Parent
+	branchVars := getVisibleVariables(scope, overwrittenVars, outsideScopeVars)
+	thenBlock := f.NewBlock("")
+	thenEnd, thenOverwrittenVars, err := p.addStatements(thenBlock, stmt.ThenStatements, branchVars, funcList)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without else statements, the condition being false jumps straight to the merge block.
+	elseBlock, elseEnd := b, b
+	elseOverwrittenVars := make(map[*parser.VariableDeclaration]value.Value)
+	if len(stmt.ElseStatements) > 0 {
+		elseBlock = f.NewBlock("")
+		elseEnd, elseOverwrittenVars, err = p.addStatements(elseBlock, stmt.ElseStatements, branchVars, funcList)
+		if err != nil {
+			return nil, err
 		}
-	default:
-		return nil, errors.New("compiler error: unsupported expression type")
 	}
-}
 
-func (p *LLVMPrinter) getZeroValue(typ parser.Type) (value.Value, error) {
-	switch t := typ.(type) {
-	case parser.BasicType:
-		switch t.DataType {
-		case parser.IntDataType:
-			return constant.NewInt(types.I32, 0), nil
-		default:
-			return nil, errors.Errorf("compiler error: basic data type '%d' is not implemented", t.DataType)
+	type branchEnd struct {
+		block           *ir.Block
+		overwrittenVars map[*parser.VariableDeclaration]value.Value
+	}
+	var ends []branchEnd
+	for _, end := range []branchEnd{{thenEnd, thenOverwrittenVars}, {elseEnd, elseOverwrittenVars}} {
+		if end.block.Term == nil || end.block == b {
+			ends = append(ends, end)
 		}
-	default:
-		return nil, errors.New("type is unsupported")
 	}
-}
 
-func getLLVMFunctionParams(parameters []*parser.Field, returnTypes []types.Type) ([]*ir.Param, error) {
-	var params []*ir.Param
-	if len(returnTypes) > 1 {
-		for i, t := range returnTypes {
-			var pt *types.PointerType
-			if ppt, ok := t.(*types.PointerType); ok {
-				pt = ppt
-			} else {
-				pt = types.NewPointer(t)
+	// When every branch returns, execution does not continue after the statement.
+	if len(ends) == 0 {
+		b.NewCondBr(vals[0], thenBlock, elseBlock)
+		return thenEnd, nil
+	}
+
+	mergeBlock := f.NewBlock("")
+	if elseBlock == b {
+		b.NewCondBr(vals[0], thenBlock, mergeBlock)
+	} else {
+		b.NewCondBr(vals[0], thenBlock, elseBlock)
+	}
+
+	for _, end := range ends {
+		if end.block != b {
+			end.block.NewBr(mergeBlock)
+		}
+	}
+
+	for _, varDecl := range getAssignedVariables([]parser.Statement{stmt}) {
+		before, ok := branchVars[varDecl]
+		if !ok {
+			continue // Declared inside a branch, or a global variable.
+		}
+
+		incs := make([]*ir.Incoming, 0, len(ends))
+		for _, end := range ends {
+			val, ok := end.overwrittenVars[varDecl]
+			if !ok {
+				val = before
 			}
+			incs = append(incs, ir.NewIncoming(val, end.block))
+		}
 
-			params = append(params, ir.NewParam(fmt.Sprintf("qx.mulret.%d", i), pt))
+		switch {
+		case len(incs) == 1 || incs[0].X == incs[1].X:
+			setVariableValue(varDecl, incs[0].X, scope, overwrittenVars)
+		default:
+			setVariableValue(varDecl, mergeBlock.NewPhi(incs...), scope, overwrittenVars)
 		}
 	}
 
-	for _, f := range parameters {
-		typ, err := getLLVMType(f.VariableDeclaration.TypeDeclaration.Type)
+	return mergeBlock, nil
+}
+
+// addForStatement adds a for loop. The init statement has a scope of its own that also contains the loop.
+func (p *LLVMPrinter) addForStatement(b *ir.Block, stmt *parser.ForStatement, scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value, funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, error) {
+
+	forOutsideScopeVars := getVisibleVariables(scope, overwrittenVars, outsideScopeVars)
+	forScope := make(map[*parser.VariableDeclaration]value.Value)
+	forOverwrittenVars := make(map[*parser.VariableDeclaration]value.Value)
+	if stmt.Init != nil {
+		var err error
+		b, err = p.addStatement(b, stmt.Init, forScope, forOverwrittenVars, forOutsideScopeVars, funcList)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot parse type of parameter '%s'", f.Name)
+			return nil, errors.Wrap(err, "cannot print for statement init")
 		}
+	}
 
-		params = append(params, ir.NewParam(f.Name, typ))
+	loopVars := getVisibleVariables(forScope, forOverwrittenVars, forOutsideScopeVars)
+	b, loopOverwrittenVars, err := p.addLoop(b, stmt, stmt.Condition, stmt.Statements, stmt.LoopAction, loopVars, funcList)
+	if err != nil {
+		return nil, err
 	}
 
-	return params, nil
+	for varDecl, val := range loopOverwrittenVars {
+		if _, inForScope := forScope[varDecl]; !inForScope {
+			forOverwrittenVars[varDecl] = val
+		}
+	}
+	for varDecl, val := range forOverwrittenVars {
+		setVariableValue(varDecl, val, scope, overwrittenVars)
+	}
+
+	return b, nil
 }
 
-func getFuncVariableScope(parameters []*parser.Field, irParams []*ir.Param) (map[*parser.VariableDeclaration]value.Value, error) {
-	scope := make(map[*parser.VariableDeclaration]value.Value)
-	for i, f := range parameters {
-		scope[f.VariableDeclaration] = irParams[i]
+// addLoop adds a loop that runs the statements followed by the action for as long as the condition is true, or forever
+// when there is no condition. Every variable from outside the loop that is assigned in the loop gets a phi node at the
+// start of the loop, merging the value from before the loop with the value at the end of an iteration. Those phi nodes
+// are the values of the variables after the loop, unless break statements leave the loop with other values.
+func (p *LLVMPrinter) addLoop(b *ir.Block, loop parser.Statement, condition parser.Expression, statements []parser.Statement,
+	action parser.Statement, outsideScopeVars map[*parser.VariableDeclaration]value.Value,
+	funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, map[*parser.VariableDeclaration]value.Value, error) {
+
+	f := b.Parent
+	condBlock := f.NewBlock("")
+	b.NewBr(condBlock)
+
+	// The targets are only added to the function after the statements, so the blocks stay in the order they run in.
+	targets := &loopTargets{exitBlock: newDetachedBlock(f), continueBlock: condBlock}
+	if action != nil {
+		// The action runs before the next iteration, so a continue statement jumps to a block running the action.
+		targets.continueBlock = newDetachedBlock(f)
 	}
+	p.loops[loop] = targets
+	defer delete(p.loops, loop)
 
-	return scope, nil
-}
+	loopVars := getVisibleVariables(nil, nil, outsideScopeVars)
+	phis := make(map[*parser.VariableDeclaration]*ir.InstPhi)
+	phiVals := make(map[*parser.VariableDeclaration]value.Value)
+	for _, varDecl := range getAssignedVariables(append([]parser.Statement{action}, statements...)) {
+		val, ok := outsideScopeVars[varDecl]
+		if !ok {
+			continue // Declared inside the loop.
+		}
 
-func getLLVMType(typ parser.Type) (types.Type, error) {
-	switch t := typ.(type) {
-	case parser.BasicType:
-		switch t.DataType {
-		case parser.IntDataType:
-			return types.I32, nil
+		phi := condBlock.NewPhi(ir.NewIncoming(val, b))
+		phis[varDecl] = phi
+		phiVals[varDecl] = phi
+		loopVars[varDecl] = phi
+		targets.vars = append(targets.vars, varDecl)
+	}
+
+	// The condition can continue in another block when it needs to jump, like for &&.
+	condEnd := condBlock
+	var condVal value.Value
+	if condition != nil {
+		var vals []value.Value
+		var err error
+		condEnd, vals, err = p.getExpressionValues(condBlock, condition, nil, nil, loopVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot print loop condition")
+		}
+		condVal = vals[0]
+	}
+
+	bodyBlock := f.NewBlock("")
+	bodyEnd, bodyOverwrittenVars, err := p.addStatements(bodyBlock, statements, loopVars, funcList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if targets.continueBlock != condBlock && len(targets.continues) > 0 {
+		// The end of the body continues like the continue statements, in the block running the action.
+		edges := targets.continues
+		if bodyEnd.Term == nil {
+			edge, err := p.getLoopEdge(bodyEnd, targets, nil, bodyOverwrittenVars, loopVars)
+			if err != nil {
+				return nil, nil, err
+			}
+			bodyEnd.NewBr(targets.continueBlock)
+			edges = append(edges, edge)
+		}
+
+		appendBlock(f, targets.continueBlock)
+		bodyEnd, bodyOverwrittenVars = targets.continueBlock, mergeLoopEdges(targets.continueBlock, targets.vars, edges)
+	} else {
+		for _, edge := range targets.continues {
+			for varDecl, phi := range phis {
+				phi.Incs = append(phi.Incs, ir.NewIncoming(edge.vals[varDecl], edge.block))
+			}
+		}
+	}
+
+	if bodyEnd.Term == nil {
+		if action != nil {
+			bodyEnd, err = p.addStatement(bodyEnd, action, make(map[*parser.VariableDeclaration]value.Value), bodyOverwrittenVars, loopVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "cannot print loop action")
+			}
+		}
+
+		bodyEnd.NewBr(condBlock)
+		for varDecl, phi := range phis {
+			val, ok := bodyOverwrittenVars[varDecl]
+			if !ok {
+				val = phi
+			}
+			phi.Incs = append(phi.Incs, ir.NewIncoming(val, bodyEnd))
+		}
+	}
+
+	appendBlock(f, targets.exitBlock)
+	if condVal != nil {
+		condEnd.NewCondBr(condVal, bodyBlock, targets.exitBlock)
+	} else {
+		condEnd.NewBr(bodyBlock)
+	}
+
+	if len(targets.breaks) == 0 {
+		return targets.exitBlock, phiVals, nil
+	}
+
+	// Break statements leave the loop with the values the variables have at that moment.
+	var edges []loopEdge
+	if condVal != nil {
+		edges = append(edges, loopEdge{block: condEnd, vals: phiVals})
+	}
+	edges = append(edges, targets.breaks...)
+
+	return targets.exitBlock, mergeLoopEdges(targets.exitBlock, targets.vars, edges), nil
+}
+
+// getLoopEdge returns the jump from the block to a target of the loop, with the current values of the variables of the
+// loop.
+func (p *LLVMPrinter) getLoopEdge(b *ir.Block, targets *loopTargets, scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value) (loopEdge, error) {
+
+	vals := make(map[*parser.VariableDeclaration]value.Value, len(targets.vars))
+	for _, varDecl := range targets.vars {
+		val, _, err := p.getScopeVariableValue(varDecl, scope, overwrittenVars, outsideScopeVars)
+		if err != nil {
+			return loopEdge{}, err
+		}
+		vals[varDecl] = val
+	}
+
+	return loopEdge{block: b, vals: vals}, nil
+}
+
+// mergeLoopEdges returns the values of the variables in the block that the jumps go to. A variable that has different
+// values on the jumps gets a phi node.
+func mergeLoopEdges(b *ir.Block, vars []*parser.VariableDeclaration, edges []loopEdge) map[*parser.VariableDeclaration]value.Value {
+	vals := make(map[*parser.VariableDeclaration]value.Value, len(vars))
+	for _, varDecl := range vars {
+		incs := make([]*ir.Incoming, 0, len(edges))
+		same := true
+		for _, edge := range edges {
+			incs = append(incs, ir.NewIncoming(edge.vals[varDecl], edge.block))
+			same = same && edge.vals[varDecl] == incs[0].X
+		}
+
+		if same {
+			vals[varDecl] = incs[0].X
+		} else {
+			vals[varDecl] = b.NewPhi(incs...)
+		}
+	}
+
+	return vals
+}
+
+// newDetachedBlock returns a block of the function that is not added to it yet, so that it can be jumped to before
+// appendBlock adds it.
+func newDetachedBlock(f *ir.Func) *ir.Block {
+	block := ir.NewBlock("")
+	block.Parent = f
+	return block
+}
+
+func appendBlock(f *ir.Func, block *ir.Block) {
+	f.Blocks = append(f.Blocks, block)
+}
+
+func (p *LLVMPrinter) getScopeVariableValue(varDecl *parser.VariableDeclaration, scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value) (value.Value, bool, error) {
+
+	varVal, inScope := scope[varDecl]
+	if !inScope {
+		var ok3 bool
+		varVal, ok3 = overwrittenVars[varDecl]
+		if !ok3 {
+			var ok4 bool
+			varVal, ok4 = outsideScopeVars[varDecl]
+			if !ok4 {
+				return nil, false, errors.New("compiler error: variable declaration not in scope")
+			}
+		}
+	}
+
+	return varVal, inScope, nil
+}
+
+func (p *LLVMPrinter) getExpressionValues(b *ir.Block, expression parser.Expression, scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value, funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, []value.Value, error) {
+
+	switch exp := expression.(type) {
+	case *parser.IntegerLiteralExpression:
+		tds, err := parser.MustSingleReturnType(exp)
+		if err != nil {
+			return nil, nil, err
+		}
+		typ, err := getLLVMType(tds[0].Type)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return b, []value.Value{constant.NewInt(typ.(*types.IntType), exp.Value.Int64())}, nil
+	case *parser.FloatLiteralExpression:
+		val := constant.NewFloat(types.Double, exp.Value)
+		return b, []value.Value{val}, nil
+	case *parser.CharacterLiteralExpression:
+		return b, []value.Value{constant.NewInt(types.I8, int64(exp.Value))}, nil
+	case *parser.BooleanLiteralExpression:
+		return b, []value.Value{constant.NewBool(exp.Value)}, nil
+	case *parser.IdentifierExpression:
+		if funcDecl, ok := exp.IdentifierDeclaration.(*parser.FunctionDeclaration); ok {
+			f, ok := funcList[funcDecl]
+			if !ok {
+				return nil, nil, errors.Errorf("compiler error: function '%s' not found", funcDecl.Name)
+			}
+
+			val, err := p.getFunctionValue(funcDecl, f)
+			if err != nil {
+				return nil, nil, err
+			}
+			return b, []value.Value{val}, nil
+		}
+
+		varDecl := exp.IdentifierDeclaration.(*parser.VariableDeclaration)
+		if varDecl.Constant {
+			val, _, err := p.getConstantValue(varDecl)
+			if err != nil {
+				return nil, nil, err
+			}
+			return b, []value.Value{val}, nil
+		}
+		if ptr, ok := p.getVariablePointer(b, varDecl); ok {
+			return b, []value.Value{b.NewLoad(ptr.Type().(*types.PointerType).ElemType, ptr)}, nil
+		}
+
+		val, _, err := p.getScopeVariableValue(varDecl, scope, overwrittenVars, outsideScopeVars)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, []value.Value{val}, nil
+	case *parser.AddExpression:
+		b, val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Left")
+		}
+		b, val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Right")
+		}
+
+		var add value.Value
+		if types.IsFloat(val1[0].Type()) {
+			add = b.NewFAdd(val1[0], val2[0])
+		} else {
+			add = b.NewAdd(val1[0], val2[0]) // TODO what if adding strings?
+		}
+		return b, []value.Value{add}, nil
+	case *parser.SubtractExpression:
+		b, val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Left")
+		}
+		b, val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Right")
+		}
+
+		var sub value.Value
+		if types.IsFloat(val1[0].Type()) {
+			sub = b.NewFSub(val1[0], val2[0])
+		} else {
+			sub = b.NewSub(val1[0], val2[0])
+		}
+		return b, []value.Value{sub}, nil
+	case *parser.MultiplyExpression:
+		b, val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Left")
+		}
+		b, val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Right")
+		}
+
+		var mul value.Value
+		if types.IsFloat(val1[0].Type()) {
+			mul = b.NewFMul(val1[0], val2[0])
+		} else {
+			mul = b.NewMul(val1[0], val2[0])
+		}
+		return b, []value.Value{mul}, nil
+	case *parser.DivideExpression:
+		b, val1, err := p.getExpressionValues(b, exp.Left, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Left")
+		}
+		b, val2, err := p.getExpressionValues(b, exp.Right, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'add' with Right")
+		}
+
+		tds, err := parser.MustSingleReturnType(exp)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var div value.Value
+		if types.IsFloat(val1[0].Type()) {
+			div = b.NewFDiv(val1[0], val2[0])
+		} else if isUnsignedType(tds[0].Type) {
+			div = b.NewUDiv(val1[0], val2[0]) // TODO division by zero causes undefined behavior, so code must assert error
+		} else {
+			div = b.NewSDiv(val1[0], val2[0]) // TODO division by zero causes undefined behavior, so code must assert error
+		}
+		return b, []value.Value{div}, nil
+	case *parser.ModuloExpression:
+		return p.getIntegerOperationValues(b, exp, exp.Left, exp.Right, lexer.Modulo, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.BitwiseAndExpression:
+		return p.getIntegerOperationValues(b, exp, exp.Left, exp.Right, lexer.BitwiseAnd, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.BitwiseOrExpression:
+		return p.getIntegerOperationValues(b, exp, exp.Left, exp.Right, lexer.BitwiseOr, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.BitwiseXorExpression:
+		return p.getIntegerOperationValues(b, exp, exp.Left, exp.Right, lexer.BitwiseXor, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.ShiftLeftExpression:
+		return p.getIntegerOperationValues(b, exp, exp.Left, exp.Right, lexer.ShiftLeft, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.ShiftRightExpression:
+		return p.getIntegerOperationValues(b, exp, exp.Left, exp.Right, lexer.ShiftRight, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.EqualExpression:
+		return p.getComparisonValues(b, exp.Left, exp.Right, enum.IPredEQ, enum.FPredOEQ, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.NotEqualExpression:
+		return p.getComparisonValues(b, exp.Left, exp.Right, enum.IPredNE, enum.FPredUNE, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.LessExpression:
+		return p.getComparisonValues(b, exp.Left, exp.Right, enum.IPredSLT, enum.FPredOLT, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.LessOrEqualExpression:
+		return p.getComparisonValues(b, exp.Left, exp.Right, enum.IPredSLE, enum.FPredOLE, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.GreaterExpression:
+		return p.getComparisonValues(b, exp.Left, exp.Right, enum.IPredSGT, enum.FPredOGT, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.GreaterOrEqualExpression:
+		return p.getComparisonValues(b, exp.Left, exp.Right, enum.IPredSGE, enum.FPredOGE, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.AndExpression:
+		return p.getShortCircuitValues(b, exp.Left, exp.Right, true, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.OrExpression:
+		return p.getShortCircuitValues(b, exp.Left, exp.Right, false, scope, overwrittenVars, outsideScopeVars, funcList)
+	case *parser.NotExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot 'not' expression")
+		}
+
+		return b, []value.Value{b.NewXor(vals[0], constant.True)}, nil
+	case *parser.NegateExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot negate expression")
+		}
+
+		if types.IsFloat(vals[0].Type()) {
+			return b, []value.Value{b.NewFNeg(vals[0])}, nil
+		}
+		return b, []value.Value{b.NewSub(constant.NewInt(vals[0].Type().(*types.IntType), 0), vals[0])}, nil
+	case *parser.BitwiseNotExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot flip bits of expression")
+		}
+
+		return b, []value.Value{b.NewXor(vals[0], constant.NewInt(vals[0].Type().(*types.IntType), -1))}, nil
+	case *parser.ConversionExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot convert expression")
+		}
+
+		fromTds, err := parser.MustSingleReturnType(exp.Expression)
+		if err != nil {
+			return nil, nil, err
+		}
+		val, err := getConversionValue(b, vals[0], fromTds[0].Type, exp.TypeDeclaration.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, []value.Value{val}, nil
+	case *parser.FieldAccessExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot access field '%s'", exp.FieldName)
+		}
+
+		i, _, err := exp.Field()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return b, []value.Value{b.NewExtractValue(vals[0], uint64(i))}, nil
+	case *parser.StructLiteralExpression:
+		st, ok := exp.TypeDeclaration.Type.(parser.StructType)
+		if !ok {
+			return nil, nil, errors.New("compiler error: struct literal does not have a struct type")
+		}
+
+		val, err := p.getZeroValue(st)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, f := range exp.Fields {
+			var fieldVals []value.Value
+			b, fieldVals, err = p.getExpressionValues(b, f.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "cannot print value of field '%s'", f.Name)
+			}
+
+			i, ok := st.FieldIndex(f.Name)
+			if !ok {
+				return nil, nil, errors.Errorf("compiler error: struct '%s' has no field '%s'", st.Name, f.Name)
+			}
+
+			val = b.NewInsertValue(val, fieldVals[0], uint64(i))
+		}
+
+		return b, []value.Value{val}, nil
+	case *parser.IndexExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot index expression")
+		}
+		b, indexVals, err := p.getExpressionValues(b, exp.Index, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot print index")
+		}
+
+		tds, err := parser.MustSingleReturnType(exp.Expression)
+		if err != nil {
+			return nil, nil, err
+		}
+		val, err := p.getElementValue(b, vals[0], tds[0].Type, indexVals[0], exp.UFSourceLine(), true)
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, []value.Value{val}, nil
+	case *parser.SliceExpression:
+		b, arrayPtr, err := p.getArrayPointer(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot slice expression")
+		}
+
+		var vals []value.Value
+		if arrayPtr == nil {
+			b, vals, err = p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "cannot slice expression")
+			}
+		}
+
+		var bounds [2]value.Value
+		for i, bound := range []parser.Expression{exp.Low, exp.High} {
+			if bound == nil {
+				continue
+			}
+
+			var boundVals []value.Value
+			b, boundVals, err = p.getExpressionValues(b, bound, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "cannot print slice bound")
+			}
+			bounds[i] = boundVals[0]
+		}
+
+		tds, err := parser.MustSingleReturnType(exp.Expression)
+		if err != nil {
+			return nil, nil, err
+		}
+		var val value.Value
+		if arrayPtr != nil {
+			val = p.getArraySliceValue(b, arrayPtr, tds[0].Type.(parser.ArrayType), bounds[0], bounds[1], exp.UFSourceLine())
+		} else {
+			val, err = p.getSliceValue(b, vals[0], tds[0].Type, bounds[0], bounds[1], exp.UFSourceLine())
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return b, []value.Value{val}, nil
+	case *parser.LenExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot get length of expression")
+		}
+
+		tds, err := parser.MustSingleReturnType(exp.Expression)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch t := tds[0].Type.(type) {
+		case parser.ArrayType:
+			return b, []value.Value{constant.NewInt(types.I32, t.Length)}, nil
+		case parser.SliceType:
+			return b, []value.Value{b.NewExtractValue(vals[0], 1)}, nil
+		default:
+			return nil, nil, errors.Errorf("compiler error: cannot get length of type '%s'", t.TypeName())
+		}
+	case *parser.AppendExpression:
+		b, vals, err := p.getExpressionValues(b, exp.Slice, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot append to expression")
+		}
+
+		elements := make([]value.Value, 0, len(exp.Elements))
+		for i, elementExp := range exp.Elements {
+			var elementVals []value.Value
+			b, elementVals, err = p.getExpressionValues(b, elementExp, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "cannot print element at index %d", i)
+			}
+			elements = append(elements, elementVals[0])
+		}
+
+		return b, []value.Value{p.getAppendValue(b, vals[0], elements)}, nil
+	case *parser.FunctionCallExpression:
+		callSourceTypes, err := exp.CallSource.ResultingTypeDeclarations()
+		if err != nil {
+			return nil, nil, err
+		}
+		funcType, ok := callSourceTypes[0].Type.(parser.FunctionType)
+		if !ok {
+			return nil, nil, errors.New("compiler error: call source is not a function")
+		}
+
+		// A declared function is called directly. Other function values are called through their code, which gets the
+		// environment with the variables they captured before the other parameters.
+		var callee value.Value
+		var params []value.Value
+		if funcDecl := getCalledFunctionDeclaration(exp); funcDecl != nil {
+			callee, ok = funcList[funcDecl]
+			if !ok {
+				return nil, nil, errors.Errorf("compiler error: function '%s' not found", funcDecl.Name)
+			}
+		} else {
+			var vals []value.Value
+			b, vals, err = p.getExpressionValues(b, exp.CallSource, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "cannot print function to call")
+			}
+
+			var env value.Value
+			callee, env = getFunctionValueParts(b, vals[0])
+			params = append(params, env)
+
+			// The zero value of a function type has no code to call.
+			if _, ok := callee.(*ir.Func); !ok {
+				line := constant.NewInt(types.I32, int64(exp.UFSourceLine()))
+				b.NewCall(p.getCheckCallFunc(), b.NewBitCast(callee, types.I8Ptr), line)
+			}
+		}
+
+		// Multiple return values are written to memory of the caller, the pointers to which are the first parameters.
+		returnTypes := funcType.ReturnTypeDeclarations()
+		var retPtrs []value.Value
+		if len(returnTypes) > 1 {
+			for _, td := range returnTypes {
+				typ, err := getLLVMType(td.Type)
+				if err != nil {
+					return nil, nil, errors.Wrap(err, "compiler error: unsupported function return type")
+				}
+				retPtrs = append(retPtrs, addEntryAlloca(b.Parent, typ))
+			}
+		}
+
+		params = append(params, retPtrs...)
+		for i, paramExp := range exp.Parameters {
+			var val []value.Value
+			var err error
+			b, val, err = p.getExpressionValues(b, paramExp, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "cannot parse parameter at index %d", i)
+			}
+			params = append(params, val[0]) // TODO support multiple return values
+		}
+
+		call := b.NewCall(callee, params...)
+		if len(returnTypes) == 0 {
+			call.Typ = types.Void
+		} else if len(returnTypes) == 1 {
+			typ, err := getLLVMType(returnTypes[0].Type)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "compiler error: unsupported function return type")
+			}
+			call.Typ = typ
+		} else {
+			vals := make([]value.Value, 0, len(retPtrs))
+			for _, ptr := range retPtrs {
+				vals = append(vals, b.NewLoad(ptr.Type().(*types.PointerType).ElemType, ptr))
+			}
+			return b, vals, nil
+		}
+
+		return b, []value.Value{call}, nil
+	case *parser.FunctionLiteralExpression:
+		return p.addFunctionLiteral(b, exp, funcList)
+	default:
+		return nil, nil, errors.New("compiler error: unsupported expression type")
+	}
+}
+
+// addFunctionLiteral adds the function of a function literal to the module, and returns the function value for it. The
+// variables it captures are on the heap, and the environment of the function value points to them. Its function gets
+// the environment as its first parameter.
+func (p *LLVMPrinter) addFunctionLiteral(b *ir.Block, exp *parser.FunctionLiteralExpression,
+	funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, []value.Value, error) {
+
+	typ, err := getLLVMType(exp.FunctionDefinition.FunctionType)
+	if err != nil {
+		return nil, nil, err
+	}
+	retType, params, err := getLLVMFunctionSignature(exp.FunctionDefinition.FunctionType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cellTypes := make([]types.Type, 0, len(exp.Captures))
+	for _, varDecl := range exp.Captures {
+		cellType, err := getLLVMType(varDecl.TypeDeclaration.Type)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot get type of captured variable '%s'", varDecl.Name)
+		}
+		cellTypes = append(cellTypes, types.NewPointer(cellType))
+	}
+	envType := types.NewStruct(cellTypes...)
+
+	p.numFunctionLiterals[b.Parent]++
+	env := ir.NewParam(envParamName, types.I8Ptr)
+	name := fmt.Sprintf("%s.func%d", b.Parent.Name(), p.numFunctionLiterals[b.Parent])
+	f := p.module.NewFunc(name, retType, append([]*ir.Param{env}, params...)...)
+	f.Linkage = enum.LinkageInternal
+
+	entry := f.NewBlock("")
+	if len(exp.Captures) > 0 {
+		envPtr := entry.NewBitCast(env, types.NewPointer(envType))
+		for i, varDecl := range exp.Captures {
+			cellPtr := entry.NewGetElementPtr(envType, envPtr, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, int64(i)))
+			p.setCell(f, varDecl, entry.NewLoad(cellTypes[i], cellPtr))
+		}
+	}
+
+	if err := p.addFunctionBody(entry, exp.FunctionDefinition, funcList); err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot print function literal on line %d", exp.UFSourceLine())
+	}
+
+	if len(exp.Captures) == 0 {
+		return b, []value.Value{constant.NewStruct(typ.(*types.StructType), f, constant.NewNull(types.I8Ptr))}, nil
+	}
+
+	envMem := b.NewCall(p.getMallocFunc(), getTypeSize(envType))
+	envPtr := b.NewBitCast(envMem, types.NewPointer(envType))
+	for i, varDecl := range exp.Captures {
+		cell, ok := p.getVariablePointer(b, varDecl)
+		if !ok {
+			return nil, nil, errors.Errorf("compiler error: captured variable '%s' is not on the heap", varDecl.Name)
+		}
+
+		cellPtr := b.NewGetElementPtr(envType, envPtr, constant.NewInt(types.I32, 0), constant.NewInt(types.I32, int64(i)))
+		b.NewStore(cell, cellPtr)
+	}
+
+	val := b.NewInsertValue(constant.NewZeroInitializer(typ), f, 0)
+	return b, []value.Value{b.NewInsertValue(val, envMem, 1)}, nil
+}
+
+// getFunctionValue returns the function value of a declared function. Its code calls the function, leaving out the
+// environment as there are no captured variables.
+func (p *LLVMPrinter) getFunctionValue(decl *parser.FunctionDeclaration, f *ir.Func) (value.Value, error) {
+	typ, err := getLLVMType(decl.FunctionDefinition.FunctionType)
+	if err != nil {
+		return nil, err
+	}
+
+	code, ok := p.funcValues[decl]
+	if !ok {
+		params := []*ir.Param{ir.NewParam(envParamName, types.I8Ptr)}
+		args := make([]value.Value, 0, len(f.Params))
+		for _, param := range f.Params {
+			arg := ir.NewParam(param.Name(), param.Typ)
+			params = append(params, arg)
+			args = append(args, arg)
+		}
+
+		code = p.module.NewFunc(f.Name()+".value", f.Sig.RetType, params...)
+		code.Linkage = enum.LinkageInternal
+		b := code.NewBlock("")
+		call := b.NewCall(f, args...)
+		if f.Sig.RetType.Equal(types.Void) {
+			b.NewRet(nil)
+		} else {
+			b.NewRet(call)
+		}
+		p.funcValues[decl] = code
+	}
+
+	return constant.NewStruct(typ.(*types.StructType), code, constant.NewNull(types.I8Ptr)), nil
+}
+
+// getFunctionValueParts returns the code and the environment of a function value.
+func getFunctionValueParts(b *ir.Block, val value.Value) (value.Value, value.Value) {
+	if c, ok := val.(*constant.Struct); ok {
+		return c.Fields[0], c.Fields[1]
+	}
+
+	return b.NewExtractValue(val, 0), b.NewExtractValue(val, 1)
+}
+
+// getCalledFunctionDeclaration returns the declared function that the call calls by its name, or nil when it calls a
+// function value.
+func getCalledFunctionDeclaration(exp *parser.FunctionCallExpression) *parser.FunctionDeclaration {
+	if idExp, ok := exp.CallSource.(*parser.IdentifierExpression); ok {
+		if funcDecl, ok := idExp.IdentifierDeclaration.(*parser.FunctionDeclaration); ok {
+			return funcDecl
+		}
+	}
+
+	return nil
+}
+
+// getVariablePointer returns the memory of a variable that is not kept in registers, which are global variables, the
+// variables that function literals capture and the variables holding an array that is sliced.
+func (p *LLVMPrinter) getVariablePointer(b *ir.Block, varDecl *parser.VariableDeclaration) (value.Value, bool) {
+	if global, ok := p.globals[varDecl]; ok {
+		return global, true
+	}
+
+	cell, ok := p.cells[b.Parent][varDecl]
+	return cell, ok
+}
+
+// setDeclaredVariableValue sets the first value of a variable declared in a function. A captured variable is moved to
+// the heap, so every function using it sees its changes. The same is done for an addressed variable, so slices of its
+// array see its changes.
+func (p *LLVMPrinter) setDeclaredVariableValue(b *ir.Block, varDecl *parser.VariableDeclaration, val value.Value,
+	scope map[*parser.VariableDeclaration]value.Value) {
+
+	if varDecl.Captured || varDecl.Addressed {
+		p.addCell(b, varDecl, val)
+	} else {
+		scope[varDecl] = val
+	}
+}
+
+// addCell reserves memory on the heap for a captured or addressed variable and stores its first value in it.
+func (p *LLVMPrinter) addCell(b *ir.Block, varDecl *parser.VariableDeclaration, val value.Value) {
+	mem := b.NewCall(p.getMallocFunc(), getTypeSize(val.Type()))
+	cell := b.NewBitCast(mem, types.NewPointer(val.Type()))
+	b.NewStore(val, cell)
+	p.setCell(b.Parent, varDecl, cell)
+}
+
+func (p *LLVMPrinter) setCell(f *ir.Func, varDecl *parser.VariableDeclaration, cell value.Value) {
+	if _, ok := p.cells[f]; !ok {
+		p.cells[f] = make(map[*parser.VariableDeclaration]value.Value)
+	}
+	p.cells[f][varDecl] = cell
+}
+
+// getComparisonValues compares the left and right expression, using the integer predicate for integers and the
+// floating-point predicate for floats. The integer predicate is signed, its unsigned counterpart is used for unsigned
+// integers. Ordered predicates are used for floats so comparing with NaN is false, except for "not equal" which is
+// true when comparing with NaN.
+func (p *LLVMPrinter) getComparisonValues(b *ir.Block, left, right parser.Expression, intPred enum.IPred, floatPred enum.FPred,
+	scope, overwrittenVars, outsideScopeVars map[*parser.VariableDeclaration]value.Value,
+	funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, []value.Value, error) {
+
+	b, val1, err := p.getExpressionValues(b, left, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot compare with Left")
+	}
+	b, val2, err := p.getExpressionValues(b, right, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot compare with Right")
+	}
+
+	if types.IsFloat(val1[0].Type()) {
+		return b, []value.Value{b.NewFCmp(floatPred, val1[0], val2[0])}, nil
+	}
+
+	tds, err := parser.MustSingleReturnType(left)
+	if err != nil {
+		return nil, nil, err
+	}
+	if isUnsignedType(tds[0].Type) {
+		switch intPred {
+		case enum.IPredSLT:
+			intPred = enum.IPredULT
+		case enum.IPredSLE:
+			intPred = enum.IPredULE
+		case enum.IPredSGT:
+			intPred = enum.IPredUGT
+		case enum.IPredSGE:
+			intPred = enum.IPredUGE
+		}
+	}
+
+	return b, []value.Value{b.NewICmp(intPred, val1[0], val2[0])}, nil
+}
+
+// getShortCircuitValues combines two booleans with && or ||. The right expression is only evaluated when the left one
+// does not decide the result already, so the values continue in a new block that merges them with a phi node.
+func (p *LLVMPrinter) getShortCircuitValues(b *ir.Block, left, right parser.Expression, and bool,
+	scope, overwrittenVars, outsideScopeVars map[*parser.VariableDeclaration]value.Value,
+	funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, []value.Value, error) {
+
+	b, val1, err := p.getExpressionValues(b, left, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot combine with Left")
+	}
+
+	f := b.Parent
+	rightBlock := f.NewBlock("")
+	rightEnd, val2, err := p.getExpressionValues(rightBlock, right, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "cannot combine with Right")
+	}
+
+	mergeBlock := f.NewBlock("")
+	if and {
+		b.NewCondBr(val1[0], rightBlock, mergeBlock)
+	} else {
+		b.NewCondBr(val1[0], mergeBlock, rightBlock)
+	}
+	rightEnd.NewBr(mergeBlock)
+
+	// Skipping the right expression means the result is false for && and true for ||.
+	phi := mergeBlock.NewPhi(ir.NewIncoming(constant.NewBool(!and), b), ir.NewIncoming(val2[0], rightEnd))
+	return mergeBlock, []value.Value{phi}, nil
+}
+
+// getConstantValue returns the value of the variable that the semantic analysis computed at compile time. When the
+// value is not known at compile time, false is returned.
+func (p *LLVMPrinter) getConstantValue(decl *parser.VariableDeclaration) (constant.Constant, bool, error) {
+	v, ok := p.fileScope.ConstantValues[decl]
+	if !ok {
+		return nil, false, nil
+	}
+
+	typ, err := getLLVMType(decl.TypeDeclaration.Type)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch v := v.(type) {
+	case *big.Int:
+		return constant.NewInt(typ.(*types.IntType), v.Int64()), true, nil
+	case float64:
+		return constant.NewFloat(typ.(*types.FloatType), v), true, nil
+	case bool:
+		return constant.NewBool(v), true, nil
+	default:
+		return nil, false, errors.Errorf("compiler error: unknown constant type %T", v)
+	}
+}
+
+// getIntegerOperationValues applies an operator that is only defined for integers. Byte is unsigned, so it uses the
+// unsigned remainder and shift. Shifting by the size of the type or more results in 0, or -1 when shifting a negative
+// Int to the right. Shifting by a negative count stops the program.
+func (p *LLVMPrinter) getIntegerOperationValues(b *ir.Block, exp, left, right parser.Expression, operator lexer.TokenType,
+	scope, overwrittenVars, outsideScopeVars map[*parser.VariableDeclaration]value.Value,
+	funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, []value.Value, error) {
+
+	b, val1, err := p.getExpressionValues(b, left, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot '%s' with Left", lexer.GetTokenTypeString(operator))
+	}
+	b, val2, err := p.getExpressionValues(b, right, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot '%s' with Right", lexer.GetTokenTypeString(operator))
+	}
+
+	tds, err := parser.MustSingleReturnType(exp)
+	if err != nil {
+		return nil, nil, err
+	}
+	unsigned := isUnsignedType(tds[0].Type)
+
+	x, y := val1[0], val2[0]
+	var val value.Value
+	switch operator {
+	case lexer.Modulo:
+		if unsigned {
+			val = b.NewURem(x, y)
+		} else {
+			val = b.NewSRem(x, y) // TODO division by zero causes undefined behavior, so code must assert error
+		}
+	case lexer.BitwiseAnd:
+		val = b.NewAnd(x, y)
+	case lexer.BitwiseOr:
+		val = b.NewOr(x, y)
+	case lexer.BitwiseXor:
+		val = b.NewXor(x, y)
+	case lexer.ShiftLeft, lexer.ShiftRight:
+		val = p.getShiftValue(b, x, y, operator == lexer.ShiftLeft, unsigned, exp.UFSourceLine())
+	default:
+		return nil, nil, errors.Errorf("compiler error: unknown integer operator '%s'", lexer.GetTokenTypeString(operator))
+	}
+
+	return b, []value.Value{val}, nil
+}
+
+// getShiftValue shifts x by y bits. LLVM has no result for shifting by the size of the type or more, so such a count is
+// replaced before shifting, and the result is chosen afterwards. The program stops at runtime if the count is a negative
+// Int.
+func (p *LLVMPrinter) getShiftValue(b *ir.Block, x, y value.Value, left, unsigned bool, line int) value.Value {
+	typ := x.Type().(*types.IntType)
+	if _, ok := y.(*constant.Int); !unsigned && !ok {
+		b.NewCall(p.getCheckShiftFunc(), y, constant.NewInt(types.I32, int64(line)))
+	}
+
+	zero := constant.NewInt(typ, 0)
+	tooFar := b.NewICmp(enum.IPredUGE, y, constant.NewInt(typ, int64(typ.BitSize)))
+	if !left && !unsigned {
+		// Shifting a negative Int right by the size or more results in -1, as does shifting it by the size minus one.
+		maxShift := constant.NewInt(typ, int64(typ.BitSize-1))
+		return b.NewAShr(x, b.NewSelect(tooFar, maxShift, y))
+	}
+
+	count := b.NewSelect(tooFar, zero, y)
+	var shifted value.Value
+	if left {
+		shifted = b.NewShl(x, count)
+	} else {
+		shifted = b.NewLShr(x, count)
+	}
+	return b.NewSelect(tooFar, zero, shifted)
+}
+
+func (p *LLVMPrinter) getZeroValue(typ parser.Type) (value.Value, error) {
+	switch t := typ.(type) {
+	case parser.BasicType:
+		switch t.DataType {
+		case parser.IntDataType:
+			return constant.NewInt(types.I32, 0), nil
+		case parser.FloatDataType:
+			return constant.NewFloat(types.Double, 0), nil
+		case parser.BoolDataType:
+			return constant.False, nil
+		case parser.ByteDataType:
+			return constant.NewInt(types.I8, 0), nil
+		default:
+			return nil, errors.Errorf("compiler error: basic data type '%d' is not implemented", t.DataType)
+		}
+	case parser.StructType, parser.ArrayType, parser.SliceType, parser.FunctionType:
+		typ, err := getLLVMType(t)
+		if err != nil {
+			return nil, err
+		}
+
+		return constant.NewZeroInitializer(typ), nil
+	default:
+		return nil, errors.New("type is unsupported")
+	}
+}
+
+// getAssignedValue returns the new value of the variable at the start of the target, after assigning the value to the
+// field or element the target refers to. Elements of a slice are on the heap, so when the target goes through a slice
+// the element is stored there instead, the variable does not change and nil is returned.
+func (p *LLVMPrinter) getAssignedValue(b *ir.Block, target parser.Expression, val value.Value, scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value, funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, value.Value, error) {
+
+	// The fields and indices to go through, starting at the variable.
+	var chain []parser.Expression
+	exp := target
+	for true {
+		if fe, ok := exp.(*parser.FieldAccessExpression); ok {
+			chain = append([]parser.Expression{fe}, chain...)
+			exp = fe.Expression
+		} else if ie, ok := exp.(*parser.IndexExpression); ok {
+			chain = append([]parser.Expression{ie}, chain...)
+			exp = ie.Expression
+		} else {
+			break
+		}
+	}
+
+	b, vals, err := p.getExpressionValues(b, exp, scope, overwrittenVars, outsideScopeVars, funcList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Every index is printed once, from left to right.
+	indices := make([]value.Value, len(chain))
+	for i, e := range chain {
+		if ie, ok := e.(*parser.IndexExpression); ok {
+			var indexVals []value.Value
+			b, indexVals, err = p.getExpressionValues(b, ie.Index, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "cannot print index")
+			}
+			indices[i] = indexVals[0]
+		}
+	}
+
+	// The values that contain the target, starting at the variable.
+	containers := []value.Value{vals[0]}
+	for i, e := range chain[:len(chain)-1] {
+		var container value.Value
+		switch e := e.(type) {
+		case *parser.FieldAccessExpression:
+			fi, _, err := e.Field()
+			if err != nil {
+				return nil, nil, err
+			}
+			container = b.NewExtractValue(containers[i], uint64(fi))
+		case *parser.IndexExpression:
+			tds, err := parser.MustSingleReturnType(e.Expression)
+			if err != nil {
+				return nil, nil, err
+			}
+			container, err = p.getElementValue(b, containers[i], tds[0].Type, indices[i], e.UFSourceLine(), true)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		containers = append(containers, container)
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		switch e := chain[i].(type) {
+		case *parser.FieldAccessExpression:
+			fi, _, err := e.Field()
+			if err != nil {
+				return nil, nil, err
+			}
+			val = b.NewInsertValue(containers[i], val, uint64(fi))
+		case *parser.IndexExpression:
+			tds, err := parser.MustSingleReturnType(e.Expression)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// The indices of the containers were checked when the containers were read.
+			val, err = p.setElementValue(b, containers[i], tds[0].Type, indices[i], val, e.UFSourceLine(), i == len(chain)-1)
+			if err != nil {
+				return nil, nil, err
+			}
+			if val == nil {
+				return b, nil, nil
+			}
+		}
+	}
+
+	return b, val, nil
+}
+
+// getElementValue returns the element at the index of an array or slice. When check is true, the program stops at
+// runtime if the index is out of range.
+func (p *LLVMPrinter) getElementValue(b *ir.Block, container value.Value, typ parser.Type, index value.Value,
+	line int, check bool) (value.Value, error) {
+
+	switch t := typ.(type) {
+	case parser.ArrayType:
+		if i, ok := getConstantIndex(index, t.Length); ok {
+			return b.NewExtractValue(container, i), nil
+		}
+		if check {
+			p.addIndexCheck(b, index, constant.NewInt(types.I32, t.Length), line)
+		}
+
+		ptr := getArrayElementPointer(b, container, index)
+		return b.NewLoad(ptr.Type().(*types.PointerType).ElemType, ptr), nil
+	case parser.SliceType:
+		ptr := p.getSliceElementPointer(b, container, index, line, check)
+		return b.NewLoad(ptr.Type().(*types.PointerType).ElemType, ptr), nil
+	default:
+		return nil, errors.Errorf("compiler error: cannot index type '%s'", typ.TypeName())
+	}
+}
+
+// setElementValue sets the element at the index of an array or slice, and returns the new array. The elements of a slice
+// are changed on the heap, so nil is returned for a slice. When check is true, the program stops at runtime if the index
+// is out of range.
+func (p *LLVMPrinter) setElementValue(b *ir.Block, container value.Value, typ parser.Type, index value.Value, val value.Value,
+	line int, check bool) (value.Value, error) {
+
+	switch t := typ.(type) {
+	case parser.ArrayType:
+		if i, ok := getConstantIndex(index, t.Length); ok {
+			return b.NewInsertValue(container, val, i), nil
+		}
+		if check {
+			p.addIndexCheck(b, index, constant.NewInt(types.I32, t.Length), line)
+		}
+
+		ptr := getArrayElementPointer(b, container, index)
+		b.NewStore(val, ptr)
+		return b.NewLoad(container.Type(), ptr.Src), nil
+	case parser.SliceType:
+		b.NewStore(val, p.getSliceElementPointer(b, container, index, line, check))
+		return nil, nil
+	default:
+		return nil, errors.Errorf("compiler error: cannot index type '%s'", typ.TypeName())
+	}
+}
+
+// getSliceValue returns a slice of the elements of an array or slice from the low bound up to the high bound. An array
+// is copied to the heap first. The program stops at runtime if the bounds are out of range.
+func (p *LLVMPrinter) getSliceValue(b *ir.Block, container value.Value, typ parser.Type, low, high value.Value,
+	line int) (value.Value, error) {
+
+	switch t := typ.(type) {
+	case parser.ArrayType:
+		mem := b.NewCall(p.getMallocFunc(), getTypeSize(container.Type()))
+		arrayPtr := b.NewBitCast(mem, types.NewPointer(container.Type()))
+		b.NewStore(container, arrayPtr)
+		return p.getArraySliceValue(b, arrayPtr, t, low, high, line), nil
+	case parser.SliceType:
+		return p.getBoundedSliceValue(b, container, low, high, line), nil
+	default:
+		return nil, errors.Errorf("compiler error: cannot slice type '%s'", typ.TypeName())
+	}
+}
+
+// getArraySliceValue returns a slice of the elements of the array in memory from the low bound up to the high bound.
+// The slice refers to the memory of the array. The program stops at runtime if the bounds are out of range.
+func (p *LLVMPrinter) getArraySliceValue(b *ir.Block, arrayPtr value.Value, typ parser.ArrayType, low, high value.Value,
+	line int) value.Value {
+
+	arrayType := arrayPtr.Type().(*types.PointerType).ElemType
+	zero := constant.NewInt(types.I32, 0)
+	length := constant.NewInt(types.I32, typ.Length)
+	data := b.NewGetElementPtr(arrayType, arrayPtr, zero, zero)
+	return p.getBoundedSliceValue(b, newSliceValue(b, data, length, length), low, high, line)
+}
+
+// getBoundedSliceValue returns a slice of the elements of the slice from the low bound up to the high bound. The program
+// stops at runtime if the bounds are out of range.
+func (p *LLVMPrinter) getBoundedSliceValue(b *ir.Block, slice value.Value, low, high value.Value, line int) value.Value {
+	data := b.NewExtractValue(slice, 0)
+	length := b.NewExtractValue(slice, 1)
+	capacity := b.NewExtractValue(slice, 2)
+	if low == nil {
+		low = constant.NewInt(types.I32, 0)
+	}
+	if high == nil {
+		high = length
+	}
+	b.NewCall(p.getCheckSliceFunc(), low, high, capacity, constant.NewInt(types.I32, int64(line)))
+
+	elemType := data.Type().(*types.PointerType).ElemType
+	newData := b.NewGetElementPtr(elemType, data, low)
+	return newSliceValue(b, newData, b.NewSub(high, low), b.NewSub(capacity, low))
+}
+
+// getArrayPointer returns the memory of the array that the expression results in, or nil when the array is not in
+// memory. The array may be in a variable kept in memory, or be a field or an element of an array in memory, or be an
+// element of a slice. Nothing is printed when nil is returned.
+func (p *LLVMPrinter) getArrayPointer(b *ir.Block, exp parser.Expression, scope map[*parser.VariableDeclaration]value.Value,
+	overwrittenVars map[*parser.VariableDeclaration]value.Value,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value,
+	funcList map[*parser.FunctionDeclaration]*ir.Func) (*ir.Block, value.Value, error) {
+
+	switch exp := exp.(type) {
+	case *parser.IdentifierExpression:
+		varDecl, ok := exp.IdentifierDeclaration.(*parser.VariableDeclaration)
+		if !ok || varDecl.Constant {
+			return b, nil, nil
+		}
+		ptr, _ := p.getVariablePointer(b, varDecl)
+		return b, ptr, nil
+	case *parser.FieldAccessExpression:
+		b, ptr, err := p.getArrayPointer(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil || ptr == nil {
+			return b, nil, err
+		}
+
+		fi, _, err := exp.Field()
+		if err != nil {
+			return nil, nil, err
+		}
+		zero := constant.NewInt(types.I32, 0)
+		structType := ptr.Type().(*types.PointerType).ElemType
+		return b, b.NewGetElementPtr(structType, ptr, zero, constant.NewInt(types.I32, int64(fi))), nil
+	case *parser.IndexExpression:
+		tds, err := parser.MustSingleReturnType(exp.Expression)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var ptr value.Value
+		var vals []value.Value
+		switch tds[0].Type.(type) {
+		case parser.ArrayType:
+			b, ptr, err = p.getArrayPointer(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil || ptr == nil {
+				return b, nil, err
+			}
+		case parser.SliceType:
+			b, vals, err = p.getExpressionValues(b, exp.Expression, scope, overwrittenVars, outsideScopeVars, funcList)
+			if err != nil {
+				return nil, nil, err
+			}
+		default:
+			return b, nil, nil
+		}
+
+		b, indexVals, err := p.getExpressionValues(b, exp.Index, scope, overwrittenVars, outsideScopeVars, funcList)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot print index")
+		}
+
+		if ptr == nil {
+			return b, p.getSliceElementPointer(b, vals[0], indexVals[0], exp.UFSourceLine(), true), nil
+		}
+
+		length := tds[0].Type.(parser.ArrayType).Length
+		if _, ok := getConstantIndex(indexVals[0], length); !ok {
+			p.addIndexCheck(b, indexVals[0], constant.NewInt(types.I32, length), exp.UFSourceLine())
+		}
+		arrayType := ptr.Type().(*types.PointerType).ElemType
+		return b, b.NewGetElementPtr(arrayType, ptr, constant.NewInt(types.I32, 0), indexVals[0]), nil
+	default:
+		return b, nil, nil
+	}
+}
+
+// getAppendValue returns the slice with the elements added to the end, growing it on the heap when needed.
+func (p *LLVMPrinter) getAppendValue(b *ir.Block, slice value.Value, elements []value.Value) value.Value {
+	data := b.NewExtractValue(slice, 0)
+	length := b.NewExtractValue(slice, 1)
+	capacity := b.NewExtractValue(slice, 2)
+
+	dataType := data.Type().(*types.PointerType)
+	grown := b.NewCall(p.getGrowSliceFunc(), b.NewBitCast(data, types.I8Ptr), length, capacity,
+		constant.NewInt(types.I32, int64(len(elements))), getTypeSize(dataType.ElemType))
+	newData := b.NewBitCast(b.NewExtractValue(grown, 0), dataType)
+	newCap := b.NewExtractValue(grown, 1)
+
+	var index value.Value = length
+	for i, element := range elements {
+		if i > 0 {
+			index = b.NewAdd(length, constant.NewInt(types.I32, int64(i)))
+		}
+		b.NewStore(element, b.NewGetElementPtr(dataType.ElemType, newData, index))
+	}
+
+	newLength := b.NewAdd(length, constant.NewInt(types.I32, int64(len(elements))))
+	return newSliceValue(b, newData, newLength, newCap)
+}
+
+// getSliceElementPointer returns a pointer to the element at the index of a slice. When check is true, the program stops
+// at runtime if the index is out of range.
+func (p *LLVMPrinter) getSliceElementPointer(b *ir.Block, slice value.Value, index value.Value, line int,
+	check bool) *ir.InstGetElementPtr {
+
+	if check {
+		p.addIndexCheck(b, index, b.NewExtractValue(slice, 1), line)
+	}
+
+	data := b.NewExtractValue(slice, 0)
+	return b.NewGetElementPtr(data.Type().(*types.PointerType).ElemType, data, index)
+}
+
+func (p *LLVMPrinter) addIndexCheck(b *ir.Block, index value.Value, length value.Value, line int) {
+	b.NewCall(p.getCheckIndexFunc(), index, length, constant.NewInt(types.I32, int64(line)))
+}
+
+// getArrayElementPointer copies the array to the stack, and returns a pointer to the element at the index. Elements can
+// only be taken out of an array value by a constant index, so this is needed for any other index.
+func getArrayElementPointer(b *ir.Block, array value.Value, index value.Value) *ir.InstGetElementPtr {
+	// The memory is allocated in the entry block, so it is only allocated once when this is done inside a loop.
+	entry := b.Parent.Blocks[0]
+	mem := ir.NewAlloca(array.Type())
+	entry.Insts = append([]ir.Instruction{mem}, entry.Insts...)
+
+	b.NewStore(array, mem)
+	return b.NewGetElementPtr(array.Type(), mem, constant.NewInt(types.I32, 0), index)
+}
+
+// getConstantIndex returns the index when it is a constant within the length of the array.
+func getConstantIndex(index value.Value, length int64) (uint64, bool) {
+	c, ok := index.(*constant.Int)
+	if !ok || c.X.Sign() < 0 || c.X.Cmp(big.NewInt(length)) >= 0 {
+		return 0, false
+	}
+
+	return c.X.Uint64(), true
+}
+
+// newSliceValue creates a slice, which consists of a pointer to the elements, the length and the capacity.
+func newSliceValue(b *ir.Block, data, length, capacity value.Value) value.Value {
+	sliceType := types.NewStruct(data.Type(), types.I32, types.I32)
+	var slice value.Value = b.NewInsertValue(constant.NewZeroInitializer(sliceType), data, 0)
+	slice = b.NewInsertValue(slice, length, 1)
+	return b.NewInsertValue(slice, capacity, 2)
+}
+
+// getVisibleVariables merges the variables from the scopes into a new map. Variables in scope take precedence over
+// overwritten variables, which take precedence over the variables from outside the scope.
+func getVisibleVariables(scope, overwrittenVars,
+	outsideScopeVars map[*parser.VariableDeclaration]value.Value) map[*parser.VariableDeclaration]value.Value {
+
+	vars := make(map[*parser.VariableDeclaration]value.Value, len(outsideScopeVars)+len(overwrittenVars)+len(scope))
+	for _, m := range []map[*parser.VariableDeclaration]value.Value{outsideScopeVars, overwrittenVars, scope} {
+		for varDecl, val := range m {
+			vars[varDecl] = val
+		}
+	}
+
+	return vars
+}
+
+func setVariableValue(varDecl *parser.VariableDeclaration, val value.Value, scope,
+	overwrittenVars map[*parser.VariableDeclaration]value.Value) {
+
+	if _, inScope := scope[varDecl]; inScope {
+		scope[varDecl] = val
+	} else {
+		overwrittenVars[varDecl] = val
+	}
+}
+
+// getAssignedVariables returns the variables that are assigned anywhere in the statements, in the order in which they
+// are first assigned.
+func getAssignedVariables(statements []parser.Statement) []*parser.VariableDeclaration {
+	var vars []*parser.VariableDeclaration
+	seen := make(map[*parser.VariableDeclaration]bool)
+
+	var visit func(statements []parser.Statement)
+	visit = func(statements []parser.Statement) {
+		for _, statement := range statements {
+			switch s := statement.(type) {
+			case parser.StatementHavingVariableDeclaration:
+				if varDecl, ok := s.GetVariableDeclaration().(*parser.VariableDeclaration); ok && !seen[varDecl] {
+					seen[varDecl] = true
+					vars = append(vars, varDecl)
+				}
+			case *parser.MultiAssignStatement:
+				for _, decl := range s.VariableDeclarations {
+					if varDecl, ok := decl.(*parser.VariableDeclaration); ok && !seen[varDecl] {
+						seen[varDecl] = true
+						vars = append(vars, varDecl)
+					}
+				}
+			case *parser.IfStatement:
+				visit(s.ThenStatements)
+				visit(s.ElseStatements)
+			case *parser.ForStatement:
+				visit([]parser.Statement{s.Init})
+				visit(s.Statements)
+				visit([]parser.Statement{s.LoopAction})
+			case *parser.WhileStatement:
+				visit(s.Statements)
+			}
+		}
+	}
+	visit(statements)
+
+	return vars
+}
+
+// addEntryAlloca reserves memory on the stack at the start of the function. It is done in the entry block, so the memory
+// is reserved once even when it is used in a loop.
+func addEntryAlloca(f *ir.Func, typ types.Type) *ir.InstAlloca {
+	entry := f.Blocks[0]
+	i := 0
+	for i < len(entry.Insts) {
+		if _, ok := entry.Insts[i].(*ir.InstAlloca); !ok {
+			break
+		}
+		i++
+	}
+
+	alloca := ir.NewAlloca(typ)
+	entry.Insts = append(entry.Insts[:i], append([]ir.Instruction{alloca}, entry.Insts[i:]...)...)
+	return alloca
+}
+
+// getLLVMFunctionSignature returns the return type and the parameters of a function with the function type. A function
+// returning multiple values returns nothing, it writes the values to the memory that its first parameters point to.
+func getLLVMFunctionSignature(t parser.FunctionType) (types.Type, []*ir.Param, error) {
+	var retTypes []types.Type
+	for _, td := range t.ReturnTypeDeclarations() {
+		typ, err := getLLVMType(td.Type)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot not get LLVM type for return type")
+		}
+
+		retTypes = append(retTypes, typ)
+	}
+
+	var retType types.Type
+	if len(retTypes) == 1 {
+		retType = retTypes[0]
+	} else {
+		retType = types.Void
+	}
+
+	params, err := getLLVMFunctionParams(t.Parameters, retTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return retType, params, nil
+}
+
+func getLLVMFunctionParams(parameters []*parser.Field, returnTypes []types.Type) ([]*ir.Param, error) {
+	var params []*ir.Param
+	if len(returnTypes) > 1 {
+		for i, t := range returnTypes {
+			var pt *types.PointerType
+			if ppt, ok := t.(*types.PointerType); ok {
+				pt = ppt
+			} else {
+				pt = types.NewPointer(t)
+			}
+
+			params = append(params, ir.NewParam(fmt.Sprintf("qx.mulret.%d", i), pt))
+		}
+	}
+
+	for _, f := range parameters {
+		typ, err := getLLVMType(f.VariableDeclaration.TypeDeclaration.Type)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse type of parameter '%s'", f.Name)
+		}
+
+		params = append(params, ir.NewParam(f.Name, typ))
+	}
+
+	return params, nil
+}
+
+// getMultipleReturnParams returns the parameters of a function that its return values are written to. They come first,
+// after the environment of a function value.
+func getMultipleReturnParams(f *ir.Func) []*ir.Param {
+	if len(f.Params) > 0 && f.Params[0].Name() == envParamName {
+		return f.Params[1:]
+	}
+
+	return f.Params
+}
+
+func getFuncVariableScope(parameters []*parser.Field, irParams []*ir.Param) (map[*parser.VariableDeclaration]value.Value, error) {
+	// The pointers for multiple return values come before the parameters.
+	offset := len(irParams) - len(parameters)
+
+	scope := make(map[*parser.VariableDeclaration]value.Value)
+	for i, f := range parameters {
+		scope[f.VariableDeclaration] = irParams[offset+i]
+	}
+
+	return scope, nil
+}
+
+func getLLVMType(typ parser.Type) (types.Type, error) {
+	switch t := typ.(type) {
+	case parser.BasicType:
+		switch t.DataType {
+		case parser.IntDataType:
+			return types.I32, nil
+		case parser.FloatDataType:
+			return types.Double, nil
+		case parser.BoolDataType:
+			return types.I1, nil
+		case parser.ByteDataType:
+			return types.I8, nil
 		default:
 			return nil, errors.Errorf("unknown/unsupported data type '%d", t.DataType)
 		}
+	case parser.StructType:
+		fieldTypes := make([]types.Type, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			typ, err := getLLVMType(f.VariableDeclaration.TypeDeclaration.Type)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot get type of field '%s'", f.Name)
+			}
+
+			fieldTypes = append(fieldTypes, typ)
+		}
+
+		return types.NewStruct(fieldTypes...), nil
+	case parser.ArrayType:
+		elemType, err := getLLVMType(t.ElementType.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		return types.NewArray(uint64(t.Length), elemType), nil
+	case parser.SliceType:
+		elemType, err := getLLVMType(t.ElementType.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		// The elements are on the heap, followed by the length and the capacity.
+		return types.NewStruct(types.NewPointer(elemType), types.I32, types.I32), nil
+	case parser.FunctionType:
+		retType, params, err := getLLVMFunctionSignature(t)
+		if err != nil {
+			return nil, err
+		}
+
+		// The code gets the environment with the captured variables before the other parameters.
+		paramTypes := make([]types.Type, 0, len(params)+1)
+		paramTypes = append(paramTypes, types.I8Ptr)
+		for _, param := range params {
+			paramTypes = append(paramTypes, param.Typ)
+		}
+
+		// A function value points to the code of the function and to the environment.
+		return types.NewStruct(types.NewPointer(types.NewFunc(retType, paramTypes...)), types.I8Ptr), nil
 	default:
 		return nil, errors.Errorf("unknown/unsupported function return type '%s'", typ.TypeName())
 	}
 }
+
+// isUnsignedType returns whether the type is an integer type without negative values.
+func isUnsignedType(typ parser.Type) bool {
+	t, ok := typ.(parser.BasicType)
+	if !ok {
+		return false
+	}
+
+	min, _, ok := t.IntegerRange()
+	return ok && min.Sign() == 0
+}
+
+// getConversionValue converts a value between the numeric types. Byte is unsigned, the other integers are signed.
+func getConversionValue(b *ir.Block, val value.Value, from, to parser.Type) (value.Value, error) {
+	fromType, ok := from.(parser.BasicType)
+	if !ok {
+		return nil, errors.Errorf("cannot convert from type '%s'", from.TypeName())
+	}
+	toType, ok := to.(parser.BasicType)
+	if !ok {
+		return nil, errors.Errorf("cannot convert to type '%s'", to.TypeName())
+	}
+
+	if fromType.DataType == toType.DataType {
+		return val, nil
+	}
+
+	typ, err := getLLVMType(toType)
+	if err != nil {
+		return nil, err
+	}
+
+	fromUnsigned := fromType.DataType == parser.ByteDataType
+	toUnsigned := toType.DataType == parser.ByteDataType
+
+	switch {
+	case fromType.DataType == parser.FloatDataType && toUnsigned:
+		return b.NewFPToUI(val, typ), nil
+	case fromType.DataType == parser.FloatDataType:
+		return b.NewFPToSI(val, typ), nil
+	case toType.DataType == parser.FloatDataType && fromUnsigned:
+		return b.NewUIToFP(val, typ), nil
+	case toType.DataType == parser.FloatDataType:
+		return b.NewSIToFP(val, typ), nil
+	}
+
+	fromBits := val.Type().(*types.IntType).BitSize
+	toBits := typ.(*types.IntType).BitSize
+	switch {
+	case fromBits > toBits:
+		return b.NewTrunc(val, typ), nil
+	case fromBits < toBits && fromUnsigned:
+		return b.NewZExt(val, typ), nil
+	case fromBits < toBits:
+		return b.NewSExt(val, typ), nil
+	default:
+		return val, nil
+	}
+}
@@ -0,0 +1,203 @@
+package printer
+
+import (
+	"fmt"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+// The runtime consists of the functions the generated code calls for work that takes more than a few instructions, like
+// bounds checks and growing slices, and the C library functions those depend on. A function is only added to the module
+// the first time it is used.
+
+func (p *LLVMPrinter) getRuntimeFunc(name string, create func() *ir.Func) *ir.Func {
+	if f, ok := p.runtimeFuncs[name]; ok {
+		return f
+	}
+
+	f := create()
+	p.runtimeFuncs[name] = f
+	return f
+}
+
+func (p *LLVMPrinter) getMallocFunc() *ir.Func {
+	return p.getRuntimeFunc("malloc", func() *ir.Func {
+		return p.module.NewFunc("malloc", types.I8Ptr, ir.NewParam("size", types.I64))
+	})
+}
+
+func (p *LLVMPrinter) getMemcpyFunc() *ir.Func {
+	return p.getRuntimeFunc("llvm.memcpy", func() *ir.Func {
+		return p.module.NewFunc("llvm.memcpy.p0i8.p0i8.i64", types.Void,
+			ir.NewParam("dst", types.I8Ptr), ir.NewParam("src", types.I8Ptr), ir.NewParam("len", types.I64),
+			ir.NewParam("isvolatile", types.I1))
+	})
+}
+
+func (p *LLVMPrinter) getDprintfFunc() *ir.Func {
+	return p.getRuntimeFunc("dprintf", func() *ir.Func {
+		f := p.module.NewFunc("dprintf", types.I32, ir.NewParam("fd", types.I32), ir.NewParam("format", types.I8Ptr))
+		f.Sig.Variadic = true
+		return f
+	})
+}
+
+func (p *LLVMPrinter) getTrapFunc() *ir.Func {
+	return p.getRuntimeFunc("llvm.trap", func() *ir.Func {
+		f := p.module.NewFunc("llvm.trap", types.Void)
+		f.FuncAttrs = append(f.FuncAttrs, enum.FuncAttrNoReturn)
+		return f
+	})
+}
+
+// getCheckIndexFunc returns the function that stops the program when an index is not smaller than the length.
+func (p *LLVMPrinter) getCheckIndexFunc() *ir.Func {
+	return p.getRuntimeFunc("qx.checkIndex", func() *ir.Func {
+		index := ir.NewParam("index", types.I32)
+		length := ir.NewParam("length", types.I32)
+		line := ir.NewParam("line", types.I32)
+		f := p.module.NewFunc("qx.checkIndex", types.Void, index, length, line)
+		f.Linkage = enum.LinkageInternal
+
+		entry := f.NewBlock("")
+		okBlock := f.NewBlock("")
+		failBlock := f.NewBlock("")
+
+		// Negative indices are large when compared as unsigned, so one comparison covers both bounds.
+		entry.NewCondBr(entry.NewICmp(enum.IPredULT, index, length), okBlock, failBlock)
+		okBlock.NewRet(nil)
+		p.addPanic(failBlock, "panic: index out of range [%d] with length %d on line %d\n", index, length, line)
+		return f
+	})
+}
+
+// getCheckSliceFunc returns the function that stops the program when the bounds of a slice expression are not in order
+// or go past the capacity.
+func (p *LLVMPrinter) getCheckSliceFunc() *ir.Func {
+	return p.getRuntimeFunc("qx.checkSlice", func() *ir.Func {
+		low := ir.NewParam("low", types.I32)
+		high := ir.NewParam("high", types.I32)
+		capacity := ir.NewParam("capacity", types.I32)
+		line := ir.NewParam("line", types.I32)
+		f := p.module.NewFunc("qx.checkSlice", types.Void, low, high, capacity, line)
+		f.Linkage = enum.LinkageInternal
+
+		entry := f.NewBlock("")
+		okBlock := f.NewBlock("")
+		failBlock := f.NewBlock("")
+
+		inOrder := entry.NewICmp(enum.IPredULE, low, high)
+		inCapacity := entry.NewICmp(enum.IPredULE, high, capacity)
+		entry.NewCondBr(entry.NewAnd(inOrder, inCapacity), okBlock, failBlock)
+		okBlock.NewRet(nil)
+		p.addPanic(failBlock, "panic: slice bounds out of range [%d:%d] with capacity %d on line %d\n", low, high, capacity, line)
+		return f
+	})
+}
+
+// getCheckShiftFunc returns the function that stops the program when a shift count is negative.
+func (p *LLVMPrinter) getCheckShiftFunc() *ir.Func {
+	return p.getRuntimeFunc("qx.checkShift", func() *ir.Func {
+		count := ir.NewParam("count", types.I32)
+		line := ir.NewParam("line", types.I32)
+		f := p.module.NewFunc("qx.checkShift", types.Void, count, line)
+		f.Linkage = enum.LinkageInternal
+
+		entry := f.NewBlock("")
+		okBlock := f.NewBlock("")
+		failBlock := f.NewBlock("")
+
+		entry.NewCondBr(entry.NewICmp(enum.IPredSGE, count, constant.NewInt(types.I32, 0)), okBlock, failBlock)
+		okBlock.NewRet(nil)
+		p.addPanic(failBlock, "panic: negative shift count %d on line %d\n", count, line)
+		return f
+	})
+}
+
+// getCheckCallFunc returns the function that stops the program when a function value without code is called.
+func (p *LLVMPrinter) getCheckCallFunc() *ir.Func {
+	return p.getRuntimeFunc("qx.checkCall", func() *ir.Func {
+		code := ir.NewParam("code", types.I8Ptr)
+		line := ir.NewParam("line", types.I32)
+		f := p.module.NewFunc("qx.checkCall", types.Void, code, line)
+		f.Linkage = enum.LinkageInternal
+
+		entry := f.NewBlock("")
+		okBlock := f.NewBlock("")
+		failBlock := f.NewBlock("")
+
+		entry.NewCondBr(entry.NewICmp(enum.IPredNE, code, constant.NewNull(types.I8Ptr)), okBlock, failBlock)
+		okBlock.NewRet(nil)
+		p.addPanic(failBlock, "panic: call of nil function value on line %d\n", line)
+		return f
+	})
+}
+
+// getGrowSliceFunc returns the function that makes room for extra elements in a slice. When the capacity is too small,
+// the elements are copied to new memory on the heap that has room for at least twice as many elements. It returns the
+// pointer to the elements and the capacity.
+func (p *LLVMPrinter) getGrowSliceFunc() *ir.Func {
+	return p.getRuntimeFunc("qx.growSlice", func() *ir.Func {
+		data := ir.NewParam("data", types.I8Ptr)
+		length := ir.NewParam("length", types.I32)
+		capacity := ir.NewParam("capacity", types.I32)
+		extra := ir.NewParam("extra", types.I32)
+		elemSize := ir.NewParam("elemsize", types.I64)
+		retType := types.NewStruct(types.I8Ptr, types.I32)
+		f := p.module.NewFunc("qx.growSlice", retType, data, length, capacity, extra, elemSize)
+		f.Linkage = enum.LinkageInternal
+
+		entry := f.NewBlock("")
+		keepBlock := f.NewBlock("")
+		growBlock := f.NewBlock("")
+
+		needed := entry.NewAdd(length, extra)
+		entry.NewCondBr(entry.NewICmp(enum.IPredSLE, needed, capacity), keepBlock, growBlock)
+
+		keep := keepBlock.NewInsertValue(constant.NewZeroInitializer(retType), data, 0)
+		keepBlock.NewRet(keepBlock.NewInsertValue(keep, capacity, 1))
+
+		doubled := growBlock.NewMul(capacity, constant.NewInt(types.I32, 2))
+		newCap := growBlock.NewSelect(growBlock.NewICmp(enum.IPredSGT, doubled, needed), doubled, needed)
+		minCap := constant.NewInt(types.I32, 4)
+		newCap = growBlock.NewSelect(growBlock.NewICmp(enum.IPredSGT, newCap, minCap), newCap, minCap)
+
+		newData := growBlock.NewCall(p.getMallocFunc(), growBlock.NewMul(growBlock.NewSExt(newCap, types.I64), elemSize))
+		usedSize := growBlock.NewMul(growBlock.NewSExt(length, types.I64), elemSize)
+		growBlock.NewCall(p.getMemcpyFunc(), newData, data, usedSize, constant.False)
+
+		grown := growBlock.NewInsertValue(constant.NewZeroInitializer(retType), newData, 0)
+		growBlock.NewRet(growBlock.NewInsertValue(grown, newCap, 1))
+		return f
+	})
+}
+
+// addPanic writes the message to stderr and stops the program. The block is terminated afterwards.
+func (p *LLVMPrinter) addPanic(b *ir.Block, format string, args ...value.Value) {
+	params := append([]value.Value{constant.NewInt(types.I32, 2), p.getStringConstant(format)}, args...)
+	b.NewCall(p.getDprintfFunc(), params...)
+	b.NewCall(p.getTrapFunc())
+	b.NewUnreachable()
+}
+
+// getStringConstant adds the zero-terminated string to the module and returns a pointer to its first character.
+func (p *LLVMPrinter) getStringConstant(s string) constant.Constant {
+	g := p.module.NewGlobalDef(fmt.Sprintf("qx.str.%d", p.numStrings), constant.NewCharArrayFromString(s+"\x00"))
+	g.Linkage = enum.LinkagePrivate
+	g.UnnamedAddr = enum.UnnamedAddrUnnamedAddr
+	g.Immutable = true
+	p.numStrings++
+
+	zero := constant.NewInt(types.I32, 0)
+	return constant.NewGetElementPtr(g.ContentType, g, zero, zero)
+}
+
+// getTypeSize returns the size of the type in bytes, as the address of the second element of an array starting at null.
+func getTypeSize(typ types.Type) constant.Constant {
+	null := constant.NewNull(types.NewPointer(typ))
+	return constant.NewPtrToInt(constant.NewGetElementPtr(typ, null, constant.NewInt(types.I32, 1)), types.I64)
+}
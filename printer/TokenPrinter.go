@@ -0,0 +1,56 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/milandamen/quisnix/lexer"
+)
+
+// TokenPrinter prints a token stream with one token per line, prefixed by its user friendly position.
+// Comments attached to the tokens are printed on their own line at the place they were found.
+type TokenPrinter struct{}
+
+func (p *TokenPrinter) Print(w io.Writer, tokens []lexer.Token) error {
+	for _, token := range tokens {
+		if err := printComments(w, token.LeadingTrivia()); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%d:%d\t%s\n", token.UFLine(), token.UFColumn(), getTokenString(token)); err != nil {
+			return err
+		}
+		if err := printComments(w, token.TrailingTrivia()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printComments(w io.Writer, comments []lexer.Comment) error {
+	for _, c := range comments {
+		if _, err := fmt.Fprintf(w, "%d:%d\tcomment %s\n", c.UFLine(), c.UFColumn(), strconv.QuoteToASCII(c.Text())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getTokenString(token lexer.Token) string {
+	switch t := token.(type) {
+	case lexer.IntegerToken:
+		return fmt.Sprintf("integer %d", t.Integer())
+	case lexer.FloatToken:
+		return "float " + t.Raw()
+	case lexer.CharacterToken:
+		return "character " + strconv.QuoteRuneToASCII(t.Character())
+	case lexer.StringToken:
+		return "string " + strconv.QuoteToASCII(t.String())
+	case lexer.IdentifierToken:
+		return "identifier " + t.Identifier()
+	default:
+		return lexer.GetTokenTypeString(token.Type())
+	}
+}
@@ -16,7 +16,7 @@ import (
 )
 
 var _ = Describe("Printer", func() {
-	FIt("should print correct LLVM IR", func() {
+	It("should print correct LLVM IR", func() {
 		l := lexer.Lexer{}
 		p := parser.Parser{}
 		a := semanalyzer.SemAnalyzer{}
@@ -47,9 +47,1338 @@ func test(asd Int) Int {
 		Expect(mainFunc).ToNot(BeNil())
 
 		b := bytes.Buffer{}
-		Expect(pr.Print(&b, declarations)).To(Succeed())
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
 		fmt.Println(b.String())
 	})
+	It("should print floating-point arithmetic as double", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var f Float;
+	f = half(1.5e1) - 0.5;
+	return Int(f * 2.0);
+}
+
+func half(v Float) Float {
+	return v / 2.0;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(ContainSubstring("call double @qx_uf_half(double 15.0)"))
+		Expect(b.String()).To(ContainSubstring("fsub double %1, 0.5"))
+		Expect(b.String()).To(ContainSubstring("fptosi double %3 to i32"))
+		Expect(b.String()).To(ContainSubstring("fdiv double %v, 2.0"))
+	})
+	It("should print a for loop with phi nodes for the variables it assigns", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var sum Float;
+	for var f Float; f < 10.0; f += 0.5 {
+		sum += f;
+	}
+	return Int(sum);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @main() {
+0:
+	br label %1
+
+1:
+	%2 = phi double [ 0.0, %0 ], [ %7, %5 ]
+	%3 = phi double [ 0.0, %0 ], [ %6, %5 ]
+	%4 = fcmp olt double %2, 10.0
+	br i1 %4, label %5, label %8
+
+5:
+	%6 = fadd double %3, %2
+	%7 = fadd double %2, 0.5
+	br label %1
+
+8:
+	%9 = fptosi double %3 to i32
+	ret i32 %9
+}
+`))
+	})
+	It("should print structs as LLVM struct values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var p Point;
+	p = Point{y: 2};
+	p.x = 3;
+	return p.x * p.y;
+}
+
+type Point { x Int; y Int; }
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @main() {
+0:
+	%1 = insertvalue { i32, i32 } zeroinitializer, i32 2, 1
+	%2 = insertvalue { i32, i32 } %1, i32 3, 0
+	%3 = extractvalue { i32, i32 } %2, 0
+	%4 = extractvalue { i32, i32 } %2, 1
+	%5 = mul i32 %3, %4
+	ret i32 %5
+}
+`))
+	})
+	It("should print slices on the heap with bounds checks", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var a [3]Int;
+	var s []Int;
+	a[2] = 4;
+	s = append(a[1:], 5);
+	return s[2] + at(a, 2) + len(s);
+}
+
+func at(a [3]Int, i Int) Int {
+	return a[i];
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		ir := b.String()
+		Expect(ir).To(ContainSubstring("insertvalue [3 x i32] %3, i32 4, 2"))
+		Expect(ir).To(ContainSubstring("call i8* @malloc(i64 ptrtoint ([3 x i32]* getelementptr ([3 x i32], [3 x i32]* null, i32 1) to i64))"))
+		Expect(ir).To(ContainSubstring("call void @qx.checkSlice(i32 1, i32 %10, i32 %11, i32 6)"))
+		Expect(ir).To(ContainSubstring("call { i8*, i32 } @qx.growSlice(i8* %21, i32 %19, i32 %20, i32 1, i64 ptrtoint (i32* getelementptr (i32, i32* null, i32 1) to i64))"))
+		Expect(ir).To(ContainSubstring("call void @qx.checkIndex(i32 2, i32 %31, i32 7)"))
+		Expect(ir).To(ContainSubstring(`define i32 @qx_uf_at([3 x i32] %a, i32 %i) {
+0:
+	%1 = alloca [3 x i32]
+	call void @qx.checkIndex(i32 %i, i32 3, i32 11)
+	store [3 x i32] %a, [3 x i32]* %1
+	%2 = getelementptr [3 x i32], [3 x i32]* %1, i32 0, i32 %i
+	%3 = load i32, i32* %2
+	ret i32 %3
+}`))
+		Expect(ir).To(ContainSubstring("define internal void @qx.checkIndex(i32 %index, i32 %length, i32 %line)"))
+		Expect(ir).To(ContainSubstring(`c"panic: index out of range [%d] with length %d on line %d\0A\00"`))
+	})
+	It("should let slices of arrays in variables refer to the memory of the variables", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+type Grid {
+	rows [2][3]Int;
+}
+
+func main() Int {
+	var a [3]Int;
+	var g Grid;
+	var u = a[0:2];
+	var v = g.rows[1][:];
+	var w = copied()[:];
+	u[1] = 3;
+	v[2] = 4;
+	w[0] = 5;
+	return a[1] + g.rows[1][2];
+}
+
+func copied() [3]Int {
+	var c [3]Int;
+	return c;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		ir := b.String()
+
+		// The variables are kept on the heap, and the slices point into them.
+		Expect(ir).To(ContainSubstring(`	%2 = bitcast i8* %1 to [3 x i32]*
+	store [3 x i32] zeroinitializer, [3 x i32]* %2`))
+		Expect(ir).To(ContainSubstring(`	%4 = bitcast i8* %3 to { [2 x [3 x i32]] }*
+	store { [2 x [3 x i32]] } zeroinitializer, { [2 x [3 x i32]] }* %4
+	%5 = getelementptr [3 x i32], [3 x i32]* %2, i32 0, i32 0`))
+		Expect(ir).To(ContainSubstring(`	%18 = getelementptr { [2 x [3 x i32]] }, { [2 x [3 x i32]] }* %4, i32 0, i32 0
+	%19 = getelementptr [2 x [3 x i32]], [2 x [3 x i32]]* %18, i32 0, i32 1
+	%20 = getelementptr [3 x i32], [3 x i32]* %19, i32 0, i32 0`))
+
+		// The result of a call is not in a variable, so it is copied to the heap.
+		Expect(ir).To(ContainSubstring(`	%33 = call [3 x i32] @qx_uf_copied()
+	%34 = call i8* @malloc(i64 ptrtoint ([3 x i32]* getelementptr ([3 x i32], [3 x i32]* null, i32 1) to i64))
+	%35 = bitcast i8* %34 to [3 x i32]*
+	store [3 x i32] %33, [3 x i32]* %35`))
+
+		// The variables are read from the heap after the slices changed them.
+		Expect(ir).To(ContainSubstring(`	%58 = load [3 x i32], [3 x i32]* %2
+	%59 = extractvalue [3 x i32] %58, 1
+	%60 = load { [2 x [3 x i32]] }, { [2 x [3 x i32]] }* %4`))
+	})
+	It("should print global variables and fold constants", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+var total = start() + limit;
+const limit = size * 2;
+const size Int = 5;
+var ratio = Float(limit) / 4.0;
+
+func start() Int {
+	return size;
+}
+
+func main() Int {
+	total += limit;
+	return total;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`@qx_uv_total = global i32 0
+@qx_uv_ratio = global double 2.5
+
+define i32 @qx_uf_start() {
+0:
+	ret i32 5
+}
+
+define i32 @main() {
+0:
+	call void @qx.init()
+	%1 = load i32, i32* @qx_uv_total
+	%2 = add i32 %1, 10
+	store i32 %2, i32* @qx_uv_total
+	%3 = load i32, i32* @qx_uv_total
+	ret i32 %3
+}
+
+define internal void @qx.init() {
+0:
+	%1 = call i32 @qx_uf_start()
+	%2 = add i32 %1, 10
+	store i32 %2, i32* @qx_uv_total
+	ret void
+}
+`))
+	})
+	It("should print variables with their values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var a = 3;
+	var b Int = a * 2;
+	var c Int;
+	return a + b + c;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @main() {
+0:
+	%1 = mul i32 3, 2
+	%2 = add i32 3, %1
+	%3 = add i32 %2, 0
+	ret i32 %3
+}
+`))
+	})
+	It("should print prefix operators", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var a = 3;
+	var f = -Float(a);
+	var b = !(a < 0);
+	return -a;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @main() {
+0:
+	%1 = sitofp i32 3 to double
+	%2 = fneg double %1
+	%3 = icmp slt i32 3, 0
+	%4 = xor i1 %3, true
+	%5 = sub i32 0, 3
+	ret i32 %5
+}
+`))
+	})
+	It("should print integer operators", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var a = 47;
+	var b = a % 10 | 8;
+	b <<= a;
+	var c = ~a >> b ^ 3;
+	return b & c;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`@qx.str.0 = private unnamed_addr constant [43 x i8] c"panic: negative shift count %d on line %d\0A\00"
+
+define i32 @main() {
+0:
+	%1 = srem i32 47, 10
+	%2 = or i32 %1, 8
+	%3 = icmp uge i32 47, 32
+	%4 = select i1 %3, i32 0, i32 47
+	%5 = shl i32 %2, %4
+	%6 = select i1 %3, i32 0, i32 %5
+	%7 = xor i32 47, -1
+	call void @qx.checkShift(i32 %6, i32 6)
+	%8 = icmp uge i32 %6, 32
+	%9 = select i1 %8, i32 31, i32 %6
+	%10 = ashr i32 %7, %9
+	%11 = xor i32 %10, 3
+	%12 = and i32 %6, %11
+	ret i32 %12
+}
+
+define internal void @qx.checkShift(i32 %count, i32 %line) {
+0:
+	%1 = icmp sge i32 %count, 0
+	br i1 %1, label %2, label %3
+
+2:
+	ret void
+
+3:
+	%4 = call i32 (i32, i8*, ...) @dprintf(i32 2, i8* getelementptr ([43 x i8], [43 x i8]* @qx.str.0, i32 0, i32 0), i32 %count, i32 %line)
+	call void @llvm.trap()
+	unreachable
+}
+
+declare i32 @dprintf(i32 %fd, i8* %format, ...)
+
+declare void @llvm.trap() noreturn
+`))
+	})
+	It("should shift by counts that are too large without undefined results", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	return shift(40);
+}
+
+func shift(s Int) Int {
+	var b Byte = 200;
+	return (1 << s) + Int(b >> Byte(s));
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(ContainSubstring(`define i32 @qx_uf_shift(i32 %s) {
+0:
+	call void @qx.checkShift(i32 %s, i32 8)
+	%1 = icmp uge i32 %s, 32
+	%2 = select i1 %1, i32 0, i32 %s
+	%3 = shl i32 1, %2
+	%4 = select i1 %1, i32 0, i32 %3
+	%5 = trunc i32 %s to i8
+	%6 = icmp uge i8 %5, 8
+	%7 = select i1 %6, i8 0, i8 %5
+	%8 = lshr i8 200, %7
+	%9 = select i1 %6, i8 0, i8 %8
+`))
+		Expect(b.String()).To(ContainSubstring(`c"panic: negative shift count %d on line %d\0A\00"`))
+	})
+	It("should print compound assignments", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+var g = 10;
+func main() Int {
+	var a = 7;
+	a *= 6;
+	a /= 4;
+	a -= 1;
+	var f = 2.5;
+	f /= 5.0;
+	g += a;
+	return g;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`@qx_uv_g = global i32 10
+
+define i32 @main() {
+0:
+	%1 = mul i32 7, 6
+	%2 = sdiv i32 %1, 4
+	%3 = sub i32 %2, 1
+	%4 = fdiv double 2.5, 5.0
+	%5 = load i32, i32* @qx_uv_g
+	%6 = add i32 %5, %3
+	store i32 %6, i32* @qx_uv_g
+	%7 = load i32, i32* @qx_uv_g
+	ret i32 %7
+}
+`))
+	})
+	It("should merge variables assigned in branches and loops with phi nodes", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var x = 20.0;
+	var steps = 0;
+	while x > 1.0 {
+		if x > 4.0 {
+			x /= 2.0;
+		} else {
+			x = x - 1.0;
+		}
+		steps++;
+	}
+	if x < 0.0 {
+		return 0;
+	}
+	return steps;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @main() {
+0:
+	br label %1
+
+1:
+	%2 = phi double [ 20.0, %0 ], [ %12, %11 ]
+	%3 = phi i32 [ 0, %0 ], [ %13, %11 ]
+	%4 = fcmp ogt double %2, 1.0
+	br i1 %4, label %5, label %14
+
+5:
+	%6 = fcmp ogt double %2, 4.0
+	br i1 %6, label %7, label %9
+
+7:
+	%8 = fdiv double %2, 2.0
+	br label %11
+
+9:
+	%10 = fsub double %2, 1.0
+	br label %11
+
+11:
+	%12 = phi double [ %8, %7 ], [ %10, %9 ]
+	%13 = add i32 %3, 1
+	br label %1
+
+14:
+	%15 = fcmp olt double %2, 0.0
+	br i1 %15, label %16, label %17
+
+16:
+	ret i32 0
+
+17:
+	ret i32 %3
+}
+`))
+	})
+	It("should print integer literals with the integer type they are used as", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var b Byte = 255;
+	b += 1;
+	return Int(b);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @main() {
+0:
+	%1 = add i8 255, 1
+	%2 = zext i8 %1 to i32
+	ret i32 %2
+}
+`))
+	})
+	It("should print bytes, booleans and short-circuit evaluation", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func isDigit(c Byte) Bool {
+	return c >= '0' && c <= '9';
+}
+func main() Int {
+	var b = Byte(250) / 'd';
+	var ok = isDigit(b) || !true;
+	if ok {
+		return 1;
+	}
+	return 0;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i1 @qx_uf_isDigit(i8 %c) {
+0:
+	%1 = icmp uge i8 %c, 48
+	br i1 %1, label %2, label %4
+
+2:
+	%3 = icmp ule i8 %c, 57
+	br label %4
+
+4:
+	%5 = phi i1 [ false, %0 ], [ %3, %2 ]
+	ret i1 %5
+}
+
+define i32 @main() {
+0:
+	%1 = trunc i32 250 to i8
+	%2 = udiv i8 %1, 100
+	%3 = call i1 @qx_uf_isDigit(i8 %2)
+	br i1 %3, label %6, label %4
+
+4:
+	%5 = xor i1 true, true
+	br label %6
+
+6:
+	%7 = phi i1 [ true, %0 ], [ %5, %4 ]
+	br i1 %7, label %8, label %9
+
+8:
+	ret i32 1
+
+9:
+	ret i32 0
+}
+`))
+	})
+	It("should return multiple values through pointer parameters", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func main() Int {
+	var q Int;
+	var r Int;
+	q, r = divmod(47, 10);
+	return q + r;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define void @qx_uf_divmod(i32* %qx.mulret.0, i32* %qx.mulret.1, i32 %a, i32 %b) {
+0:
+	%1 = sdiv i32 %a, %b
+	%2 = srem i32 %a, %b
+	store i32 %1, i32* %qx.mulret.0
+	store i32 %2, i32* %qx.mulret.1
+	ret void
+}
+
+define i32 @main() {
+0:
+	%1 = alloca i32
+	%2 = alloca i32
+	call void @qx_uf_divmod(i32* %1, i32* %2, i32 47, i32 10)
+	%3 = load i32, i32* %1
+	%4 = load i32, i32* %2
+	%5 = add i32 %3, %4
+	ret i32 %5
+}
+`))
+	})
+	It("should print call statements and discarded values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+var total Int;
+func add(n Int) {
+	total += n;
+}
+func twice(n Int) Int {
+	add(n);
+	return n * 2;
+}
+func main() Int {
+	add(3);
+	_ = twice(4);
+	return total;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`@qx_uv_total = global i32 0
+
+define void @qx_uf_add(i32 %n) {
+0:
+	%1 = load i32, i32* @qx_uv_total
+	%2 = add i32 %1, %n
+	store i32 %2, i32* @qx_uv_total
+	ret void
+}
+
+define i32 @qx_uf_twice(i32 %n) {
+0:
+	call void @qx_uf_add(i32 %n)
+	%1 = mul i32 %n, 2
+	ret i32 %1
+}
+
+define i32 @main() {
+0:
+	call void @qx_uf_add(i32 3)
+	%1 = call i32 @qx_uf_twice(i32 4)
+	%2 = load i32, i32* @qx_uv_total
+	ret i32 %2
+}
+`))
+	})
+	It("should print early returns", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func sign(n Int) Int {
+	if n < 0 {
+		return 0 - 1;
+	} else if n == 0 {
+		return 0;
+	} else {
+		return 1;
+	}
+}
+func firstDivisor(n Int) Int {
+	var d = 2;
+	for ;; {
+		if n % d == 0 {
+			return d;
+		}
+		d++;
+	}
+}
+func main() Int {
+	return sign(3) + firstDivisor(91);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @qx_uf_sign(i32 %n) {
+0:
+	%1 = icmp slt i32 %n, 0
+	br i1 %1, label %2, label %4
+
+2:
+	%3 = sub i32 0, 1
+	ret i32 %3
+
+4:
+	%5 = icmp eq i32 %n, 0
+	br i1 %5, label %6, label %7
+
+6:
+	ret i32 0
+
+7:
+	ret i32 1
+}
+
+define i32 @qx_uf_firstDivisor(i32 %n) {
+0:
+	br label %1
+
+1:
+	%2 = phi i32 [ 2, %0 ], [ %8, %7 ]
+	br label %3
+
+3:
+	%4 = srem i32 %n, %2
+	%5 = icmp eq i32 %4, 0
+	br i1 %5, label %6, label %7
+
+6:
+	ret i32 %2
+
+7:
+	%8 = add i32 %2, 1
+	br label %1
+
+9:
+	unreachable
+}
+
+define i32 @main() {
+0:
+	%1 = call i32 @qx_uf_sign(i32 3)
+	%2 = call i32 @qx_uf_firstDivisor(i32 91)
+	%3 = add i32 %1, %2
+	ret i32 %3
+}
+`))
+	})
+	It("should merge variables at the targets of break and continue statements", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func firstMultiple(n Int, limit Int) Int {
+	var found = 0;
+	for var i = 1; i < limit; i++ {
+		if i % n != 0 {
+			continue;
+		}
+		found = i;
+		break;
+	}
+	return found;
+}
+func sumOdd(limit Int) Int {
+	var sum = 0;
+	var i = 0;
+	while i < limit {
+		i++;
+		if i % 2 == 0 {
+			continue;
+		}
+		sum += i;
+	}
+	return sum;
+}
+func main() Int {
+	return firstMultiple(7, 30) + sumOdd(10);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`define i32 @qx_uf_firstMultiple(i32 %n, i32 %limit) {
+0:
+	br label %1
+
+1:
+	%2 = phi i32 [ 1, %0 ], [ %11, %10 ]
+	%3 = phi i32 [ 0, %0 ], [ %3, %10 ]
+	%4 = icmp slt i32 %2, %limit
+	br i1 %4, label %5, label %12
+
+5:
+	%6 = srem i32 %2, %n
+	%7 = icmp ne i32 %6, 0
+	br i1 %7, label %8, label %9
+
+8:
+	br label %10
+
+9:
+	br label %12
+
+10:
+	%11 = add i32 %2, 1
+	br label %1
+
+12:
+	%13 = phi i32 [ %3, %1 ], [ %2, %9 ]
+	ret i32 %13
+}
+
+define i32 @qx_uf_sumOdd(i32 %limit) {
+0:
+	br label %1
+
+1:
+	%2 = phi i32 [ 0, %0 ], [ %6, %9 ], [ %6, %10 ]
+	%3 = phi i32 [ 0, %0 ], [ %3, %9 ], [ %11, %10 ]
+	%4 = icmp slt i32 %2, %limit
+	br i1 %4, label %5, label %12
+
+5:
+	%6 = add i32 %2, 1
+	%7 = srem i32 %6, 2
+	%8 = icmp eq i32 %7, 0
+	br i1 %8, label %9, label %10
+
+9:
+	br label %1
+
+10:
+	%11 = add i32 %3, %6
+	br label %1
+
+12:
+	ret i32 %3
+}
+
+define i32 @main() {
+0:
+	%1 = call i32 @qx_uf_firstMultiple(i32 7, i32 30)
+	%2 = call i32 @qx_uf_sumOdd(i32 10)
+	%3 = add i32 %1, %2
+	ret i32 %3
+}
+`))
+	})
+	It("should call functions through function values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func double(x Int) Int {
+	return x * 2;
+}
+func apply(f func(Int) Int, x Int) Int {
+	return f(x);
+}
+func pick(twice Bool) func(Int) Int {
+	var f func(Int) Int;
+	if twice {
+		f = double;
+	}
+	return f;
+}
+func main() Int {
+	return apply(double, 3) + pick(true)(5);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`@qx.str.0 = private unnamed_addr constant [46 x i8] c"panic: call of nil function value on line %d\0A\00"
+
+define i32 @qx_uf_double(i32 %x) {
+0:
+	%1 = mul i32 %x, 2
+	ret i32 %1
+}
+
+define i32 @qx_uf_apply({ i32 (i8*, i32)*, i8* } %f, i32 %x) {
+0:
+	%1 = extractvalue { i32 (i8*, i32)*, i8* } %f, 0
+	%2 = extractvalue { i32 (i8*, i32)*, i8* } %f, 1
+	%3 = bitcast i32 (i8*, i32)* %1 to i8*
+	call void @qx.checkCall(i8* %3, i32 6)
+	%4 = call i32 %1(i8* %2, i32 %x)
+	ret i32 %4
+}
+
+define { i32 (i8*, i32)*, i8* } @qx_uf_pick(i1 %twice) {
+0:
+	br i1 %twice, label %1, label %2
+
+1:
+	br label %2
+
+2:
+	%3 = phi { i32 (i8*, i32)*, i8* } [ { i32 (i8*, i32)* @qx_uf_double.value, i8* null }, %1 ], [ zeroinitializer, %0 ]
+	ret { i32 (i8*, i32)*, i8* } %3
+}
+
+define i32 @main() {
+0:
+	%1 = call i32 @qx_uf_apply({ i32 (i8*, i32)*, i8* } { i32 (i8*, i32)* @qx_uf_double.value, i8* null }, i32 3)
+	%2 = call { i32 (i8*, i32)*, i8* } @qx_uf_pick(i1 true)
+	%3 = extractvalue { i32 (i8*, i32)*, i8* } %2, 0
+	%4 = extractvalue { i32 (i8*, i32)*, i8* } %2, 1
+	%5 = bitcast i32 (i8*, i32)* %3 to i8*
+	call void @qx.checkCall(i8* %5, i32 16)
+	%6 = call i32 %3(i8* %4, i32 5)
+	%7 = add i32 %1, %6
+	ret i32 %7
+}
+
+define internal void @qx.checkCall(i8* %code, i32 %line) {
+0:
+	%1 = icmp ne i8* %code, null
+	br i1 %1, label %2, label %3
+
+2:
+	ret void
+
+3:
+	%4 = call i32 (i32, i8*, ...) @dprintf(i32 2, i8* getelementptr ([46 x i8], [46 x i8]* @qx.str.0, i32 0, i32 0), i32 %line)
+	call void @llvm.trap()
+	unreachable
+}
+
+declare i32 @dprintf(i32 %fd, i8* %format, ...)
+
+declare void @llvm.trap() noreturn
+
+define internal i32 @qx_uf_double.value(i8* %qx.env, i32 %x) {
+0:
+	%1 = call i32 @qx_uf_double(i32 %x)
+	ret i32 %1
+}
+`))
+	})
+	It("should share captured variables on the heap with function literals", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func counter(step Int) func() Int {
+	var n = 0;
+	return func() Int {
+		n += step;
+		return n;
+	};
+}
+func main() Int {
+	var next = counter(3);
+	_ = next();
+	var twice = func(x Int) Int {
+		return x * 2;
+	};
+	return twice(next());
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`@qx.str.0 = private unnamed_addr constant [46 x i8] c"panic: call of nil function value on line %d\0A\00"
+
+define { i32 (i8*)*, i8* } @qx_uf_counter(i32 %step) {
+0:
+	%1 = call i8* @malloc(i64 ptrtoint (i32* getelementptr (i32, i32* null, i32 1) to i64))
+	%2 = bitcast i8* %1 to i32*
+	store i32 %step, i32* %2
+	%3 = call i8* @malloc(i64 ptrtoint (i32* getelementptr (i32, i32* null, i32 1) to i64))
+	%4 = bitcast i8* %3 to i32*
+	store i32 0, i32* %4
+	%5 = call i8* @malloc(i64 ptrtoint ({ i32*, i32* }* getelementptr ({ i32*, i32* }, { i32*, i32* }* null, i32 1) to i64))
+	%6 = bitcast i8* %5 to { i32*, i32* }*
+	%7 = getelementptr { i32*, i32* }, { i32*, i32* }* %6, i32 0, i32 0
+	store i32* %4, i32** %7
+	%8 = getelementptr { i32*, i32* }, { i32*, i32* }* %6, i32 0, i32 1
+	store i32* %2, i32** %8
+	%9 = insertvalue { i32 (i8*)*, i8* } zeroinitializer, i32 (i8*)* @qx_uf_counter.func1, 0
+	%10 = insertvalue { i32 (i8*)*, i8* } %9, i8* %5, 1
+	ret { i32 (i8*)*, i8* } %10
+}
+
+define i32 @main() {
+0:
+	%1 = call { i32 (i8*)*, i8* } @qx_uf_counter(i32 3)
+	%2 = extractvalue { i32 (i8*)*, i8* } %1, 0
+	%3 = extractvalue { i32 (i8*)*, i8* } %1, 1
+	%4 = bitcast i32 (i8*)* %2 to i8*
+	call void @qx.checkCall(i8* %4, i32 11)
+	%5 = call i32 %2(i8* %3)
+	%6 = extractvalue { i32 (i8*)*, i8* } %1, 0
+	%7 = extractvalue { i32 (i8*)*, i8* } %1, 1
+	%8 = bitcast i32 (i8*)* %6 to i8*
+	call void @qx.checkCall(i8* %8, i32 15)
+	%9 = call i32 %6(i8* %7)
+	%10 = call i32 @main.func1(i8* null, i32 %9)
+	ret i32 %10
+}
+
+declare i8* @malloc(i64 %size)
+
+define internal i32 @qx_uf_counter.func1(i8* %qx.env) {
+0:
+	%1 = bitcast i8* %qx.env to { i32*, i32* }*
+	%2 = getelementptr { i32*, i32* }, { i32*, i32* }* %1, i32 0, i32 0
+	%3 = load i32*, i32** %2
+	%4 = getelementptr { i32*, i32* }, { i32*, i32* }* %1, i32 0, i32 1
+	%5 = load i32*, i32** %4
+	%6 = load i32, i32* %3
+	%7 = load i32, i32* %5
+	%8 = add i32 %6, %7
+	store i32 %8, i32* %3
+	%9 = load i32, i32* %3
+	ret i32 %9
+}
+
+define internal void @qx.checkCall(i8* %code, i32 %line) {
+0:
+	%1 = icmp ne i8* %code, null
+	br i1 %1, label %2, label %3
+
+2:
+	ret void
+
+3:
+	%4 = call i32 (i32, i8*, ...) @dprintf(i32 2, i8* getelementptr ([46 x i8], [46 x i8]* @qx.str.0, i32 0, i32 0), i32 %line)
+	call void @llvm.trap()
+	unreachable
+}
+
+declare i32 @dprintf(i32 %fd, i8* %format, ...)
+
+declare void @llvm.trap() noreturn
+
+define internal i32 @main.func1(i8* %qx.env, i32 %x) {
+0:
+	%1 = mul i32 %x, 2
+	ret i32 %1
+}
+`))
+	})
+	It("should return multiple values from function literals with and without captured variables", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+		pr := printer.LLVMPrinter{}
+
+		program := `
+func main() Int {
+	var k = 5;
+	var f = func() (Int, Bool) {
+		return 7, true;
+	};
+	var g = func(n Int) (Int, Int) {
+		return n + k, n * k;
+	};
+	var a Int;
+	var ok Bool;
+	a, ok = f();
+	var s Int;
+	var m Int;
+	s, m = g(2);
+	if ok {
+		return a + s + m;
+	}
+	return 0;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		b := bytes.Buffer{}
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
+		Expect(b.String()).To(Equal(`@qx.str.0 = private unnamed_addr constant [46 x i8] c"panic: call of nil function value on line %d\0A\00"
+
+define i32 @main() {
+0:
+	%1 = alloca i32
+	%2 = alloca i1
+	%3 = alloca i32
+	%4 = alloca i32
+	%5 = call i8* @malloc(i64 ptrtoint (i32* getelementptr (i32, i32* null, i32 1) to i64))
+	%6 = bitcast i8* %5 to i32*
+	store i32 5, i32* %6
+	%7 = call i8* @malloc(i64 ptrtoint ({ i32* }* getelementptr ({ i32* }, { i32* }* null, i32 1) to i64))
+	%8 = bitcast i8* %7 to { i32* }*
+	%9 = getelementptr { i32* }, { i32* }* %8, i32 0, i32 0
+	store i32* %6, i32** %9
+	%10 = insertvalue { void (i8*, i32*, i32*, i32)*, i8* } zeroinitializer, void (i8*, i32*, i32*, i32)* @main.func2, 0
+	%11 = insertvalue { void (i8*, i32*, i32*, i32)*, i8* } %10, i8* %7, 1
+	call void @main.func1(i8* null, i32* %1, i1* %2)
+	%12 = load i32, i32* %1
+	%13 = load i1, i1* %2
+	%14 = extractvalue { void (i8*, i32*, i32*, i32)*, i8* } %11, 0
+	%15 = extractvalue { void (i8*, i32*, i32*, i32)*, i8* } %11, 1
+	%16 = bitcast void (i8*, i32*, i32*, i32)* %14 to i8*
+	call void @qx.checkCall(i8* %16, i32 15)
+	call void %14(i8* %15, i32* %3, i32* %4, i32 2)
+	%17 = load i32, i32* %3
+	%18 = load i32, i32* %4
+	br i1 %13, label %19, label %22
+
+19:
+	%20 = add i32 %12, %17
+	%21 = add i32 %20, %18
+	ret i32 %21
+
+22:
+	ret i32 0
+}
+
+declare i8* @malloc(i64 %size)
+
+define internal void @main.func1(i8* %qx.env, i32* %qx.mulret.0, i1* %qx.mulret.1) {
+0:
+	store i32 7, i32* %qx.mulret.0
+	store i1 true, i1* %qx.mulret.1
+	ret void
+}
+
+define internal void @main.func2(i8* %qx.env, i32* %qx.mulret.0, i32* %qx.mulret.1, i32 %n) {
+0:
+	%1 = bitcast i8* %qx.env to { i32* }*
+	%2 = getelementptr { i32* }, { i32* }* %1, i32 0, i32 0
+	%3 = load i32*, i32** %2
+	%4 = load i32, i32* %3
+	%5 = add i32 %n, %4
+	%6 = load i32, i32* %3
+	%7 = mul i32 %n, %6
+	store i32 %5, i32* %qx.mulret.0
+	store i32 %7, i32* %qx.mulret.1
+	ret void
+}
+
+define internal void @qx.checkCall(i8* %code, i32 %line) {
+0:
+	%1 = icmp ne i8* %code, null
+	br i1 %1, label %2, label %3
+
+2:
+	ret void
+
+3:
+	%4 = call i32 (i32, i8*, ...) @dprintf(i32 2, i8* getelementptr ([46 x i8], [46 x i8]* @qx.str.0, i32 0, i32 0), i32 %line)
+	call void @llvm.trap()
+	unreachable
+}
+
+declare i32 @dprintf(i32 %fd, i8* %format, ...)
+
+declare void @llvm.trap() noreturn
+`))
+	})
 	PIt("should print correct LLVM IR", func() {
 		l := lexer.Lexer{}
 		p := parser.Parser{}
@@ -90,7 +1419,7 @@ func test(asd Int) Int {
 		Expect(mainFunc).ToNot(BeNil())
 
 		b := bytes.Buffer{}
-		Expect(pr.Print(&b, declarations)).To(Succeed())
+		Expect(pr.Print(&b, declarations, fileScope)).To(Succeed())
 		fmt.Println(b.String())
 	})
 })
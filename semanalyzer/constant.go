@@ -0,0 +1,283 @@
+package semanalyzer
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/milandamen/quisnix/parser"
+	"github.com/pkg/errors"
+)
+
+// constantValue computes the value of an expression at compile time. Integers, including bytes, result in a *big.Int,
+// floats in a float64 and booleans in a bool. When the expression depends on something only known while the program
+// runs, false is returned. Must only be used on expressions that passed the type checks.
+func constantValue(expression parser.Expression) (interface{}, bool, error) {
+	switch e := expression.(type) {
+	case *parser.IntegerLiteralExpression:
+		return new(big.Int).Set(e.Value), true, nil
+	case *parser.CharacterLiteralExpression:
+		return big.NewInt(int64(e.Value)), true, nil
+	case *parser.FloatLiteralExpression:
+		return e.Value, true, nil
+	case *parser.BooleanLiteralExpression:
+		return e.Value, true, nil
+	case *parser.IdentifierExpression:
+		d, ok := e.IdentifierDeclaration.(*parser.VariableDeclaration)
+		if !ok || !d.Constant {
+			return nil, false, nil
+		}
+
+		return constantValue(d.Value)
+	case *parser.NotExpression:
+		v, ok, err := constantValue(e.Expression)
+		if !ok || err != nil {
+			return nil, ok, err
+		}
+
+		return !v.(bool), true, nil
+	case *parser.NegateExpression:
+		v, ok, err := constantValue(e.Expression)
+		if !ok || err != nil {
+			return nil, ok, err
+		}
+
+		if f, ok := v.(float64); ok {
+			return -f, true, nil
+		}
+
+		i := new(big.Int).Neg(v.(*big.Int))
+		tds, err := parser.MustSingleReturnType(e)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := checkConstantRange(e, i, tds[0]); err != nil {
+			return nil, false, err
+		}
+
+		return i, true, nil
+	case *parser.BitwiseNotExpression:
+		v, ok, err := constantValue(e.Expression)
+		if !ok || err != nil {
+			return nil, ok, err
+		}
+
+		tds, err := parser.MustSingleReturnType(e)
+		if err != nil {
+			return nil, false, err
+		}
+
+		// An unsigned integer has no sign bit to flip, so its bits are flipped within its range.
+		min, max, _ := tds[0].Type.(parser.BasicType).IntegerRange()
+		if min.Sign() == 0 {
+			return new(big.Int).Sub(max, v.(*big.Int)), true, nil
+		}
+		return new(big.Int).Not(v.(*big.Int)), true, nil
+	case *parser.ConversionExpression:
+		v, ok, err := constantValue(e.Expression)
+		if !ok || err != nil {
+			return nil, ok, err
+		}
+
+		return convertConstant(e, v, e.TypeDeclaration)
+	case *parser.AndExpression:
+		return foldBoolConstants(e.Left, e.Right, func(l, r bool) bool { return l && r })
+	case *parser.OrExpression:
+		return foldBoolConstants(e.Left, e.Right, func(l, r bool) bool { return l || r })
+	case *parser.AddExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).Add, func(l, r float64) float64 { return l + r })
+	case *parser.SubtractExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).Sub, func(l, r float64) float64 { return l - r })
+	case *parser.MultiplyExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).Mul, func(l, r float64) float64 { return l * r })
+	case *parser.DivideExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).Quo, func(l, r float64) float64 { return l / r })
+	case *parser.ModuloExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).Rem, nil)
+	case *parser.BitwiseAndExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).And, nil)
+	case *parser.BitwiseOrExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).Or, nil)
+	case *parser.BitwiseXorExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, (*big.Int).Xor, nil)
+	case *parser.ShiftLeftExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, shiftLeftConstant, nil)
+	case *parser.ShiftRightExpression:
+		return foldArithmeticConstants(e, e.Left, e.Right, shiftRightConstant, nil)
+	case *parser.EqualExpression:
+		return foldComparisonConstants(e.Left, e.Right, func(c int) bool { return c == 0 })
+	case *parser.NotEqualExpression:
+		return foldComparisonConstants(e.Left, e.Right, func(c int) bool { return c != 0 })
+	case *parser.LessExpression:
+		return foldComparisonConstants(e.Left, e.Right, func(c int) bool { return c < 0 })
+	case *parser.LessOrEqualExpression:
+		return foldComparisonConstants(e.Left, e.Right, func(c int) bool { return c <= 0 })
+	case *parser.GreaterExpression:
+		return foldComparisonConstants(e.Left, e.Right, func(c int) bool { return c > 0 })
+	case *parser.GreaterOrEqualExpression:
+		return foldComparisonConstants(e.Left, e.Right, func(c int) bool { return c >= 0 })
+	default:
+		return nil, false, nil
+	}
+}
+
+// constantOperands computes both sides of an expression, returning false when either one is not constant.
+func constantOperands(left parser.Expression, right parser.Expression) (interface{}, interface{}, bool, error) {
+	l, ok, err := constantValue(left)
+	if !ok || err != nil {
+		return nil, nil, ok, err
+	}
+
+	r, ok, err := constantValue(right)
+	if !ok || err != nil {
+		return nil, nil, ok, err
+	}
+
+	return l, r, true, nil
+}
+
+func foldBoolConstants(left parser.Expression, right parser.Expression, op func(l, r bool) bool) (interface{}, bool, error) {
+	l, r, ok, err := constantOperands(left, right)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+
+	return op(l.(bool), r.(bool)), true, nil
+}
+
+func foldArithmeticConstants(e parser.Expression, left parser.Expression, right parser.Expression,
+	intOp func(z, x, y *big.Int) *big.Int, floatOp func(l, r float64) float64) (interface{}, bool, error) {
+
+	l, r, ok, err := constantOperands(left, right)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+
+	switch e.(type) {
+	case *parser.DivideExpression, *parser.ModuloExpression:
+		if isZeroConstant(r) {
+			return nil, false, errors.Errorf("division by zero on line %d column %d", e.UFSourceLine(), e.UFSourceColumn())
+		}
+	case *parser.ShiftLeftExpression, *parser.ShiftRightExpression:
+		if r.(*big.Int).Sign() < 0 {
+			return nil, false, errors.Errorf("negative shift count %s on line %d column %d",
+				r.(*big.Int).String(), e.UFSourceLine(), e.UFSourceColumn())
+		}
+	}
+
+	if lf, ok := l.(float64); ok {
+		return floatOp(lf, r.(float64)), true, nil
+	}
+
+	v := intOp(new(big.Int), l.(*big.Int), r.(*big.Int))
+	tds, err := parser.MustSingleReturnType(e)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := checkConstantRange(e, v, tds[0]); err != nil {
+		return nil, false, err
+	}
+
+	return v, true, nil
+}
+
+func foldComparisonConstants(left parser.Expression, right parser.Expression, op func(c int) bool) (interface{}, bool, error) {
+	l, r, ok, err := constantOperands(left, right)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+
+	switch lv := l.(type) {
+	case *big.Int:
+		return op(lv.Cmp(r.(*big.Int))), true, nil
+	case float64:
+		rv := r.(float64)
+		if lv != lv || rv != rv {
+			// NaN is not equal to anything, not even itself.
+			return op(1) && op(-1), true, nil
+		}
+		return op(big.NewFloat(lv).Cmp(big.NewFloat(rv))), true, nil
+	case bool:
+		c := 0
+		if lv != r.(bool) {
+			c = 1
+		}
+		return op(c), true, nil
+	default:
+		return nil, false, errors.Errorf("compiler error: unknown constant type %T", l)
+	}
+}
+
+func convertConstant(e parser.Expression, v interface{}, target *parser.TypeDeclaration) (interface{}, bool, error) {
+	t, ok := target.Type.(parser.BasicType)
+	if !ok {
+		return nil, false, errors.Errorf("compiler error: cannot convert constant to type '%s'", target.Type.TypeName())
+	}
+
+	if t.DataType == parser.FloatDataType {
+		if i, ok := v.(*big.Int); ok {
+			f, _ := new(big.Float).SetInt(i).Float64()
+			return f, true, nil
+		}
+
+		return v, true, nil
+	}
+
+	i, ok := v.(*big.Int)
+	if !ok {
+		f := v.(float64)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false, errors.Errorf("constant %g overflows type '%s' on line %d column %d",
+				f, t.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+		}
+
+		i, _ = big.NewFloat(math.Trunc(f)).Int(nil)
+	}
+
+	if err := checkConstantRange(e, i, target); err != nil {
+		return nil, false, err
+	}
+
+	return i, true, nil
+}
+
+// maxConstantShift is larger than the size of any integer type, so shifting further gives the same result.
+const maxConstantShift = 64
+
+func shiftLeftConstant(z, x, y *big.Int) *big.Int {
+	if y.Cmp(big.NewInt(maxConstantShift)) > 0 {
+		y = big.NewInt(maxConstantShift)
+	}
+
+	return z.Lsh(x, uint(y.Uint64()))
+}
+
+func shiftRightConstant(z, x, y *big.Int) *big.Int {
+	if y.Cmp(big.NewInt(maxConstantShift)) > 0 {
+		y = big.NewInt(maxConstantShift)
+	}
+
+	return z.Rsh(x, uint(y.Uint64()))
+}
+
+func isZeroConstant(v interface{}) bool {
+	switch v := v.(type) {
+	case *big.Int:
+		return v.Sign() == 0
+	case float64:
+		return v == 0
+	default:
+		return false
+	}
+}
+
+func checkConstantRange(e parser.Expression, v *big.Int, td *parser.TypeDeclaration) error {
+	if t, ok := td.Type.(parser.BasicType); ok {
+		if min, max, ok := t.IntegerRange(); ok && (v.Cmp(min) < 0 || v.Cmp(max) > 0) {
+			return errors.Errorf("constant %s overflows type '%s' on line %d column %d",
+				v.String(), t.TypeName(), e.UFSourceLine(), e.UFSourceColumn())
+		}
+	}
+
+	return nil
+}
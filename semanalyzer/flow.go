@@ -0,0 +1,136 @@
+package semanalyzer
+
+import (
+	"github.com/milandamen/quisnix/parser"
+	"github.com/pkg/errors"
+)
+
+// FlowChecker makes sure every path through a function that returns values ends in a return statement, and that no
+// statement follows a statement after which execution never continues. Break and continue statements must be in the
+// loop they refer to.
+type FlowChecker struct {
+	brokenLoops map[parser.Statement]bool // Loops that are left by a break statement.
+}
+
+func (c *FlowChecker) Execute(declarations []parser.Declaration, scope parser.Scope) error {
+	c.brokenLoops = make(map[parser.Statement]bool)
+	for _, decl := range declarations {
+		if d, ok := decl.(*parser.FunctionDeclaration); ok {
+			if err := c.checkFunctionDefinition(d.FunctionDefinition, d); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, literal := range getFunctionLiterals(scope) {
+		if err := c.checkFunctionDefinition(literal.FunctionDefinition, literal); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkFunctionDefinition checks the statements of a function, which is declared or defined by a function literal at
+// the node.
+func (c *FlowChecker) checkFunctionDefinition(def *parser.FunctionDefinition, node parser.Node) error {
+	terminates, err := c.checkStatements(def.Statements)
+	if err != nil {
+		return err
+	}
+
+	if !terminates && len(def.FunctionType.ReturnTypes) > 0 {
+		return errors.Errorf("function should return values on line %d column %d",
+			node.UFSourceLine(), node.UFSourceColumn())
+	}
+
+	return nil
+}
+
+// checkStatements returns whether execution never continues after the statements, because every path through them
+// ends in a return, break or continue statement.
+func (c *FlowChecker) checkStatements(statements []parser.Statement) (bool, error) {
+	terminates := false
+	for _, stmt := range statements {
+		if terminates {
+			return false, errors.Errorf("unreachable code on line %d column %d", stmt.UFSourceLine(), stmt.UFSourceColumn())
+		}
+
+		var err error
+		terminates, err = c.checkStatement(stmt)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return terminates, nil
+}
+
+func (c *FlowChecker) checkStatement(statement parser.Statement) (bool, error) {
+	switch s := statement.(type) {
+	case *parser.ReturnStatement:
+		return true, nil
+	case *parser.BreakStatement:
+		if err := checkBranchLoop("break", s.Label, s.Loop, s); err != nil {
+			return false, err
+		}
+
+		c.brokenLoops[s.Loop] = true
+		return true, nil
+	case *parser.ContinueStatement:
+		if err := checkBranchLoop("continue", s.Label, s.Loop, s); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	case *parser.IfStatement:
+		thenTerminates, err := c.checkStatements(s.ThenStatements)
+		if err != nil {
+			return false, err
+		}
+
+		elseTerminates, err := c.checkStatements(s.ElseStatements)
+		if err != nil {
+			return false, err
+		}
+
+		// Without else statements, execution continues when the condition is false.
+		return thenTerminates && elseTerminates && len(s.ElseStatements) > 0, nil
+	case *parser.ForStatement:
+		if _, err := c.checkStatements(s.Statements); err != nil {
+			return false, err
+		}
+
+		// A loop without a condition can only be left by returning or breaking.
+		return s.Condition == nil && !c.brokenLoops[s], nil
+	case *parser.WhileStatement:
+		if _, err := c.checkStatements(s.Statements); err != nil {
+			return false, err
+		}
+
+		// Like a for loop without a condition, a loop whose condition is always true can only be left by returning or
+		// breaking.
+		v, ok, err := constantValue(s.Condition)
+		if err != nil {
+			return false, err
+		}
+
+		return ok && v == true && !c.brokenLoops[s], nil
+	default:
+		return false, nil
+	}
+}
+
+// checkBranchLoop makes sure a break or continue statement is in the loop it refers to.
+func checkBranchLoop(keyword string, label string, loop parser.Statement, stmt parser.Node) error {
+	if loop != nil {
+		return nil
+	}
+
+	if label != "" {
+		return errors.Errorf("%s refers to label '%s' that is not on a surrounding loop on line %d column %d",
+			keyword, label, stmt.UFSourceLine(), stmt.UFSourceColumn())
+	}
+
+	return errors.Errorf("%s outside of a loop on line %d column %d", keyword, stmt.UFSourceLine(), stmt.UFSourceColumn())
+}
@@ -0,0 +1,295 @@
+package semanalyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/milandamen/quisnix/parser"
+	"github.com/pkg/errors"
+)
+
+// globalInitializationOrder returns the variables and constants of the file scope in the order their values must be
+// computed. A variable depends on the variables its value refers to, directly or through the functions it calls. The
+// next variable to initialize is always the earliest declared one whose dependencies are initialized. An error is
+// returned when a value refers back to its own variable.
+func globalInitializationOrder(declarations []parser.Declaration) ([]*parser.VariableDeclaration, error) {
+	g := newInitializationGraph()
+	globals := make([]*parser.VariableDeclaration, 0)
+	for _, decl := range declarations {
+		if d, ok := decl.(*parser.VariableDeclaration); ok {
+			g.globals[d] = true
+			globals = append(globals, d)
+		}
+	}
+
+	for _, d := range globals {
+		if path := g.findPath(d, d); path != nil {
+			names := make([]string, 0, len(path))
+			for i, decl := range path[:len(path)-1] {
+				names = append(names, fmt.Sprintf("'%s' refers to '%s'", declarationName(decl), declarationName(path[i+1])))
+			}
+
+			return nil, errors.Errorf("initialization cycle for '%s' on line %d column %d: %s",
+				d.Name, d.UFSourceLine(), d.UFSourceColumn(), strings.Join(names, ", "))
+		}
+	}
+
+	dependencies := make(map[*parser.VariableDeclaration][]*parser.VariableDeclaration)
+	for _, d := range globals {
+		dependencies[d] = g.dependencies(d)
+	}
+
+	initialized := make(map[*parser.VariableDeclaration]bool)
+	order := make([]*parser.VariableDeclaration, 0, len(globals))
+	for len(order) < len(globals) {
+		for _, d := range globals {
+			if initialized[d] {
+				continue
+			}
+
+			ready := true
+			for _, dep := range dependencies[d] {
+				if !initialized[dep] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				initialized[d] = true
+				order = append(order, d)
+				break
+			}
+		}
+	}
+
+	return order, nil
+}
+
+// initializationGraph links global variables and functions to the global variables and functions they refer to.
+type initializationGraph struct {
+	globals    map[*parser.VariableDeclaration]bool
+	references map[parser.Declaration][]parser.Declaration
+}
+
+func newInitializationGraph() *initializationGraph {
+	return &initializationGraph{
+		globals:    make(map[*parser.VariableDeclaration]bool),
+		references: make(map[parser.Declaration][]parser.Declaration),
+	}
+}
+
+// getReferences returns the global variables and functions referred to by the value of a global variable or the body
+// of a function, in the order they are referred to.
+func (g *initializationGraph) getReferences(decl parser.Declaration) []parser.Declaration {
+	if refs, ok := g.references[decl]; ok {
+		return refs
+	}
+
+	refs := make([]parser.Declaration, 0)
+	seen := make(map[parser.Declaration]bool)
+	visit := func(d parser.Declaration) {
+		if seen[d] {
+			return
+		}
+
+		switch d := d.(type) {
+		case *parser.VariableDeclaration:
+			if !g.globals[d] {
+				return
+			}
+		case *parser.FunctionDeclaration:
+		default:
+			return
+		}
+
+		seen[d] = true
+		refs = append(refs, d)
+	}
+
+	switch d := decl.(type) {
+	case *parser.VariableDeclaration:
+		if d.Value != nil {
+			walkExpressionReferences(d.Value, visit)
+		}
+	case *parser.FunctionDeclaration:
+		walkStatementReferences(d.FunctionDefinition.Statements, visit)
+	}
+
+	g.references[decl] = refs
+	return refs
+}
+
+// dependencies returns the global variables that must be initialized before the variable. Functions are followed,
+// variables are not as their own dependencies are initialized before them.
+func (g *initializationGraph) dependencies(decl *parser.VariableDeclaration) []*parser.VariableDeclaration {
+	deps := make([]*parser.VariableDeclaration, 0)
+	visited := make(map[parser.Declaration]bool)
+
+	var visit func(d parser.Declaration)
+	visit = func(d parser.Declaration) {
+		for _, ref := range g.getReferences(d) {
+			if visited[ref] {
+				continue
+			}
+			visited[ref] = true
+
+			if v, ok := ref.(*parser.VariableDeclaration); ok {
+				deps = append(deps, v)
+			} else {
+				visit(ref)
+			}
+		}
+	}
+
+	visit(decl)
+	return deps
+}
+
+// findPath returns the declarations on the way from one declaration to another, including both, or nil when there is
+// no such way.
+func (g *initializationGraph) findPath(from parser.Declaration, to parser.Declaration) []parser.Declaration {
+	visited := make(map[parser.Declaration]bool)
+
+	var find func(d parser.Declaration) []parser.Declaration
+	find = func(d parser.Declaration) []parser.Declaration {
+		for _, ref := range g.getReferences(d) {
+			if ref == to {
+				return []parser.Declaration{d, ref}
+			}
+			if visited[ref] {
+				continue
+			}
+			visited[ref] = true
+
+			if path := find(ref); path != nil {
+				return append([]parser.Declaration{d}, path...)
+			}
+		}
+
+		return nil
+	}
+
+	return find(from)
+}
+
+func declarationName(decl parser.Declaration) string {
+	switch d := decl.(type) {
+	case *parser.VariableDeclaration:
+		return d.Name
+	case *parser.FunctionDeclaration:
+		return d.Name
+	default:
+		return decl.DeclarationType()
+	}
+}
+
+// walkStatementReferences calls visit for every declaration the statements refer to.
+func walkStatementReferences(statements []parser.Statement, visit func(d parser.Declaration)) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *parser.VariableDeclaration:
+			if s.Value != nil {
+				walkExpressionReferences(s.Value, visit)
+			}
+		case *parser.AssignStatement:
+			visit(s.VariableDeclaration)
+			walkExpressionReferences(s.Expression, visit)
+		case *parser.MultiAssignStatement:
+			for _, d := range s.VariableDeclarations {
+				visit(d)
+			}
+			walkExpressionReferences(s.Expression, visit)
+		case *parser.DiscardStatement:
+			walkExpressionReferences(s.Expression, visit)
+		case *parser.CompoundAssignStatement:
+			walkExpressionReferences(s.Operation(), visit)
+		case *parser.FieldAssignStatement:
+			walkExpressionReferences(s.Target, visit)
+			walkExpressionReferences(s.Expression, visit)
+		case *parser.IndexAssignStatement:
+			walkExpressionReferences(s.Target, visit)
+			walkExpressionReferences(s.Expression, visit)
+		case *parser.IncrementStatement:
+			visit(s.VariableDeclaration)
+		case *parser.DecrementStatement:
+			visit(s.VariableDeclaration)
+		case *parser.IfStatement:
+			walkExpressionReferences(s.Condition, visit)
+			walkStatementReferences(s.ThenStatements, visit)
+			walkStatementReferences(s.ElseStatements, visit)
+		case *parser.ForStatement:
+			if s.Init != nil {
+				walkStatementReferences([]parser.Statement{s.Init}, visit)
+			}
+			if s.Condition != nil {
+				walkExpressionReferences(s.Condition, visit)
+			}
+			if s.LoopAction != nil {
+				walkStatementReferences([]parser.Statement{s.LoopAction}, visit)
+			}
+			walkStatementReferences(s.Statements, visit)
+		case *parser.WhileStatement:
+			walkExpressionReferences(s.Condition, visit)
+			walkStatementReferences(s.Statements, visit)
+		case *parser.ReturnStatement:
+			for _, exp := range s.ReturnExpressions {
+				walkExpressionReferences(exp, visit)
+			}
+		case parser.Expression:
+			walkExpressionReferences(s, visit)
+		}
+	}
+}
+
+// walkExpressionReferences calls visit for every declaration the expression refers to.
+func walkExpressionReferences(expression parser.Expression, visit func(d parser.Declaration)) {
+	switch e := expression.(type) {
+	case *parser.IdentifierExpression:
+		visit(e.IdentifierDeclaration)
+	case parser.DualInputOperands:
+		left, right := e.Operands()
+		walkExpressionReferences(left, visit)
+		walkExpressionReferences(right, visit)
+	case *parser.NotExpression:
+		walkExpressionReferences(e.Expression, visit)
+	case *parser.NegateExpression:
+		walkExpressionReferences(e.Expression, visit)
+	case *parser.BitwiseNotExpression:
+		walkExpressionReferences(e.Expression, visit)
+	case *parser.ConversionExpression:
+		walkExpressionReferences(e.Expression, visit)
+	case *parser.FieldAccessExpression:
+		walkExpressionReferences(e.Expression, visit)
+	case *parser.StructLiteralExpression:
+		for _, f := range e.Fields {
+			walkExpressionReferences(f.Expression, visit)
+		}
+	case *parser.IndexExpression:
+		walkExpressionReferences(e.Expression, visit)
+		walkExpressionReferences(e.Index, visit)
+	case *parser.SliceExpression:
+		walkExpressionReferences(e.Expression, visit)
+		if e.Low != nil {
+			walkExpressionReferences(e.Low, visit)
+		}
+		if e.High != nil {
+			walkExpressionReferences(e.High, visit)
+		}
+	case *parser.LenExpression:
+		walkExpressionReferences(e.Expression, visit)
+	case *parser.AppendExpression:
+		walkExpressionReferences(e.Slice, visit)
+		for _, element := range e.Elements {
+			walkExpressionReferences(element, visit)
+		}
+	case *parser.FunctionCallExpression:
+		walkExpressionReferences(e.CallSource, visit)
+		for _, param := range e.Parameters {
+			walkExpressionReferences(param, visit)
+		}
+	case *parser.FunctionLiteralExpression:
+		// It is not known whether the function literal is called while initializing, so everything it uses counts.
+		walkStatementReferences(e.FunctionDefinition.Statements, visit)
+	}
+}
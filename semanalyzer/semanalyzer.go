@@ -14,6 +14,11 @@ func (s *SemAnalyzer) Analyze(declarations []parser.Declaration, scope parser.Sc
 		return nil, err
 	}
 
+	f := FlowChecker{}
+	if err := f.Execute(declarations, scope); err != nil {
+		return nil, err
+	}
+
 	mainFunc, err := s.findMainFunction(scope)
 	if err != nil {
 		return nil, err
@@ -22,6 +27,16 @@ func (s *SemAnalyzer) Analyze(declarations []parser.Declaration, scope parser.Sc
 	return mainFunc, nil
 }
 
+// getFunctionLiterals returns the function literals in the file of the scope, which are checked apart from the functions
+// they are in.
+func getFunctionLiterals(scope parser.Scope) []*parser.FunctionLiteralExpression {
+	if fs, ok := scope.(*parser.FileScope); ok {
+		return fs.AllFunctionLiterals
+	}
+
+	return nil
+}
+
 func (s *SemAnalyzer) findMainFunction(scope parser.Scope) (*parser.FunctionDeclaration, error) {
 	decl := scope.SearchFunctionDeclaration("main")
 	if decl == nil {
@@ -1,18 +1,38 @@
 package semanalyzer
 
 import (
+	"github.com/milandamen/quisnix/lexer"
 	"github.com/milandamen/quisnix/parser"
 	"github.com/pkg/errors"
 )
 
-type Typer struct{}
+type Typer struct {
+	constantValues map[*parser.VariableDeclaration]interface{} // Values of variables known at compile time.
+}
 
 func (t *Typer) Execute(declarations []parser.Declaration, scope parser.Scope) error {
+	t.constantValues = make(map[*parser.VariableDeclaration]interface{})
+
+	// Global variables are checked first and in initialization order, so the types taken from their values are known
+	// before anything uses them.
+	globals, err := globalInitializationOrder(declarations)
+	if err != nil {
+		return err
+	}
+
+	for _, decl := range globals {
+		if err := t.checkVariableDeclaration(decl, true); err != nil {
+			return err
+		}
+	}
+
 	for _, decl := range declarations {
 		var err error
 		switch d := decl.(type) {
 		case *parser.FunctionDeclaration:
-			err = t.checkFunctionDeclaration(d, scope)
+			err = t.checkFunctionDefinition(d.FunctionDefinition, scope)
+		case *parser.TypeDeclaration:
+			err = t.checkTypeDeclaration(d)
 		}
 
 		if err != nil {
@@ -20,31 +40,207 @@ func (t *Typer) Execute(declarations []parser.Declaration, scope parser.Scope) e
 		}
 	}
 
+	// Function literals come after the functions around them, where the types of the variables they capture may only
+	// be taken from the values of those variables.
+	for _, literal := range getFunctionLiterals(scope) {
+		if err := t.checkFunctionDefinition(literal.FunctionDefinition, scope); err != nil {
+			return err
+		}
+	}
+
+	// The printer uses the order and values found here instead of computing them again.
+	if fs, ok := scope.(*parser.FileScope); ok {
+		fs.InitializationOrder = globals
+		fs.ConstantValues = t.constantValues
+	}
+
 	return nil
 }
 
-func (t *Typer) checkFunctionDeclaration(decl *parser.FunctionDeclaration, scope parser.Scope) error {
+func (t *Typer) checkFunctionDefinition(def *parser.FunctionDefinition, scope parser.Scope) error {
 	funcReturnTypes := make([]*parser.TypeDeclaration, 0)
-	for _, f := range decl.FunctionDefinition.FunctionType.ReturnTypes {
+	for _, f := range def.FunctionType.ReturnTypes {
 		funcReturnTypes = append(funcReturnTypes, f.VariableDeclaration.TypeDeclaration)
 	}
 
-	err := t.checkStatements(decl.FunctionDefinition.Statements, funcReturnTypes, scope)
+	return t.checkStatements(def.Statements, funcReturnTypes, scope)
+}
+
+// checkVariableDeclaration takes the type of a variable from its value when it was left out, and makes sure the value
+// of a constant can be computed at compile time. The values of constants and of global variables that are known at
+// compile time are kept.
+func (t *Typer) checkVariableDeclaration(decl *parser.VariableDeclaration, global bool) error {
+	if decl.Value == nil {
+		return nil
+	}
+
+	if decl.TypeDeclaration != nil {
+		parser.SetIntegerLiteralTypes(decl.Value, decl.TypeDeclaration)
+	}
+	resultTypes, err := parser.MustSingleReturnType(decl.Value)
+	if err != nil {
+		return err
+	}
+
+	if decl.TypeDeclaration == nil {
+		decl.TypeDeclaration = resultTypes[0]
+	} else if decl.TypeDeclaration != resultTypes[0] {
+		return errors.Errorf("type mismatch: expected '%s' but was given '%s' on line %d column %d",
+			decl.TypeDeclaration.Type.TypeName(), resultTypes[0].Type.TypeName(), decl.UFSourceLine(), decl.UFSourceColumn())
+	}
+
+	if !decl.Constant && !global {
+		return nil
+	}
+
+	v, ok, err := constantValue(decl.Value)
+	if err != nil {
+		return err
+	}
+	if ok {
+		t.constantValues[decl] = v
+	} else if decl.Constant {
+		return errors.Errorf("value of constant '%s' cannot be computed at compile time on line %d column %d",
+			decl.Name, decl.Value.UFSourceLine(), decl.Value.UFSourceColumn())
+	}
+
+	return nil
+}
+
+// checkTypeDeclaration makes sure a struct type does not contain itself, as it would have an infinite size.
+func (t *Typer) checkTypeDeclaration(decl *parser.TypeDeclaration) error {
+	visited := make(map[*parser.TypeDeclaration]bool)
+
+	var containsDecl func(td *parser.TypeDeclaration) bool
+	containsDecl = func(td *parser.TypeDeclaration) bool {
+		// An array holds its elements itself, while a slice only refers to them.
+		if at, ok := td.Type.(parser.ArrayType); ok {
+			return at.ElementType == decl || containsDecl(at.ElementType)
+		}
+
+		st, ok := td.Type.(parser.StructType)
+		if !ok || visited[td] {
+			return false
+		}
+		visited[td] = true
+
+		for _, f := range st.Fields {
+			fieldTd := f.VariableDeclaration.TypeDeclaration
+			if fieldTd == decl || containsDecl(fieldTd) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if containsDecl(decl) {
+		return errors.Errorf("invalid recursive type '%s' on line %d column %d",
+			decl.Type.TypeName(), decl.UFSourceLine(), decl.UFSourceColumn())
+	}
+
+	return nil
+}
+
+// checkTargetAssignment makes sure the value assigned to a field or element has the type of that field or element.
+func (t *Typer) checkTargetAssignment(target parser.Expression, exp parser.Expression, stmt parser.Node) error {
+	targetTypes, err := parser.MustSingleReturnType(target)
 	if err != nil {
 		return err
 	}
 
-	if len(funcReturnTypes) > 0 {
-		numStmts := len(decl.FunctionDefinition.Statements)
-		if numStmts != 0 {
-			stmt := decl.FunctionDefinition.Statements[numStmts-1]
-			if _, ok := stmt.(*parser.ReturnStatement); !ok {
-				return errors.Errorf("function should return values on line %d column %d",
-					decl.UFSourceLine(), decl.UFSourceColumn())
+	parser.SetIntegerLiteralTypes(exp, targetTypes[0])
+	resultTypes, err := parser.MustSingleReturnType(exp)
+	if err != nil {
+		return err
+	}
+
+	if targetTypes[0] != resultTypes[0] {
+		return errors.Errorf("type mismatch: expected '%s' but was given '%s' on line %d column %d",
+			targetTypes[0].Type.TypeName(), resultTypes[0].Type.TypeName(), stmt.UFSourceLine(), stmt.UFSourceColumn())
+	}
+
+	return nil
+}
+
+// checkMultiAssignStatement makes sure every value of the function call can be assigned to its variable.
+func (t *Typer) checkMultiAssignStatement(s *parser.MultiAssignStatement) error {
+	resultTypes, err := s.Expression.ResultingTypeDeclarations()
+	if err != nil {
+		return err
+	}
+
+	if len(resultTypes) != len(s.VariableDeclarations) {
+		values := "values"
+		if len(resultTypes) == 1 {
+			values = "value"
+		}
+		return errors.Errorf("assignment mismatch: %d variables but %d %s on line %d column %d",
+			len(s.VariableDeclarations), len(resultTypes), values, s.UFSourceLine(), s.UFSourceColumn())
+	}
+
+	for i, decl := range s.VariableDeclarations {
+		if decl == nil {
+			continue // The value is discarded.
+		}
+
+		v, ok := decl.(*parser.VariableDeclaration)
+		if !ok {
+			return errors.New("compiler error: declaration of statement should be type VariableDeclaration")
+		}
+		if v.Constant {
+			return errors.Errorf("cannot assign to constant '%s' on line %d column %d",
+				v.Name, s.UFSourceLine(), s.UFSourceColumn())
+		}
+
+		if v.TypeDeclaration != resultTypes[i] {
+			return errors.Errorf("type mismatch: expected '%s' but was given '%s' on line %d column %d",
+				v.TypeDeclaration.Type.TypeName(), resultTypes[i].Type.TypeName(), s.UFSourceLine(), s.UFSourceColumn())
+		}
+	}
+
+	return nil
+}
+
+// checkReturnStatement makes sure the returned values have the return types of the function. A single function call
+// returning multiple values can be returned as a whole.
+func (t *Typer) checkReturnStatement(sr *parser.ReturnStatement, funcReturnTypes []*parser.TypeDeclaration) error {
+	givenTypes := make([]*parser.TypeDeclaration, 0, len(sr.ReturnExpressions))
+	positions := make([]parser.Node, 0, len(sr.ReturnExpressions))
+	if len(sr.ReturnExpressions) == 1 && len(funcReturnTypes) > 1 {
+		tds, err := sr.ReturnExpressions[0].ResultingTypeDeclarations()
+		if err != nil {
+			return err
+		}
+
+		givenTypes = tds
+		for range tds {
+			positions = append(positions, sr.ReturnExpressions[0])
+		}
+	} else {
+		for i, exp := range sr.ReturnExpressions {
+			if i < len(funcReturnTypes) {
+				parser.SetIntegerLiteralTypes(exp, funcReturnTypes[i])
 			}
-		} else {
-			return errors.Errorf("function should return values on line %d column %d",
-				decl.UFSourceLine(), decl.UFSourceColumn())
+			tds, err := parser.MustSingleReturnType(exp)
+			if err != nil {
+				return err
+			}
+
+			givenTypes = append(givenTypes, tds[0])
+			positions = append(positions, exp)
+		}
+	}
+
+	if len(givenTypes) != len(funcReturnTypes) {
+		return errors.Errorf("number of return types mismatch: expected %d but was given %d on line %d column %d",
+			len(funcReturnTypes), len(givenTypes), sr.UFSourceLine(), sr.UFSourceColumn())
+	}
+
+	for i, expectedType := range funcReturnTypes {
+		if givenTypes[i] != expectedType {
+			return errors.Errorf("return type mismatch: expected '%s' but was given '%s' on line %d column %d",
+				expectedType.Type.TypeName(), givenTypes[i].Type.TypeName(), positions[i].UFSourceLine(), positions[i].UFSourceColumn())
 		}
 	}
 
@@ -52,16 +248,20 @@ func (t *Typer) checkFunctionDeclaration(decl *parser.FunctionDeclaration, scope
 }
 
 func (t *Typer) checkStatements(statements []parser.Statement, funcReturnTypes []*parser.TypeDeclaration, scope parser.Scope) error {
-	numStmts := len(statements)
-	for i, stmt := range statements {
+	for _, stmt := range statements {
 		if sv, ok := stmt.(parser.StatementHavingVariableDeclaration); ok {
 			v, ok := sv.GetVariableDeclaration().(*parser.VariableDeclaration)
 			if !ok {
 				return errors.New("compiler error: declaration of statement should be type VariableDeclaration")
 			}
+			if v.Constant {
+				return errors.Errorf("cannot assign to constant '%s' on line %d column %d",
+					v.Name, stmt.UFSourceLine(), stmt.UFSourceColumn())
+			}
 
 			switch s := stmt.(type) {
 			case *parser.AssignStatement:
+				parser.SetIntegerLiteralTypes(s.Expression, v.TypeDeclaration)
 				resultTypes, err := parser.MustSingleReturnType(s.Expression)
 				if err != nil {
 					return err
@@ -71,28 +271,21 @@ func (t *Typer) checkStatements(statements []parser.Statement, funcReturnTypes [
 					return errors.Errorf("type mismatch: expected '%s' but was given '%s' on line %d column %d",
 						v.TypeDeclaration.Type.TypeName(), resultTypes[0].Type.TypeName(), s.UFSourceLine(), s.UFSourceColumn())
 				}
-			case *parser.AddAssignStatement:
-				if v.TypeDeclaration != scope.SearchTypeDeclaration("Int") {
-					return errors.Errorf("cannot add to variable with type '%s' on line %d column %d",
-						v.TypeDeclaration.Type.TypeName(), s.UFSourceLine(), s.UFSourceColumn())
-				}
-
-				resultTypes, err := parser.MustSingleReturnType(s.Expression)
-				if err != nil {
+			case *parser.FieldAssignStatement:
+				if err := t.checkTargetAssignment(s.Target, s.Expression, s); err != nil {
 					return err
 				}
-
-				if v.TypeDeclaration != resultTypes[0] {
-					return errors.Errorf("type mismatch: expected '%s' but was given '%s' on line %d column %d",
-						v.TypeDeclaration.Type.TypeName(), resultTypes[0].Type.TypeName(), s.UFSourceLine(), s.UFSourceColumn())
+			case *parser.IndexAssignStatement:
+				if err := t.checkTargetAssignment(s.Target, s.Expression, s); err != nil {
+					return err
 				}
-			case *parser.SubtractAssignStatement:
-				if v.TypeDeclaration != scope.SearchTypeDeclaration("Int") {
-					return errors.Errorf("cannot subtract from variable with type '%s' on line %d column %d",
-						v.TypeDeclaration.Type.TypeName(), s.UFSourceLine(), s.UFSourceColumn())
+			case *parser.CompoundAssignStatement:
+				if !parser.IsNumericType(v.TypeDeclaration) {
+					return errors.Errorf("cannot use '%s=' on variable with type '%s' on line %d column %d",
+						lexer.GetTokenTypeString(s.Operator), v.TypeDeclaration.Type.TypeName(), s.UFSourceLine(), s.UFSourceColumn())
 				}
 
-				resultTypes, err := parser.MustSingleReturnType(s.Expression)
+				resultTypes, err := parser.MustSingleReturnType(s.Operation())
 				if err != nil {
 					return err
 				}
@@ -112,16 +305,51 @@ func (t *Typer) checkStatements(statements []parser.Statement, funcReturnTypes [
 						v.TypeDeclaration.Type.TypeName(), s.UFSourceLine(), s.UFSourceColumn())
 				}
 			}
-		} else if sc, ok := stmt.(parser.StatementHavingCondition); ok {
-			cond := sc.GetCondition()
-			resultTypes, err := parser.MustSingleReturnType(cond)
+		} else if s, ok := stmt.(*parser.MultiAssignStatement); ok {
+			if err := t.checkMultiAssignStatement(s); err != nil {
+				return err
+			}
+		} else if s, ok := stmt.(*parser.DiscardStatement); ok {
+			if _, err := parser.MustSingleReturnType(s.Expression); err != nil {
+				return err
+			}
+		} else if s, ok := stmt.(*parser.FunctionCallExpression); ok {
+			// Values are easily forgotten, so they can only be thrown away explicitly.
+			resultTypes, err := s.ResultingTypeDeclarations()
 			if err != nil {
 				return err
 			}
+			if len(resultTypes) != 0 {
+				return errors.Errorf("result of function call is not used on line %d column %d: assign it or discard it with '_'",
+					s.UFSourceLine(), s.UFSourceColumn())
+			}
+		} else if vd, ok := stmt.(*parser.VariableDeclaration); ok {
+			if err := t.checkVariableDeclaration(vd, false); err != nil {
+				return err
+			}
+		} else if td, ok := stmt.(*parser.TypeDeclaration); ok {
+			if err := t.checkTypeDeclaration(td); err != nil {
+				return err
+			}
+		} else if sc, ok := stmt.(parser.StatementHavingCondition); ok {
+			// The type of a variable declared in the init statement may only be known after checking its value.
+			if s, ok := stmt.(*parser.ForStatement); ok && s.Init != nil {
+				if err := t.checkStatements([]parser.Statement{s.Init}, funcReturnTypes, scope); err != nil {
+					return err
+				}
+			}
 
-			if resultTypes[0] != scope.SearchTypeDeclaration("Bool") {
-				return errors.Errorf("condition must result with type 'Bool' on line %d column %d",
-					cond.UFSourceLine(), cond.UFSourceColumn())
+			// A for statement may leave out its condition to loop forever.
+			if cond := sc.GetCondition(); cond != nil {
+				resultTypes, err := parser.MustSingleReturnType(cond)
+				if err != nil {
+					return err
+				}
+
+				if resultTypes[0] != scope.SearchTypeDeclaration("Bool") {
+					return errors.Errorf("condition must result with type 'Bool' on line %d column %d",
+						cond.UFSourceLine(), cond.UFSourceColumn())
+				}
 			}
 
 			switch s := stmt.(type) {
@@ -133,6 +361,11 @@ func (t *Typer) checkStatements(statements []parser.Statement, funcReturnTypes [
 					return err
 				}
 			case *parser.ForStatement:
+				if s.LoopAction != nil {
+					if err := t.checkStatements([]parser.Statement{s.LoopAction}, funcReturnTypes, scope); err != nil {
+						return err
+					}
+				}
 				if err := t.checkStatements(s.Statements, funcReturnTypes, scope); err != nil {
 					return err
 				}
@@ -141,28 +374,9 @@ func (t *Typer) checkStatements(statements []parser.Statement, funcReturnTypes [
 					return err
 				}
 			}
-		}
-
-		if i == numStmts-1 {
-			if sr, ok := stmt.(*parser.ReturnStatement); ok {
-				if len(sr.ReturnExpressions) != len(funcReturnTypes) {
-					return errors.Errorf("number of return types mismatch: expected %d but was given %d on line %d column %d",
-						len(funcReturnTypes), len(sr.ReturnExpressions), sr.UFSourceLine(), sr.UFSourceColumn())
-				}
-
-				for i, expectedType := range funcReturnTypes {
-					exp := sr.ReturnExpressions[i]
-					givenTypeArr, err := parser.MustSingleReturnType(exp)
-					if err != nil {
-						return err
-					}
-
-					givenType := givenTypeArr[0]
-					if givenType != expectedType {
-						return errors.Errorf("return type mismatch: expected '%s' but was given '%d' on line %d column %d",
-							expectedType.Type.TypeName(), givenType.Type.TypeName(), exp.UFSourceLine(), exp.UFSourceColumn())
-					}
-				}
+		} else if sr, ok := stmt.(*parser.ReturnStatement); ok {
+			if err := t.checkReturnStatement(sr, funcReturnTypes); err != nil {
+				return err
 			}
 		}
 	}
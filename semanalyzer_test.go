@@ -2,6 +2,7 @@ package quisnix
 
 import (
 	"bytes"
+	"math/big"
 
 	"github.com/milandamen/quisnix/semanalyzer"
 
@@ -37,6 +38,7 @@ func test(asd Int) Int {
 	var cc String;
 	a = 123 + 4;
 	b = 'b';
+	b = '\xE9';
 	cc = "abc";
 	a -= 2 + 3 * 4;
 	a++;
@@ -46,7 +48,7 @@ func test(asd Int) Int {
 `
 		tokens, err := l.Parse(bytes.NewBufferString(program))
 		Expect(err).To(Succeed())
-		Expect(len(tokens)).To(Equal(83))
+		Expect(len(tokens)).To(Equal(87))
 
 		declarations, fileScope, err := p.Parse(tokens)
 		Expect(err).To(Succeed())
@@ -56,4 +58,851 @@ func test(asd Int) Int {
 		Expect(err).To(Succeed())
 		Expect(mainFunc).ToNot(BeNil())
 	})
+	It("should fail a character literal that does not fit in a Byte", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() {
+	var b Byte;
+	b = '\u{e9}';
+}
+`: "character literal 'é' is not ASCII and does not fit in type 'Byte' on line 4 column 6",
+			`
+func main() {
+	var b Byte;
+	b = 'é';
+}
+`: "character literal 'é' is not ASCII and does not fit in type 'Byte' on line 4 column 6",
+			`
+func main() {
+	var b Byte;
+	b = '😀';
+}
+`: "character literal '😀' is not ASCII and does not fit in type 'Byte' on line 4 column 6",
+		})
+	})
+	It("should fail an integer literal that overflows its type", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+func main() Int {
+	var a Int;
+	a = 0x7FFF_FFFF;
+	return a + 3000000000;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("integer literal 3000000000 overflows type 'Int' on line 5 column 13"))
+	})
+	It("should give integer literals the integer type they are used as", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+type Pixel {
+	v Byte;
+}
+
+func half(b Byte) Byte {
+	return b >> 1;
+}
+
+func main() Int {
+	var b Byte = 255;
+	var p = Pixel{v: 200};
+	if b == 255 {
+		b += 1;
+	}
+	p.v = half(p.v) + 1;
+	return Int(b) + Int(p.v);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		mainFuncDef := declarations[2].(*parser.FunctionDeclaration).FunctionDefinition
+		tds, err := mainFuncDef.Statements[0].(*parser.VariableDeclaration).Value.ResultingTypeDeclarations()
+		Expect(err).To(Succeed())
+		Expect(tds[0]).To(Equal(fileScope.SearchTypeDeclaration("Byte")))
+	})
+	It("should fail integer literals that do not fit the integer type they are used as", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() {
+	var b Byte = 256;
+}
+`: "integer literal 256 overflows type 'Byte' on line 3 column 15",
+			`
+func main() {
+	var b Byte;
+	b = -1;
+}
+`: "integer literal -1 overflows type 'Byte' on line 4 column 6",
+			`
+func f(b Byte) {
+}
+func main() {
+	f(300);
+}
+`: "integer literal 300 overflows type 'Byte' on line 5 column 4",
+			`
+func main() Bool {
+	var b Byte;
+	return b < 256;
+}
+`: "integer literal 256 overflows type 'Byte' on line 4 column 13",
+		})
+	})
+	It("should fail mixing Int and Float without a conversion", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+func main() Int {
+	var f Float;
+	f = Float(2) * 1.5;
+	return Int(f) + f;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("cannot mix types 'Int' and 'Float' without a conversion, like Int(...), on line 5 column 16"))
+	})
+	It("should fail converting a non-numeric type", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+func main() Int {
+	return Int(true);
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("cannot convert type 'Bool' to 'Int' on line 3 column 9"))
+	})
+	It("should point at the failing condition of an else if chain", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+func main() {
+	var a Int;
+	if a < 1 {
+		a = 1;
+	} else if a == 2 {
+		a = 2;
+	} else if a + 3 {
+		a = 3;
+	}
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("condition must result with type 'Bool' on line 8 column 14"))
+	})
+	It("should accept functions that return on every path", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+func sign(n Int) Int {
+	if n < 0 {
+		return 0 - 1;
+	} else if n == 0 {
+		return 0;
+	} else {
+		return 1;
+	}
+}
+func firstDivisor(n Int) Int {
+	var d = 2;
+	for ;; {
+		if n % d == 0 {
+			return d;
+		}
+		d++;
+	}
+}
+func three() Int {
+	while true {
+		return 3;
+	}
+}
+func firstMultiple(n Int, d Int) Int {
+	while true {
+		if n % d == 0 {
+			return n;
+		}
+		n++;
+	}
+}
+func clamp(n Int) Int {
+	if n > 100 {
+		return 100;
+	}
+	return n;
+}
+func log(n Int) {
+	if n < 0 {
+		return;
+	}
+}
+func main() {
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+	})
+	It("should fail functions that do not return on every path or have unreachable code", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func f(n Int) Int {
+	if n < 0 {
+		return 0;
+	}
+}
+`: "function should return values on line 2 column 1",
+			`
+func f(n Int) Int {
+	while true {
+		if n > 10 {
+			break;
+		}
+		n++;
+	}
+}
+`: "function should return values on line 2 column 1",
+			`
+func f(n Int) {
+	while true {
+		n++;
+	}
+	n--;
+}
+`: "unreachable code on line 6 column 2",
+			`
+func f(n Int) Int {
+	if n < 0 {
+		return 0;
+	} else if n > 0 {
+		return 1;
+	}
+}
+`: "function should return values on line 2 column 1",
+			`
+func f(n Int) Int {
+	while n > 0 {
+		return n;
+	}
+}
+`: "function should return values on line 2 column 1",
+			`
+func f(n Int) Int {
+	return n;
+	n++;
+}
+`: "unreachable code on line 4 column 2",
+			`
+func f(n Int) Int {
+	if n < 0 {
+		return 0;
+	} else {
+		return 1;
+	}
+	return 2;
+}
+`: "unreachable code on line 8 column 2",
+			`
+func f(n Int) {
+	for ;; {
+		return;
+	}
+	n++;
+}
+`: "unreachable code on line 6 column 2",
+			`
+func f(n Int) Int {
+	if n < 0 {
+		return 1.5;
+	}
+	return n;
+}
+`: "return type mismatch: expected 'Int' but was given 'Float' on line 4 column 10",
+		})
+	})
+	It("should fail break and continue statements outside their loop", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() {
+	break;
+}
+`: "break outside of a loop on line 3 column 2",
+			`
+func main() {
+	if true {
+		continue;
+	}
+}
+`: "continue outside of a loop on line 4 column 3",
+			`
+func main() {
+	var running = true;
+	outer: while running {
+	}
+	while true {
+		break outer;
+	}
+}
+`: "break refers to label 'outer' that is not on a surrounding loop on line 7 column 3",
+			`
+func main() {
+	while true {
+		continue;
+		break;
+	}
+}
+`: "unreachable code on line 5 column 3",
+			`
+func f() Int {
+	for ;; {
+		if true {
+			break;
+		}
+	}
+}
+`: "function should return values on line 2 column 1",
+		})
+	})
+	It("should fail a struct that contains itself", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+type A { b B; }
+type B { c C; }
+type C { a A; }
+
+func main() {
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("invalid recursive type 'A' on line 2 column 1"))
+	})
+	It("should fail invalid uses of arrays and slices", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() Int {
+	var a [3]Int;
+	return a[3];
+}
+`: "index 3 out of range on line 4 column 11",
+			`
+func main() Int {
+	var s []Int;
+	return s[1.5];
+}
+`: "index must be of type 'Int' but was given 'Float' on line 4 column 11",
+			`
+func main() {
+	var a [3]Int;
+	var s []Int;
+	s = a[1:4];
+}
+`: "slice bound 4 out of range on line 5 column 10",
+			`
+func main() {
+	var s []Int;
+	s = append(s, 1.5);
+}
+`: "type mismatch: expected 'Int' but was given 'Float' on line 4 column 16",
+			`
+func main() {
+	var a [3]Int;
+	a = append(a, 1);
+}
+`: "can only append to a slice, type '[3]Int' given on line 4 column 6",
+			`
+func main() Int {
+	var a Int;
+	return len(a);
+}
+`: "cannot get the length of type 'Int' on line 4 column 9",
+			`
+func main() {
+	var a [3]Int;
+	a[0] = 'c';
+}
+`: "type mismatch: expected 'Int' but was given 'Byte' on line 4 column 2",
+			`
+type A { b [2]A; }
+func main() {
+}
+`: "invalid recursive type 'A' on line 2 column 1",
+		})
+	})
+	It("should fail invalid variables and constants in the file scope", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+var a = f();
+func f() Int { return b; }
+var b = a + 1;
+func main() {
+}
+`: "initialization cycle for 'a' on line 2 column 1: 'a' refers to 'f', 'f' refers to 'b', 'b' refers to 'a'",
+			`
+const a = b;
+const b = a;
+func main() {
+}
+`: "initialization cycle for 'a' on line 2 column 1: 'a' refers to 'b', 'b' refers to 'a'",
+			`
+const a = f();
+func f() Int { return 1; }
+func main() {
+}
+`: "value of constant 'a' cannot be computed at compile time on line 2 column 12",
+			`
+const a = 1;
+func main() {
+	a++;
+}
+`: "cannot assign to constant 'a' on line 4 column 2",
+			`
+var a Float = 1;
+func main() {
+}
+`: "type mismatch: expected 'Float' but was given 'Int' on line 2 column 1",
+			`
+const a = 2147483647;
+const b = a + 1;
+func main() {
+}
+`: "constant 2147483648 overflows type 'Int' on line 3 column 13",
+			`
+const a = 10 / (a2 - 2);
+const a2 = 2;
+func main() {
+}
+`: "division by zero on line 2 column 14",
+		})
+	})
+	It("should order the variables and constants in the file scope and compute their values", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+var total = limit * 2;
+const limit Int = 10;
+var count Int = next();
+
+func next() Int {
+	return total + 1;
+}
+
+func main() Int {
+	return count;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		totalDecl := declarations[0].(*parser.VariableDeclaration)
+		limitDecl := declarations[1].(*parser.VariableDeclaration)
+		countDecl := declarations[2].(*parser.VariableDeclaration)
+		Expect(fileScope.InitializationOrder).To(Equal([]*parser.VariableDeclaration{limitDecl, totalDecl, countDecl}))
+		Expect(fileScope.ConstantValues).To(HaveLen(2))
+		Expect(fileScope.ConstantValues[limitDecl]).To(Equal(big.NewInt(10)))
+		Expect(fileScope.ConstantValues[totalDecl]).To(Equal(big.NewInt(20)))
+	})
+	It("should take the type of a variable from its value", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+func main() Float {
+	var a = 1.5;
+	for var i = 0; i < 3; i++ {
+		a += Float(i);
+	}
+	return a;
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).To(Succeed())
+
+		mainFuncDef := declarations[0].(*parser.FunctionDeclaration).FunctionDefinition
+		Expect(mainFuncDef.Statements[0].(*parser.VariableDeclaration).TypeDeclaration).To(Equal(fileScope.SearchTypeDeclaration("Float")))
+		forStmt := mainFuncDef.Statements[1].(*parser.ForStatement)
+		Expect(forStmt.Init.(*parser.VariableDeclaration).TypeDeclaration).To(Equal(fileScope.SearchTypeDeclaration("Int")))
+	})
+	It("should fail a variable with a value of another type", func() {
+		l := lexer.Lexer{}
+		p := parser.Parser{}
+		a := semanalyzer.SemAnalyzer{}
+
+		program := `
+func main() {
+	var a Int = 'c';
+}
+`
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).ToNot(Succeed())
+		Expect(err.Error()).To(Equal("type mismatch: expected 'Int' but was given 'Byte' on line 3 column 2"))
+	})
+	It("should fail prefix operators on the wrong type", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() {
+	var a = -true;
+}
+`: "cannot negate type 'Bool' on line 3 column 10",
+			`
+func main() {
+	var a = !1;
+}
+`: "can only use 'not' operator on type Bool, type Int given on line 3 column 10",
+			`
+const a = -2147483647 - 2;
+func main() {
+}
+`: "constant -2147483649 overflows type 'Int' on line 2 column 23",
+		})
+	})
+	It("should fail integer operators on the wrong type or with invalid constants", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() {
+	var a = 1.5 % 2.0;
+}
+`: "operator '%' is only defined for integer types, type 'Float' given on line 3 column 14",
+			`
+func main() {
+	var a = ~true;
+}
+`: "operator '~' is only defined for integer types, type 'Bool' given on line 3 column 10",
+			`
+func main() {
+	var a = 3;
+	a <<= 1.0;
+}
+`: "cannot mix types 'Int' and 'Float' without a conversion, like Int(...), on line 4 column 4",
+			`
+const a = 7 % (3 - 3);
+func main() {
+}
+`: "division by zero on line 2 column 13",
+			`
+const a = 1 << -1;
+func main() {
+}
+`: "negative shift count -1 on line 2 column 13",
+			`
+const a = 1 << 31;
+func main() {
+}
+`: "constant 2147483648 overflows type 'Int' on line 2 column 13",
+		})
+	})
+	It("should fail compound assignments on the wrong type", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() {
+	var a = true;
+	a += false;
+}
+`: "cannot use '+=' on variable with type 'Bool' on line 4 column 2",
+			`
+func main() {
+	var a = 1.5;
+	a %= 2.0;
+}
+`: "operator '%' is only defined for integer types, type 'Float' given on line 4 column 4",
+			`
+func main() {
+	var a = 3;
+	a /= 2.0;
+}
+`: "cannot mix types 'Int' and 'Float' without a conversion, like Int(...), on line 4 column 4",
+		})
+	})
+	It("should fail using multiple values where they do not fit", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func main() {
+	var q = divmod(7, 2);
+}
+`: "function call with 2 values used as a single value on line 6 column 16",
+			`
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func main() {
+	var q Int;
+	var r Int;
+	var s Int;
+	q, r, s = divmod(7, 2);
+}
+`: "assignment mismatch: 3 variables but 2 values on line 9 column 2",
+			`
+func main() {
+	var q Int;
+	var r Int;
+	q, r = 3;
+}
+`: "assignment mismatch: 2 variables but 1 value on line 5 column 2",
+			`
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func main() {
+	var q Int;
+	var r Float;
+	q, r = divmod(7, 2);
+}
+`: "type mismatch: expected 'Float' but was given 'Int' on line 8 column 2",
+			`
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func f() (Int, Float) {
+	return divmod(7, 2);
+}
+`: "return type mismatch: expected 'Float' but was given 'Int' on line 6 column 15",
+			`
+func f() (Int, Int) {
+	return 1;
+}
+`: "number of return types mismatch: expected 2 but was given 1 on line 3 column 2",
+		})
+	})
+	It("should fail call statements with unused or wrong values", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func twice(n Int) Int {
+	return n * 2;
+}
+func main() {
+	twice(3);
+}
+`: "result of function call is not used on line 6 column 2: assign it or discard it with '_'",
+			`
+func divmod(a Int, b Int) (Int, Int) {
+	return a / b, a % b;
+}
+func main() {
+	divmod(7, 2);
+}
+`: "result of function call is not used on line 6 column 2: assign it or discard it with '_'",
+			`
+func log(n Int) {
+}
+func main() {
+	log(true);
+}
+`: "parameter type mismatch: expected 'Int' but was given 'Bool' on line 5 column 6",
+			`
+func log(n Int) {
+}
+func main() {
+	_ = log(1);
+}
+`: "function call without a value used as a value on line 5 column 9",
+		})
+	})
+	It("should fail calling values that are not functions or using functions of another type", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() Int {
+	var a Int = 1;
+	return a(2);
+}
+`: "cannot call value of type 'Int' as a function on line 4 column 10",
+			`
+func isZero(n Int) Bool {
+	return n == 0;
+}
+func main() Int {
+	var f func(Int) Int = isZero;
+	return f(0);
+}
+`: "type mismatch: expected 'func(Int) Int' but was given 'func(Int) Bool' on line 6 column 2",
+			`
+func apply(f func(Int) Int, n Int) Int {
+	return f(n);
+}
+func main() Int {
+	return apply(main, 1);
+}
+`: "parameter type mismatch: expected 'func(Int) Int' but was given 'func() Int' on line 6 column 15",
+			`
+func main() Int {
+	var f func(Int) Int;
+	return f(true);
+}
+`: "parameter type mismatch: expected 'Int' but was given 'Bool' on line 4 column 11",
+		})
+	})
+	It("should check the statements of function literals apart from the functions around them", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() Int {
+	var f = func(n Int) Int {
+		if n > 0 {
+			return n;
+		}
+	};
+	return f(1);
+}
+`: "function should return values on line 3 column 10",
+			`
+func main() Int {
+	var f = func() Int {
+		return true;
+	};
+	return f();
+}
+`: "return type mismatch: expected 'Int' but was given 'Bool' on line 4 column 10",
+			`
+func main() {
+	while true {
+		var f = func() {
+			break;
+		};
+	}
+}
+`: "break outside of a loop on line 5 column 4",
+			`
+func main() {
+	var total = 1.5;
+	var add = func(n Int) {
+		total += n;
+	};
+}
+`: "cannot mix types 'Float' and 'Int' without a conversion, like Float(...), on line 5 column 9",
+		})
+	})
+	It("should fail using fields that do not exist or have another type", func() {
+		expectAnalyzeErrors(map[string]string{
+			`
+func main() Int {
+	var p Point;
+	return p.z;
+}
+type Point { x Int; y Int; }
+`: "type 'Point' has no field 'z' on line 4 column 11",
+			`
+func main() {
+	var p Point;
+	p = Point{x: 1, y: 'c'};
+}
+type Point { x Int; y Int; }
+`: "type mismatch: expected 'Int' but was given 'Byte' for field 'y' on line 4 column 18",
+			`
+func main() {
+	var p Point;
+	p.x = 1.5;
+}
+type Point { x Int; y Int; }
+`: "type mismatch: expected 'Int' but was given 'Float' on line 4 column 2",
+			`
+func main() {
+	var a Int;
+	a.x = 1;
+}
+`: "type 'Int' has no fields, cannot access field 'x' on line 4 column 4",
+		})
+	})
 })
+
+func expectAnalyzeErrors(programs map[string]string) {
+	l := lexer.Lexer{}
+	p := parser.Parser{}
+	a := semanalyzer.SemAnalyzer{}
+
+	for program, message := range programs {
+		tokens, err := l.Parse(bytes.NewBufferString(program))
+		Expect(err).To(Succeed())
+
+		declarations, fileScope, err := p.Parse(tokens)
+		Expect(err).To(Succeed())
+
+		_, err = a.Analyze(declarations, fileScope)
+		Expect(err).ToNot(Succeed(), program)
+		Expect(err.Error()).To(Equal(message), program)
+	}
+}